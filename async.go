@@ -0,0 +1,40 @@
+package cacheable
+
+import "context"
+
+// defaultAsyncConcurrency GetAsync默认的最大并发数
+const defaultAsyncConcurrency = 64
+
+// Result GetAsync的返回结果
+type Result[T any] struct {
+	Value  T
+	Err    error
+	Cached bool
+}
+
+// WithAsyncConcurrency 设置GetAsync同时执行的最大数量，超过时排队等待，默认64
+func WithAsyncConcurrency(n int) ManagerOption {
+	return func(m *CacheManager) {
+		m.asyncConcurrency = n
+	}
+}
+
+// GetAsync 在goroutine中执行Get，结果通过channel返回，适合同时发起多个互不依赖的读取后统一等待。
+// 同时执行的数量受WithAsyncConcurrency限制，channel总是恰好收到一个结果，调用方不读取也不会导致goroutine泄漏。
+// 排队期间ctx被取消时返回ctx.Err()
+func GetAsync[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func() (T, error), opts ...Option) <-chan Result[T] {
+	result := make(chan Result[T], 1)
+	go func() {
+		select {
+		case cacheManager.asyncSem <- struct{}{}:
+		case <-ctx.Done():
+			result <- Result[T]{Err: ctx.Err()}
+			return
+		}
+		defer func() { <-cacheManager.asyncSem }()
+
+		value, err, cached := Get(ctx, cacheManager, namespace, key, fn, opts...)
+		result <- Result[T]{Value: value, Err: err, Cached: cached}
+	}()
+	return result
+}
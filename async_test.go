@@ -0,0 +1,94 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAsync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("并发获取并统一等待", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		results := make([]<-chan Result[string], 0, 10)
+		for n := 0; n < 10; n++ {
+			n := n
+			results = append(results, GetAsync(ctx, cacheManager, namespace, fmt.Sprintf("async_%d", n), func() (string, error) {
+				return fmt.Sprintf("value_%d", n), nil
+			}))
+		}
+		for n, result := range results {
+			r := <-result
+			assert.NoError(t, r.Err)
+			assert.False(t, r.Cached)
+			assert.Equal(t, fmt.Sprintf("value_%d", n), r.Value)
+		}
+
+		r := <-GetAsync(ctx, cacheManager, namespace, "async_0", func() (string, error) {
+			return "new value", nil
+		})
+		assert.True(t, r.Cached)
+		assert.Equal(t, "value_0", r.Value)
+	})
+
+	t.Run("返回fn的错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		expectedErr := errors.New("fn error")
+		r := <-GetAsync(ctx, cacheManager, namespace, "async_error", func() (string, error) {
+			return "", expectedErr
+		})
+		assert.ErrorIs(t, r.Err, expectedErr)
+	})
+
+	t.Run("限制并发数", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithAsyncConcurrency(2))
+		var running, maxRunning atomic.Int32
+		results := make([]<-chan Result[int], 0, 6)
+		for n := 0; n < 6; n++ {
+			n := n
+			results = append(results, GetAsync(ctx, cacheManager, namespace, fmt.Sprintf("async_limit_%d", n), func() (int, error) {
+				current := running.Add(1)
+				defer running.Add(-1)
+				for {
+					prev := maxRunning.Load()
+					if current <= prev || maxRunning.CompareAndSwap(prev, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return n, nil
+			}))
+		}
+		for _, result := range results {
+			assert.NoError(t, (<-result).Err)
+		}
+		assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	})
+
+	t.Run("排队时ctx取消", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithAsyncConcurrency(1))
+		started, release := make(chan struct{}), make(chan struct{})
+		blocking := GetAsync(ctx, cacheManager, namespace, "async_blocking", func() (string, error) {
+			close(started)
+			<-release
+			return "value", nil
+		})
+		<-started
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		queued := GetAsync(cancelCtx, cacheManager, namespace, "async_queued", func() (string, error) {
+			return "value", nil
+		})
+		cancel()
+		assert.ErrorIs(t, (<-queued).Err, context.Canceled)
+
+		close(release)
+		assert.NoError(t, (<-blocking).Err)
+	})
+}
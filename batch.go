@@ -0,0 +1,110 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// getMany 读取多个store中的key，返回值与keys一一对应，不存在的key对应nil。
+// store实现MultiGetter时一次读取，否则逐个读取
+func (i *CacheManager) getMany(ctx context.Context, keys []string) ([]any, error) {
+	if getter, ok := i.cache.(MultiGetter); ok {
+		return getter.GetMany(ctx, keys)
+	}
+	values := make([]any, len(keys))
+	for n, key := range keys {
+		value, err := i.cache.Get(ctx, key)
+		if errors.Is(err, store.NotFound{}) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		values[n] = value
+	}
+	return values, nil
+}
+
+// setMany 写入多个store中已经编码的值，store实现MultiSetter时一次写入，否则逐个写入。
+// 与storeSet相同，按WithRetry重试并经过WithCircuitBreaker的熔断器。store的批量写入无法维护tag，调用方需要逐个写入带tag的值
+func (i *CacheManager) setMany(ctx context.Context, keys []string, values [][]byte, expiration time.Duration) error {
+	setter, ok := i.cache.(MultiSetter)
+	if !ok {
+		for n, key := range keys {
+			if err := i.storeSet(ctx, key, values[n], store.WithExpiration(expiration)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return i.guardStore(ctx, func() error {
+		return i.retry(ctx, func() error {
+			return setter.SetMany(ctx, keys, values, expiration)
+		})
+	})
+}
+
+// setBatch 与set相同，写入namespace下的多个key，keys为store中的key。
+// 不带tag且有效期相同的值通过setMany一次写入，其余的逐个写入。
+// 超过WithMaxValueSize的值跳过，返回第一个其他错误
+func (i *CacheManager) setBatch(ctx context.Context, namespace string, keys []string, values [][]byte, options *Options, strict bool) error {
+	if _, ok := i.cache.(MultiSetter); !ok || options.ifAbsent {
+		var firstErr error
+		for n, key := range keys {
+			o := *options
+			if err := i.set(ctx, namespace, key, values[n], &o, nil, strict); err != nil && !errors.Is(err, ErrValueTooLarge) && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	//设置了WithExpirationJitter时每个key的有效期不同，按有效期分组
+	groups := make(map[time.Duration][]*preparedSet)
+	var order []time.Duration
+	for n, key := range keys {
+		o := *options
+		p, err := i.prepareSet(ctx, namespace, key, values[n], &o)
+		if p == nil {
+			if !errors.Is(err, ErrValueTooLarge) {
+				record(err)
+			}
+			continue
+		}
+		if len(p.written.Tags) > 0 {
+			record(i.writeSet(ctx, namespace, p, false, nil, strict))
+			continue
+		}
+		if _, ok := groups[p.written.Expiration]; !ok {
+			order = append(order, p.written.Expiration)
+		}
+		groups[p.written.Expiration] = append(groups[p.written.Expiration], p)
+	}
+
+	for _, expiration := range order {
+		group := groups[expiration]
+		batchKeys := make([]string, len(group))
+		batchValues := make([][]byte, len(group))
+		for n, p := range group {
+			batchKeys[n], batchValues[n] = p.key, p.stored
+		}
+		start := time.Now()
+		err := i.setMany(ctx, batchKeys, batchValues, expiration)
+		if errors.Is(err, errCircuitOpen) {
+			continue
+		}
+		i.metrics.storeDuration(namespace, time.Since(start))
+		for _, p := range group {
+			record(i.afterSet(ctx, namespace, p, err, time.Since(start), strict))
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,99 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilities(t *testing.T) {
+	redisStore, _ := newTestRedisStore(t)
+	assert.Equal(t, Capabilities{
+		MultiGet:    true,
+		MultiSet:    true,
+		MultiDelete: true,
+		TagKeys:     true,
+		KeyScan:     true,
+		TTLRead:     true,
+		TTLUpdate:   true,
+		Lock:        true,
+		SetIfAbsent: true,
+		Tags:        true,
+	}, NewCacheManager(redisStore).Capabilities())
+
+	goCacheCapabilities := newTestGoCacheManager(t).Capabilities()
+	assert.False(t, goCacheCapabilities.MultiGet)
+	assert.True(t, goCacheCapabilities.KeyScan)
+
+	assert.Equal(t, Capabilities{}, NewCacheManager(&tagslessStore{newGoCacheStore()}).Capabilities())
+	assert.Equal(t, Capabilities{TagKeys: true, KeyScan: true, Tags: true}, NewCacheManager(newTagslessScanStore(), WithTagIndex()).Capabilities())
+}
+
+func TestGetManySetMany(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"redis":    NewCacheManager(redisStore),
+		"go-cache": newTestGoCacheManager(t),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			keys := []string{cacheManager.buildKey(namespace, "many1"), cacheManager.buildKey(namespace, "many2")}
+			err := cacheManager.setMany(ctx, keys, [][]byte{[]byte("value1"), []byte("value2")}, time.Minute)
+			assert.NoError(t, err)
+
+			values, err := cacheManager.getMany(ctx, append(keys, cacheManager.buildKey(namespace, "many_missing")))
+			assert.NoError(t, err)
+			assert.Len(t, values, 3)
+			for n, expected := range []string{"value1", "value2"} {
+				value, err := toBytes(values[n])
+				assert.NoError(t, err)
+				assert.Equal(t, expected, string(value))
+			}
+			assert.Nil(t, values[2])
+
+			// setBatch编码后写入，可以通过Get读取
+			batchKeys := []string{cacheManager.buildKey(namespace, "batch1"), cacheManager.buildKey(namespace, "batch2")}
+			err = cacheManager.setBatch(ctx, namespace, batchKeys, [][]byte{[]byte(`"value1"`), []byte(`"value2"`)}, &Options{}, true)
+			assert.NoError(t, err)
+			for key, expected := range map[string]string{"batch1": "value1", "batch2": "value2"} {
+				value, err, cached := Get(ctx, cacheManager, namespace, key, func() (string, error) {
+					return "", nil
+				})
+				assert.NoError(t, err)
+				assert.True(t, cached)
+				assert.Equal(t, expected, value)
+			}
+
+			// 带tag时逐个写入，tag正常维护
+			tagged := []string{cacheManager.buildKey(namespace, "many_tagged")}
+			err = cacheManager.setBatch(ctx, namespace, tagged, [][]byte{[]byte(`"value"`)}, &Options{Tags: []string{"many_tag"}}, true)
+			assert.NoError(t, err)
+			assert.NoError(t, cacheManager.DeleteByTags(ctx, []string{"many_tag"}))
+			values, err = cacheManager.getMany(ctx, tagged)
+			assert.NoError(t, err)
+			assert.Nil(t, values[0])
+		})
+	}
+}
+
+func TestSetManyCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	redisStore, mr := newTestRedisStore(t)
+	s := &countingSetStore{RedisStore: redisStore}
+	cacheManager := NewCacheManager(s, WithCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, OpenTimeout: time.Minute}))
+	keys := []string{cacheManager.buildKey(namespace, "breaker1"), cacheManager.buildKey(namespace, "breaker2")}
+	values := [][]byte{[]byte("value1"), []byte("value2")}
+
+	mr.SetError("down")
+	assert.Error(t, cacheManager.setMany(ctx, keys, values, time.Minute))
+	assert.Equal(t, CircuitOpen, cacheManager.CircuitState())
+
+	// 熔断器断开后不再访问store
+	mr.SetError("")
+	assert.ErrorIs(t, cacheManager.setMany(ctx, keys, values, time.Minute), errCircuitOpen)
+	assert.Equal(t, int32(1), s.setManys.Load())
+}
@@ -0,0 +1,18 @@
+package cacheable
+
+// WithBypassCache 不读取也不写入缓存，每次都直接调用fn，不经过singleflight合并，用于测试fn本身。
+// 不记录cache_requests_total等读取相关的指标，fn的耗时仍然记录到cache_fetch_duration_seconds
+func WithBypassCache() Option {
+	return func(o *Options) {
+		o.bypassCache = true
+		o.NoCache = true
+	}
+}
+
+// WithReadOnly 只读取缓存，从不调用fn，缓存不存在时返回ErrNotFound，用于测试缓存本身的行为。
+// 过期的旧值（WithStaleWhileRevalidate、WithServeStaleOnError）直接返回，不会后台刷新
+func WithReadOnly() Option {
+	return func(o *Options) {
+		o.readOnly = true
+	}
+}
@@ -0,0 +1,114 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithBypassCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("每次都调用fn且不写入缓存", func(t *testing.T) {
+		ns := "bypass"
+		cacheManager := newTestGoCacheManager(t)
+		assert.NoError(t, Set(ctx, cacheManager, ns, "key", "cached"))
+		requests := testutil.ToFloat64(CacheRequestTotal.WithLabelValues(ns))
+		fetches := histogramOf(t, CacheFetchDuration, ns).GetSampleCount()
+
+		calls := 0
+		for n := 0; n < 2; n++ {
+			value, err, meta := GetWithMeta(ctx, cacheManager, ns, "key", func() (string, error) {
+				calls++
+				return "fresh", nil
+			}, WithBypassCache())
+			assert.NoError(t, err)
+			assert.Equal(t, "fresh", value)
+			assert.False(t, meta.Cached)
+			assert.True(t, meta.Computed)
+		}
+		assert.Equal(t, 2, calls)
+
+		// 没有读取缓存，只记录了fn的耗时
+		assert.Equal(t, requests, testutil.ToFloat64(CacheRequestTotal.WithLabelValues(ns)))
+		assert.Equal(t, fetches+2, histogramOf(t, CacheFetchDuration, ns).GetSampleCount())
+
+		value, err, cached := Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "", errors.New("should not be called")
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "cached", value)
+	})
+
+	t.Run("不写入负缓存", func(t *testing.T) {
+		ns := "bypass_negative"
+		cacheManager := newTestGoCacheManager(t)
+		_, err, _ := Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "", ErrNotFound
+		}, WithBypassCache(), WithNegativeCache(time.Minute))
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		exists, err := Exists(ctx, cacheManager, ns, "key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestWithReadOnly(t *testing.T) {
+	ctx := context.Background()
+	fail := func() (string, error) {
+		return "", errors.New("should not be called")
+	}
+
+	t.Run("缓存不存在时返回ErrNotFound", func(t *testing.T) {
+		ns := "read_only"
+		cacheManager := newTestGoCacheManager(t)
+		misses := testutil.ToFloat64(CacheMissTotal.WithLabelValues(ns))
+
+		_, err, meta := GetWithMeta(ctx, cacheManager, ns, "key", fail, WithReadOnly())
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.False(t, meta.Cached)
+		assert.False(t, meta.Computed)
+		assert.Equal(t, misses+1, testutil.ToFloat64(CacheMissTotal.WithLabelValues(ns)))
+
+		exists, err := Exists(ctx, cacheManager, ns, "key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("缓存存在时返回缓存", func(t *testing.T) {
+		ns := "read_only_hit"
+		cacheManager := newTestGoCacheManager(t)
+		assert.NoError(t, Set(ctx, cacheManager, ns, "key", "cached"))
+
+		value, err, cached := Get(ctx, cacheManager, ns, "key", fail, WithReadOnly())
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "cached", value)
+	})
+
+	t.Run("过期的旧值不会后台刷新", func(t *testing.T) {
+		ns := "read_only_stale"
+		now := time.Now()
+		cacheManager := newTestGoCacheManager(t, WithClock(func() time.Time { return now }))
+		assert.NoError(t, Set(ctx, cacheManager, ns, "key", "old", WithExpiration(time.Minute), WithStaleWhileRevalidate(time.Hour)))
+		now = now.Add(2 * time.Minute)
+
+		var calls atomic.Int32
+		value, err, meta := GetWithMeta(ctx, cacheManager, ns, "key", func() (string, error) {
+			calls.Add(1)
+			return "new", nil
+		}, WithReadOnly(), WithStaleWhileRevalidate(time.Hour))
+		assert.NoError(t, err)
+		assert.True(t, meta.Stale)
+		assert.Equal(t, "old", value)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+}
@@ -2,117 +2,908 @@ package cacheable
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/eko/gocache/lib/v4/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/singleflight"
+	"hash"
+	"log/slog"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var defaultKeyPrefix = "cacheable"
-var defaultExpiration = 60 * time.Minute
-var defaultMetricsPrefix = "cacheable"
+// defaultKeyPrefix、defaultExpiration、defaultMetricsPrefix 可能在读写缓存或创建CacheManager的同时被修改，使用原子变量，读取时不加锁
+var defaultKeyPrefix atomic.Pointer[string]
+var defaultExpiration atomic.Int64
+var defaultMetricsPrefix atomic.Pointer[string]
+
+// cacheEpoch 全局的缓存纪元，会拼接到每个key中，修改后之前写入的缓存全部无法读取，等待自然过期。
+// 启动时可以通过环境变量CACHEABLE_EPOCH设置
+var cacheEpoch atomic.Int64
+
+func init() {
+	SetDefaultKeyPrefix("cacheable")
+	SetDefaultExpiration(60 * time.Minute)
+	SetDefaultMetricsPrefix("cacheable")
+
+	if v := os.Getenv("CACHEABLE_EPOCH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cacheEpoch.Store(int64(n))
+		}
+	}
+}
 
 type CacheManager struct {
 	sg    singleflight.Group
 	cache store.StoreInterface
+	// sgKeyFunc 为空时按store中的key合并，见WithSingleflightKeyFunc
+	sgKeyFunc func(namespace, key string) string
+
+	// prefix 为空时使用全局的defaultKeyPrefix
+	prefix string
+	// tenantFunc 为空时不区分租户，见WithTenantFromContext
+	tenantFunc    func(ctx context.Context) string
+	defaultTenant string
+	// keyBuilder 为空时使用prefix:namespace:key，见WithKeyBuilder
+	keyBuilder       func(prefix, namespace, key string) string
+	namespaceHashTag bool
+	// expiration 为0时使用全局的defaultExpiration
+	expiration       time.Duration
+	expirationJitter time.Duration
+	autoHashKeyAbove int
+	maxValueSize     int
+	keyHashFunc      func() hash.Hash
+
+	asyncUnlink       bool
+	valueSizeMetrics  bool
+	decodeRetry       bool
+	noCacheOnTagPanic bool
+	typeCheck         bool
+	strictSet         bool
+	hooks             Hooks
+	metrics           metricsRecorder
+	// ownMetrics 为true时创建后单独构造指标并注册到registry，见WithRegistry
+	ownMetrics    bool
+	registry      prometheus.Registerer
+	metricsPrefix string
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	clock         func() time.Time
+	retryAttempts int
+	retryBackoff  time.Duration
+	breaker       *circuitBreaker
+	tracer        trace.Tracer
+	observer      func(ev Event)
+
+	// encryptionKeys 第一个用于加密，全部用于解密，见WithEncryption
+	encryptionKeys []*encryptionKey
+	checksum       bool
+	entryAge       bool
+
+	tagIndex bool
+
+	tagShards    int
+	tagShardFunc func(key string) uint32
+
+	// namespace -> compressionConfig
+	namespaceCompression sync.Map
+	// namespace -> NamespaceConfig
+	namespaces sync.Map
+
+	asyncConcurrency int
+	asyncSem         chan struct{}
+
+	hitRatioInterval time.Duration
+	hitRatioSampler  *hitRatioSampler
+	closeOnce        sync.Once
+
+	maxInflight     int
+	maxInflightWait time.Duration
+	flightMu        sync.Mutex
+	// flightSem 每个正在计算的key占用一个位置
+	flightSem chan struct{}
+	// flights key -> 等待该key的请求数
+	flights map[string]int
+
+	trackInflight bool
+	inflightMu    sync.Mutex
+	// inflight key -> 该key正在进行的计算
+	inflight map[string]map[*inflightCall]struct{}
+
+	// pubSub 广播删除消息，见WithInvalidationChannel
+	pubSub      PubSub
+	instanceID  string
+	unsubscribe func()
 }
 
-func NewCacheManager(store store.StoreInterface) *CacheManager {
-	return &CacheManager{
-		sg:    singleflight.Group{},
-		cache: store,
+func NewCacheManager(store store.StoreInterface, opts ...ManagerOption) *CacheManager {
+	initMetrics()
+	m := &CacheManager{
+		sg:      singleflight.Group{},
+		cache:   store,
+		metrics: promMetrics{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.ownMetrics {
+		m.initOwnMetrics()
 	}
+	if _, ok := store.(KeyScanner); m.tagIndex && !ok {
+		panic("cacheable: WithTagIndex requires a store implementing KeyScanner")
+	}
+	if m.asyncConcurrency <= 0 {
+		m.asyncConcurrency = defaultAsyncConcurrency
+	}
+	m.asyncSem = make(chan struct{}, m.asyncConcurrency)
+	if m.maxInflight > 0 {
+		m.flightSem = make(chan struct{}, m.maxInflight)
+	}
+	if m.hitRatioInterval > 0 {
+		metrics := defaultCacheMetrics
+		if p, ok := m.metrics.(promMetrics); ok {
+			metrics = p.set()
+		}
+		m.hitRatioSampler = startHitRatioSampler(m.hitRatioInterval, metrics)
+	}
+	if m.pubSub != nil {
+		m.subscribeInvalidations()
+	}
+	return m
+}
+
+// Close 停止CacheManager启动的后台任务，不会关闭store，可以重复调用
+func (i *CacheManager) Close() error {
+	i.closeOnce.Do(func() {
+		if i.hitRatioSampler != nil {
+			i.hitRatioSampler.close()
+		}
+		if i.unsubscribe != nil {
+			i.unsubscribe()
+		}
+	})
+	return nil
+}
+
+// Store 返回CacheManager使用的store，用于执行CacheManager没有封装的store操作。
+// 直接操作store不经过key拼接、编码、singleflight、指标和hook，写入的值需要自行保证能被Get读取，谨慎使用
+func (i *CacheManager) Store() store.StoreInterface {
+	return i.cache
+}
+
+// Meta 描述一次读取的值来源
+type Meta struct {
+	// Cached 值来自缓存
+	Cached bool
+	// Shared 缓存未命中时，值来自其他并发请求正在执行的fn（singleflight合并），当前请求没有执行fn
+	Shared bool
+	// Computed 当前请求执行了fn，同一次计算只有一个请求的Computed为true，可以用于将计算的开销记到该请求上
+	Computed bool
+	// Stale 命中的缓存已超过WithExpiration的有效期，正在后台刷新（WithStaleWhileRevalidate）或fn失败后返回的旧值（WithServeStaleOnError）
+	Stale bool
+	// Age 命中缓存时，距离缓存写入的时间，旧版本写入的缓存没有记录写入时间，此时为0
+	Age time.Duration
 }
 
 func (i *CacheManager) Get(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) (value []byte, err error, cached bool) {
-	CacheRequestTotal.WithLabelValues(namespace).Inc()
-	// 拼接namespace和key作为缓存的key
-	key = defaultKeyPrefix + ":" + namespace + ":" + key
-	data, err := i.cache.Get(ctx, key)
-	if err != nil && !errors.Is(err, store.NotFound{}) {
+	value, err, meta := i.GetWithMeta(ctx, namespace, key, fn, opts...)
+	return value, err, meta.Cached
+}
+
+// GetWithMeta 和Get相同，额外返回值的来源信息，可以区分真正执行了fn的请求和被singleflight合并的请求
+func (i *CacheManager) GetWithMeta(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) (value []byte, err error, meta Meta) {
+	ctx, span := i.startSpan(ctx, "cache.get", namespace)
+	start := time.Now()
+	value, err, meta = i.getWithMeta(ctx, namespace, key, fn, opts...)
+	if span.IsRecording() {
+		span.SetAttributes(attribute.Bool("cache.hit", meta.Cached), attribute.Bool("cache.fn_invoked", meta.Computed))
+	}
+	endSpan(span, err)
+	if i.observing(ctx) {
+		i.observe(ctx, Event{Op: OpGet, Namespace: namespace, Key: i.tenantKey(ctx, namespace, key), Hit: meta.Cached, Shared: meta.Shared, Err: err, Duration: time.Since(start)})
+	}
+	return value, err, meta
+}
+
+func (i *CacheManager) getWithMeta(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) (value []byte, err error, meta Meta) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return nil, err, meta
+	}
+	key = i.tenantKey(ctx, namespace, key)
+	options := applyOptions(opts...)
+	if options.bypassCache {
+		meta.Computed = true
+		value, err := i.load(ctx, namespace, key, fn, opts...)
+		return value, err, meta
+	}
+	i.metrics.request(namespace, 1)
+	done := i.slowTimer(ctx, namespace, key, slowOpGet)
+	data, err := i.storeGet(ctx, key)
+	done()
+	if errors.Is(err, errCircuitOpen) {
+		//熔断器断开，不访问store直接调用fn
+		return i.loadShared(ctx, namespace, key, fn, append(opts[:len(opts):len(opts)], WithNoCache())...)
+	} else if err != nil && !errors.Is(err, store.NotFound{}) {
 		//非缓存不存在错误，直接返回
-		return nil, err, false
+		i.metrics.error(namespace, opGet, errKindStore)
+		return nil, storeError(opGet, err), meta
 	} else if err == nil {
 		//缓存存在，直接返回
-		CacheHitTotal.WithLabelValues(namespace).Inc()
-		//这里有个bug，redis取出的是string, go-cache取出的是[]byte，需要做类型转换
-		switch data.(type) {
-		case []byte:
-			return data.([]byte), nil, true
-		case string:
-			return []byte(data.(string)), nil, true
-		default:
-			return nil, errors.New("unsupported data type"), false
+		i.metrics.hit(namespace, 1)
+		stored, err := toBytes(data)
+		if err != nil {
+			i.metrics.error(namespace, opGet, errKindUnmarshal)
+			return nil, err, meta
 		}
-	}
 
-	//缓存不存在，调用fn获取数据，使用single flight防止缓存击穿
-	result, fnErr, _ := i.sg.Do(key, func() (interface{}, error) {
-		d, err := fn()
+		e, err := i.decodeValue(key, stored)
+		if errors.Is(err, errChecksumMismatch) {
+			//缓存值已损坏，按缓存不存在处理，重新计算后覆盖
+			i.metrics.error(namespace, opGet, errKindChecksum)
+			i.reportError(ctx, namespace, key, err)
+			return i.loadShared(ctx, namespace, key, fn, opts...)
+		}
 		if err != nil {
-			return nil, err
+			i.metrics.error(namespace, opGet, errKindUnmarshal)
+			if i.decodeRetry {
+				return i.retryDecode(ctx, namespace, key, fn, nil, opts...)
+			}
+			return nil, err, meta
+		}
+		if (options.typeName != "" && e.typeName != "" && e.typeName != options.typeName) || e.versionMismatch(options.version) {
+			//类型或版本发生了变化，按缓存不存在处理，重新计算后覆盖
+			return i.loadShared(ctx, namespace, key, fn, opts...)
+		}
+		meta.Cached = true
+		if e.tombstone {
+			return nil, negativeError(e), meta
+		}
+		if e.freshUntil != 0 && i.now().UnixNano() > e.freshUntil {
+			if options.staleFor <= 0 && options.staleOnError > 0 && !options.readOnly {
+				return i.loadOrServeStale(ctx, namespace, key, fn, e, opts...)
+			}
+			meta.Stale = true
+			i.revalidate(ctx, namespace, key, fn, opts...)
+		} else if i.shouldRefreshAhead(options, e) {
+			i.revalidate(ctx, namespace, key, fn, opts...)
+		}
+		if e.createdAt != 0 {
+			meta.Age = i.now().Sub(time.Unix(0, e.createdAt))
+		}
+
+		if options.SlidingExpiration > 0 {
+			//续期失败不影响本次读取，下次写入时会重新设置有效期
+			_ = i.touch(ctx, key, stored, options.SlidingExpiration)
+		}
+		return e.payload, nil, meta
+	}
+	i.metrics.miss(namespace, 1)
+	return i.loadShared(ctx, namespace, key, fn, opts...)
+}
+
+// loadShared 缓存不存在时调用fn获取数据，使用single flight防止缓存击穿，只有执行fn的请求会写入缓存，key为store中的key。
+// 合并的计算使用不会被取消的context，不受发起计算的请求取消的影响，各请求可以通过自己的ctx放弃等待
+func (i *CacheManager) loadShared(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) (value []byte, err error, meta Meta) {
+	return i.loadFlight(ctx, i.singleflightKey(namespace, key), namespace, key, fn, opts...)
+}
+
+// loadFlight 与loadShared相同，使用flightKey合并计算
+func (i *CacheManager) loadFlight(ctx context.Context, flightKey string, namespace string, key string, fn func() ([]byte, error), opts ...Option) (value []byte, err error, meta Meta) {
+	if applyOptions(opts...).readOnly {
+		return nil, ErrNotFound, meta
+	}
+	loadCtx := context.WithoutCancel(ctx)
+	release := func() {}
+	if i.maxInflight > 0 {
+		if !i.acquireFlight(ctx, flightKey) {
+			//singleflight已满，不合并直接计算
+			i.metrics.inflightRejected(namespace)
+			meta.Computed = true
+			value, err := i.loadRecover(loadCtx, namespace, key, fn, opts...)
+			return value, err, meta
 		}
-		return d, nil
+		release = func() { i.releaseFlight(flightKey) }
+	}
+
+	executed := false
+	ch := i.sg.DoChan(flightKey, func() (interface{}, error) {
+		executed = true
+		return i.loadRecover(loadCtx, namespace, key, fn, opts...)
 	})
 
-	if fnErr != nil {
-		return nil, fnErr, false
+	var result singleflight.Result
+	select {
+	case result = <-ch:
+		release()
+	case <-ctx.Done():
+		//计算仍在进行，结束后才释放singleflight位置
+		go func() {
+			<-ch
+			release()
+		}()
+		return nil, ctx.Err(), meta
+	}
+
+	//singleflight的shared在合并时对所有调用方都为true，这里以是否执行了fn区分
+	meta.Shared = !executed
+	meta.Computed = executed
+	if meta.Shared {
+		i.metrics.coalesced(namespace)
+	}
+	if result.Err != nil && meta.Shared && ctx.Err() == nil && isContextError(result.Err) {
+		//发起计算的请求被取消导致fn失败，当前请求仍然有效，重新发起计算
+		return i.loadFlight(ctx, flightKey, namespace, key, fn, opts...)
+	}
+	if result.Err != nil {
+		return nil, result.Err, meta
 	}
 
-	data, ok := result.([]byte)
+	value, ok := result.Val.([]byte)
 	if !ok {
-		return nil, errors.New("result type error"), false
+		return nil, errors.New("result type error"), meta
+	}
+	return value, nil, meta
+}
+
+// loadRecover 调用load，panic转换为错误。合并的计算在单独的goroutine中执行，panic无法被调用方recover
+func (i *CacheManager) loadRecover(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) (value []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	return i.load(ctx, namespace, key, fn, opts...)
+}
+
+// load 调用fn获取数据并写入缓存
+func (i *CacheManager) load(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) ([]byte, error) {
+	var call *inflightCall
+	if i.trackInflight {
+		call = i.beginInflight(key)
+		defer i.endInflight(key, call)
 	}
 
-	//将自定义的Option转换为store.Option
 	options := applyOptions(opts...)
-	var setOptions []store.Option
-	if options.Expiration > 0 {
-		setOptions = append(setOptions, store.WithExpiration(options.Expiration))
+	var lock *writeLock
+	if options.WriteLock != "" {
+		var err error
+		lock, err = i.acquireLock(ctx, namespace, options.WriteLock)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.release(ctx)
+	}
+
+	start := time.Now()
+	done := i.slowTimer(ctx, namespace, key, slowOpFn)
+	_, span := i.startSpan(ctx, "cache.fn", namespace)
+	value, err := callFn(options, fn)
+	endSpan(span, err)
+	done()
+	i.metrics.fetchDuration(namespace, time.Since(start))
+	if err != nil {
+		if options.cacheNegative(err) && !errors.Is(err, ErrFetchTimeout) && (lock == nil || lock.check(ctx) == nil) {
+			i.setTombstone(ctx, namespace, key, err, options, call)
+		}
+		return nil, err
+	}
+	options.applyDirective()
+	if options.NoCache {
+		return value, nil
+	}
+	if options.fnCtx != nil && options.fnCtx.Err() != nil {
+		//发起计算的请求已取消，结果可能不完整，只返回给等待的请求，不写入缓存
+		return value, nil
+	}
+	if lock != nil {
+		//锁已经被其他持有者获取时写入可能覆盖对方的结果
+		if err := lock.check(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := i.set(ctx, namespace, key, value, options, call, i.strictSet); err != nil && !errors.Is(err, ErrValueTooLarge) {
+		return nil, err
+	}
+	return value, nil
+}
+
+// set 将value写入缓存，key为store中的key，call不为nil时检查写入前后key是否被删除。
+// strict为false时store写入失败不返回错误，只记录到cache_set_error_total并调用Hooks.OnSetError
+func (i *CacheManager) set(ctx context.Context, namespace string, key string, value []byte, options *Options, call *inflightCall, strict bool) error {
+	p, err := i.prepareSet(ctx, namespace, key, value, options)
+	if p == nil {
+		return err
+	}
+	return i.writeSet(ctx, namespace, p, options.ifAbsent, call, strict)
+}
+
+// writeSet 将prepareSet编码的值写入store，ifAbsent为true时只在key不存在时写入
+func (i *CacheManager) writeSet(ctx context.Context, namespace string, p *preparedSet, ifAbsent bool, call *inflightCall, strict bool) (err error) {
+	key := p.key
+	//计算期间key或tag已被删除，结果可能已经过时，不再写入缓存
+	if call != nil && call.isDeleted(p.tags) {
+		return nil
+	}
+
+	start := time.Now()
+	done := i.slowTimer(ctx, namespace, key, slowOpSet)
+	absent := true
+	if ifAbsent {
+		absent, err = i.storeSetIfAbsent(ctx, key, p.stored, p.written.storeOptions()...)
 	} else {
-		setOptions = append(setOptions, store.WithExpiration(defaultExpiration))
+		err = i.storeSet(ctx, key, p.stored, p.written.storeOptions()...)
+	}
+	done()
+	if errors.Is(err, errCircuitOpen) {
+		if ifAbsent {
+			//调用方需要知道值没有写入
+			return storeError(opSet, err)
+		}
+		return nil
+	}
+	i.metrics.storeDuration(namespace, time.Since(start))
+	if err == nil && !absent {
+		return errKeyExists
+	}
+	if err := i.afterSet(ctx, namespace, p, err, time.Since(start), strict); err != nil {
+		return err
+	}
+
+	//写入期间key或tag被删除，删除刚写入的值
+	if call != nil && call.isDeleted(p.tags) {
+		return i.cache.Delete(ctx, key)
+	}
+	return nil
+}
+
+// preparedSet 编码完成、等待写入store的值
+type preparedSet struct {
+	key    string
+	stored []byte
+	// effective 最终生效的配置，written 传给store的配置
+	effective Options
+	written   Options
+	// tags 按租户隔离、拆分子tag之前的tag，用于检查计算期间是否被删除
+	tags []string
+}
+
+// prepareSet 计算最终生效的配置并编码value，返回nil时不需要写入，error为调用方需要处理的错误
+func (i *CacheManager) prepareSet(ctx context.Context, namespace string, key string, value []byte, options *Options) (*preparedSet, error) {
+	if !options.tombstone && i.valueTooLarge(namespace, value) {
+		//新值没有写入，已有的缓存已经过时
+		if err := i.cache.Delete(ctx, key); err != nil && !errors.Is(err, store.NotFound{}) {
+			i.reportError(ctx, namespace, key, err)
+		}
+		return nil, ErrValueTooLarge
+	}
+	//计算最终生效的配置，包括namespace配置、默认有效期和动态tag
+	if config, ok := i.namespaceConfig(namespace); ok {
+		options.applyNamespace(config)
+	}
+	if options.Expiration <= 0 {
+		options.Expiration = i.expiration
+	}
+	if options.expirationJitter <= 0 {
+		options.expirationJitter = i.expirationJitter
+	}
+	effective, err := options.resolve()
+	if err != nil {
+		i.metrics.error(namespace, opSet, errKindTag)
+		i.reportError(ctx, namespace, key, err)
+		if i.noCacheOnTagPanic {
+			return nil, nil
+		}
+	}
+	effective.Expiration += jitter(effective.expirationJitter)
+	tags := i.tenantTags(ctx, effective.Tags)
+	effective.Tags = i.shardTags(key, tags)
+
+	var stored []byte
+	if options.tombstone {
+		stored, err = i.encodeTombstone(key, value, options.typeName, options.version, options.notFound)
+	} else {
+		stored, err = i.encodeValue(namespace, key, value, effective)
+	}
+	if err != nil {
+		i.metrics.error(namespace, opSet, errKindMarshal)
+		return nil, err
+	}
+	if i.valueSizeMetrics {
+		i.metrics.valueBytes(namespace, len(stored))
+	}
+
+	//使用tag索引时由CacheManager维护tag，不再传给store
+	written := effective
+	if i.tagIndex {
+		written.Tags = nil
 	}
-	if len(options.Tags) > 0 {
-		setOptions = append(setOptions, store.WithTags(options.Tags))
+	if !options.tombstone {
+		//过期后仍保留一段时间，用于返回旧值并后台刷新，或在fn失败时返回旧值
+		written.Expiration += max(effective.staleFor, effective.staleOnError)
 	}
+	return &preparedSet{key: key, stored: stored, effective: effective, written: written, tags: tags}, nil
+}
 
-	err = i.cache.Set(ctx, key, data, setOptions...)
+// afterSet 处理store写入的结果：记录指标和事件，写入失败时按strict返回错误，成功时维护tag索引并调用Hooks.OnSet
+func (i *CacheManager) afterSet(ctx context.Context, namespace string, p *preparedSet, err error, d time.Duration, strict bool) error {
+	if i.observing(ctx) {
+		i.observe(ctx, Event{Op: OpSet, Namespace: namespace, Key: p.key, Err: err, Duration: d})
+	}
+	if err == nil && i.tagIndex && len(p.effective.Tags) > 0 {
+		//tag索引失败时DeleteByTags无法删除刚写入的值，删除后与写入失败同样处理
+		if err = i.indexTags(ctx, p.key, p.effective.Tags, p.written.Expiration); err != nil {
+			if delErr := i.cache.Delete(ctx, p.key); delErr != nil && !errors.Is(delErr, store.NotFound{}) {
+				i.reportError(ctx, namespace, p.key, delErr)
+			}
+		}
+	}
 	if err != nil {
-		return nil, err, false
+		i.metrics.error(namespace, opSet, errKindStore)
+		if strict {
+			return storeError(opSet, err)
+		}
+		i.metrics.setError(namespace)
+		if i.hooks.OnSetError != nil {
+			i.hooks.OnSetError(ctx, namespace, p.key, err)
+		}
+		return nil
+	}
+	if i.hooks.OnSet != nil {
+		i.hooks.OnSet(ctx, namespace, p.key, p.effective)
+	}
+	return nil
+}
+
+// SetRaw 直接写入已经序列化的数据，不经过任何编码，与Get使用相同的key、tag、有效期和压缩配置
+func (i *CacheManager) SetRaw(ctx context.Context, namespace string, key string, data []byte, opts ...Option) (err error) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	ctx, span := i.startSpan(ctx, "cache.set", namespace)
+	defer func() { endSpan(span, err) }()
+	return i.set(ctx, namespace, i.tenantKey(ctx, namespace, key), data, applyOptions(opts...), nil, true)
+}
+
+// now 返回当前时间，可以通过WithClock替换
+func (i *CacheManager) now() time.Time {
+	if i.clock != nil {
+		return i.clock()
+	}
+	return time.Now()
+}
+
+// toBytes 将store中读取的数据转换为[]byte，不同的store返回的类型不同，例如redis取出的是string，go-cache取出的是[]byte，
+// 无法转换时返回ErrUnsupportedDataType
+func toBytes(data any) ([]byte, error) {
+	switch v := data.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case json.RawMessage:
+		return v, nil
+	case *[]byte:
+		if v != nil {
+			return *v, nil
+		}
+	case fmt.Stringer:
+		return []byte(v.String()), nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedDataType, data)
+}
+
+// keyPrefix 返回CacheManager使用的key前缀，没有通过WithKeyPrefix设置时使用全局默认值
+func (i *CacheManager) keyPrefix() string {
+	if i.prefix != "" {
+		return i.prefix
+	}
+	return loadDefaultKeyPrefix()
+}
+
+// buildKey 拼接namespace和key作为缓存的key，设置了纪元时在前缀后加入纪元。
+// 开启WithAutoHashKeyAbove且完整key超过阈值时，key部分替换为其哈希
+func (i *CacheManager) buildKey(namespace string, key string) string {
+	fullKey := i.joinKey(namespace, key)
+	if i.autoHashKeyAbove > 0 && len(fullKey) > i.autoHashKeyAbove {
+		return i.joinKey(namespace, "h:"+i.hashKey(key))
+	}
+	return fullKey
+}
+
+// namespacePrefix 返回namespace下全部key共同的前缀
+func (i *CacheManager) namespacePrefix(namespace string) string {
+	return i.joinKey(namespace, "")
+}
+
+// joinKey 使用WithKeyBuilder设置的函数拼接前缀、namespace和key，默认以冒号连接
+func (i *CacheManager) joinKey(namespace string, key string) string {
+	prefix := i.keyPrefix()
+	if epoch := cacheEpoch.Load(); epoch != 0 {
+		prefix += ":e" + strconv.FormatInt(epoch, 10)
+	}
+	if i.namespaceHashTag {
+		namespace = "{" + namespace + "}"
+	}
+	if i.keyBuilder != nil {
+		return i.keyBuilder(prefix, namespace, key)
 	}
+	return prefix + ":" + namespace + ":" + key
+}
+
+// 内部数据使用的namespace，与调用方的key分开，checkNamespace拒绝这些namespace
+const (
+	lockNamespace = "__lock__"
+)
+
+func isReservedNamespace(namespace string) bool {
+	return namespace == lockNamespace || namespace == tagIndexNamespace || strings.HasPrefix(namespace, tenantSegmentPrefix)
+}
 
-	return data.([]byte), fnErr, false
+// hashKey 返回key的哈希，默认使用sha256，可以通过WithKeyHashFunc替换
+func (i *CacheManager) hashKey(key string) string {
+	if i.keyHashFunc == nil {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:])
+	}
+	h := i.keyHashFunc()
+	_, _ = h.Write([]byte(key))
+	return hex.EncodeToString(h.Sum(nil))
 }
 
-func (i *CacheManager) Delete(ctx context.Context, namespace string, key string) error {
-	// 拼接namespace和key作为缓存的key
-	key = defaultKeyPrefix + ":" + namespace + ":" + key
-	return i.cache.Delete(ctx, key)
+// touch 将key的有效期重置为expiration，stored为store中保存的原始数据
+func (i *CacheManager) touch(ctx context.Context, key string, stored []byte, expiration time.Duration) error {
+	if updater, ok := i.cache.(TTLUpdater); ok {
+		return updater.Expire(ctx, key, expiration)
+	}
+	return i.cache.Set(ctx, key, stored, store.WithExpiration(expiration))
+}
+
+func (i *CacheManager) Delete(ctx context.Context, namespace string, key string) (err error) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	ctx, span := i.startSpan(ctx, "cache.delete", namespace)
+	defer func() { endSpan(span, err) }()
+	key = i.tenantKey(ctx, namespace, key)
+	if i.trackInflight {
+		i.markInflightDeleted(key)
+	}
+	defer i.slowTimer(ctx, namespace, key, slowOpDelete)()
+	start := time.Now()
+	err = i.cache.Delete(ctx, key)
+	if i.observing(ctx) {
+		i.observe(ctx, Event{Op: OpDelete, Namespace: namespace, Key: key, Err: err, Duration: time.Since(start)})
+	}
+	if err != nil {
+		i.metrics.error(namespace, opDelete, errKindStore)
+		return storeError(opDelete, err)
+	}
+	i.publishInvalidation(ctx, invalidationMessage{Keys: []string{key}})
+	return nil
 }
 
 func (i *CacheManager) DeleteByTags(ctx context.Context, tags []string) error {
+	if _, err := i.tenant(ctx); err != nil {
+		return err
+	}
+	return i.invalidateTags(ctx, i.tenantTags(ctx, tags))
+}
+
+// invalidateTags 删除tag关联的缓存并广播，tag已经按租户隔离
+func (i *CacheManager) invalidateTags(ctx context.Context, tags []string) error {
+	ctx, span := i.startSpan(ctx, "cache.delete_by_tags", "")
+	if span.IsRecording() {
+		span.SetAttributes(attribute.StringSlice("cache.tags", tags))
+	}
+	var keys []string
+	if i.pubSub != nil {
+		//其他实例从二级缓存回填的本地缓存没有记录tag，删除前读取tag关联的key一起广播
+		keys, _ = i.previewDeleteByTags(ctx, tags)
+	}
+	start := time.Now()
+	err := i.deleteByTags(ctx, tags)
+	if i.observing(ctx) {
+		i.observe(ctx, Event{Op: OpDeleteByTags, Tags: tags, Err: err, Duration: time.Since(start)})
+	}
+	if err != nil && !errors.Is(err, ErrTagsUnsupported) {
+		//tag不属于某个namespace，namespace为空
+		i.metrics.error("", opInvalidate, errKindStore)
+	}
+	if err == nil {
+		i.publishInvalidation(ctx, invalidationMessage{Keys: keys, Tags: tags})
+	}
+	endSpan(span, err)
+	return err
+}
+
+func (i *CacheManager) deleteByTags(ctx context.Context, tags []string) error {
+	i.markInflightTagsDeleted(tags)
+	tags = i.expandTagShards(tags)
+	if i.tagIndex {
+		return i.deleteIndexedTags(ctx, tags)
+	}
+	if supporter, ok := i.cache.(TagSupporter); ok && !supporter.SupportsTags() {
+		return ErrTagsUnsupported
+	}
+	if i.asyncUnlink {
+		//store支持异步删除时使用UNLINK，否则回退到同步的Invalidate
+		if unlinker, ok := i.cache.(TagUnlinker); ok {
+			return unlinker.UnlinkTags(ctx, tags)
+		}
+	}
 	return i.cache.Invalidate(ctx, store.WithInvalidateTags(tags))
 }
 
+func (i *CacheManager) PreviewDeleteByTags(ctx context.Context, tags []string) (count int, sample []string, err error) {
+	keys, err := i.tagKeys(ctx, tags)
+	if err != nil {
+		return 0, nil, err
+	}
+	count = len(keys)
+	if count > previewSampleSize {
+		keys = keys[:previewSampleSize]
+	}
+	return count, keys, nil
+}
+
+// tagKeys 返回tags关联的全部key，tags为调用方传入的tag，按租户隔离后读取
+func (i *CacheManager) tagKeys(ctx context.Context, tags []string) ([]string, error) {
+	if _, err := i.tenant(ctx); err != nil {
+		return nil, err
+	}
+	return i.previewDeleteByTags(ctx, i.tenantTags(ctx, tags))
+}
+
+func (i *CacheManager) previewDeleteByTags(ctx context.Context, tags []string) ([]string, error) {
+	tags = i.expandTagShards(tags)
+	tagKeys := i.indexedTagKeys
+	if !i.tagIndex {
+		reader, ok := i.cache.(TagKeysReader)
+		if !ok {
+			return nil, ErrNotSupported
+		}
+		tagKeys = reader.TagKeys
+	}
+
+	seen := make(map[string]struct{})
+	keys := make([]string, 0)
+	for _, tag := range tags {
+		keysOfTag, err := tagKeys(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keysOfTag {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
 // Get 尝试从缓存中获取值，如果没有则调用 fn 获取并缓存，这里使用了泛型来支持不同类型的返回值，同时支持options的方式给缓存添加tag和有效期
+// T为指针类型时，fn返回的nil指针缓存为null，读取时还原为nil，与指向空结构体的指针区分
 func Get[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func() (T, error), opts ...Option) (value T, err error, cached bool) {
-	data, err, cached := cacheManager.Get(ctx, namespace, key, func() ([]byte, error) {
+	value, err, meta := GetWithMeta(ctx, cacheManager, namespace, key, fn, opts...)
+	return value, err, meta.Cached
+}
+
+// GetWithMeta 和Get相同，额外返回值的来源信息
+func GetWithMeta[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func() (T, error), opts ...Option) (value T, err error, meta Meta) {
+	err, meta = getInto(ctx, cacheManager, namespace, key, &value, false, fn, opts...)
+	return value, err, meta
+}
+
+// GetInto 和Get相同，结果反序列化到dst中，适合复用dst对象的场景。
+// 反序列化前dst会被重置为零值，避免缓存值中没有的字段残留上一次的数据，使用WithMergeInto可以保留原有字段
+func GetInto[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, dst *T, fn func() (T, error), opts ...Option) (err error, cached bool) {
+	err, meta := getInto(ctx, cacheManager, namespace, key, dst, applyOptions(opts...).mergeInto, fn, opts...)
+	return err, meta.Cached
+}
+
+// getInto 读取缓存并反序列化到dst，merge为false时反序列化前将dst重置为零值
+func getInto[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, dst *T, merge bool, fn func() (T, error), opts ...Option) (err error, meta Meta) {
+	codec := cacheManager.callCodec(namespace, opts)
+	load := func() ([]byte, error) {
 		v, e := fn()
 		if e != nil {
 			return nil, e
 		}
-		return json.Marshal(v)
-	}, opts...)
+		data, e := encodeWith(codec, v)
+		if e != nil {
+			cacheManager.metrics.error(namespace, opSet, errKindMarshal)
+		}
+		return data, e
+	}
+	if cacheManager.typeCheck {
+		opts = append(opts[:len(opts):len(opts)], withTypeName(typeName[T]()))
+	}
+	data, err, meta := cacheManager.GetWithMeta(ctx, namespace, key, load, opts...)
 	if err != nil {
-		return value, err, cached
+		return err, meta
 	}
 
-	err = json.Unmarshal(data, &value)
+	decode := func(data []byte) error {
+		if !merge {
+			var zero T
+			*dst = zero
+		}
+		return decodeWith(codec, data, dst)
+	}
+	err = decode(data)
 	if err != nil {
-		return value, err, cached
+		cacheManager.metrics.error(namespace, opGet, errKindUnmarshal)
 	}
-	return value, err, cached
+	if err != nil && meta.Cached && cacheManager.decodeRetry {
+		data, err, meta = cacheManager.retryDecode(ctx, namespace, cacheManager.tenantKey(ctx, namespace, key), load, func(payload []byte) error {
+			var v T
+			return decodeWith(codec, payload, &v)
+		}, opts...)
+		if err != nil {
+			return err, meta
+		}
+		err = decode(data)
+	}
+	if err != nil {
+		return &DecodeError{Namespace: namespace, Key: key, Type: typeName[T](), Err: err}, meta
+	}
+	return nil, meta
+}
+
+// GetOrZero 和Get相同，但不会返回错误，适用于尽力而为的缓存。
+// 任何错误（包括fn返回的错误）都会被忽略并返回T的零值，错误只会上报给Hooks.OnError，调用方无法感知。
+// 出错时不会写入缓存，下次调用会重新执行fn
+func GetOrZero[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func() (T, error), opts ...Option) T {
+	value, err, _ := Get(ctx, cacheManager, namespace, key, fn, opts...)
+	if err != nil {
+		cacheManager.reportError(ctx, namespace, cacheManager.tenantKey(ctx, namespace, key), err)
+		var zero T
+		return zero
+	}
+	return value
+}
+
+// typeName 返回类型的名称，例如main.User
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+// GetRaw 和Get相同，但fn返回的是已经序列化的数据，原样写入缓存并原样返回，不经过编码，
+// 适合直接透传上游返回的json等数据
+func GetRaw(ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func() ([]byte, error), opts ...Option) (value []byte, err error, cached bool) {
+	return cacheManager.Get(ctx, namespace, key, fn, opts...)
+}
+
+// SetRaw 直接写入已经序列化的数据，可以通过GetRaw原样读取
+func SetRaw(ctx context.Context, cacheManager *CacheManager, namespace string, key string, data []byte, opts ...Option) error {
+	return cacheManager.SetRaw(ctx, namespace, key, data, opts...)
+}
+
+// Set 直接写入已有的值，序列化方式、key、tag和有效期与Get相同，用于写操作之后直接更新缓存
+func Set[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, value T, opts ...Option) error {
+	data, err := encodeWith(cacheManager.callCodec(namespace, opts), value)
+	if err != nil {
+		return err
+	}
+	if cacheManager.typeCheck {
+		opts = append(opts[:len(opts):len(opts)], withTypeName(typeName[T]()))
+	}
+	return cacheManager.SetRaw(ctx, namespace, key, data, opts...)
 }
 
 func Delete(ctx context.Context, cacheManager *CacheManager, namespace string, key string) error {
@@ -123,14 +914,42 @@ func DeleteByTags(ctx context.Context, cacheManager *CacheManager, tags []string
 	return cacheManager.DeleteByTags(ctx, tags)
 }
 
+// previewSampleSize PreviewDeleteByTags最多返回的key数量
+const previewSampleSize = 100
+
+// PreviewDeleteByTags 返回DeleteByTags将会删除的key数量和按顺序排列的前previewSampleSize个key，不会真正删除，
+// 用于评估批量删除的影响范围。需要store实现TagKeysReader，否则返回ErrNotSupported
+func PreviewDeleteByTags(ctx context.Context, cacheManager *CacheManager, tags []string) (count int, sample []string, err error) {
+	return cacheManager.PreviewDeleteByTags(ctx, tags)
+}
+
+func loadDefaultKeyPrefix() string {
+	return *defaultKeyPrefix.Load()
+}
+
+func loadDefaultMetricsPrefix() string {
+	return *defaultMetricsPrefix.Load()
+}
+
+func loadDefaultExpiration() time.Duration {
+	return time.Duration(defaultExpiration.Load())
+}
+
 func SetDefaultKeyPrefix(prefix string) {
-	defaultKeyPrefix = prefix
+	defaultKeyPrefix.Store(&prefix)
 }
 
 func SetDefaultExpiration(expiration time.Duration) {
-	defaultExpiration = expiration
+	defaultExpiration.Store(int64(expiration))
 }
 
+// SetDefaultMetricsPrefix 设置指标前缀，需要在创建第一个CacheManager或调用InitMetrics之前调用，之后调用对已构造的指标无效
 func SetDefaultMetricsPrefix(prefix string) {
-	defaultMetricsPrefix = prefix
+	defaultMetricsPrefix.Store(&prefix)
+}
+
+// SetCacheEpoch 设置全局的缓存纪元，用于紧急情况下让全部缓存失效而不需要批量删除store中的数据，
+// 旧的缓存会按原有的有效期自然过期。纪元为0时key中不包含纪元
+func SetCacheEpoch(n int) {
+	cacheEpoch.Store(int64(n))
 }
@@ -2,10 +2,22 @@ package cacheable
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
+	"github.com/eko/gocache/lib/v4/store"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -80,6 +92,55 @@ func TestGet(t *testing.T) {
 	})
 }
 
+func TestGetZeroValue(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"gocache": newTestGoCacheManager(t),
+		"redis":   NewCacheManager(redisStore),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			assertZeroCached(t, cacheManager, "zero_int", 0)
+			assertZeroCached(t, cacheManager, "zero_bool", false)
+			assertZeroCached(t, cacheManager, "zero_string", "")
+			assertZeroCached(t, cacheManager, "zero_slice", []int{})
+
+			for _, codec := range []Codec{JSONCodec, GobCodec} {
+				assertZeroCached(t, cacheManager, fmt.Sprintf("zero_int_%T", codec), 0, WithCodec(codec))
+			}
+
+			//fn返回空的[]byte时同样写入缓存
+			for i := 0; i < 2; i++ {
+				value, err, cached := cacheManager.Get(ctx, namespace, "zero_raw", func() ([]byte, error) {
+					return []byte{}, nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, i == 1, cached)
+				assert.Empty(t, value)
+			}
+		})
+	}
+}
+
+// assertZeroCached 检查零值写入后下一次Get命中缓存
+func assertZeroCached[T any](t *testing.T, cacheManager *CacheManager, key string, zero T, opts ...Option) {
+	t.Helper()
+	ctx := context.Background()
+	calls := 0
+	for i := 0; i < 2; i++ {
+		value, err, cached := Get(ctx, cacheManager, namespace, key, func() (T, error) {
+			calls++
+			return zero, nil
+		}, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, cached, key)
+		assert.Equal(t, zero, value)
+	}
+	assert.Equal(t, 1, calls, key)
+}
+
 func TestDelete(t *testing.T) {
 	ctx := context.Background()
 
@@ -308,3 +369,1016 @@ func TestGetWithOptions(t *testing.T) {
 		assert.False(t, cached)
 	})
 }
+
+func TestPreviewDeleteByTags(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("预览标签关联的缓存", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, namespace, "key2", func() (string, error) {
+			return "value2", nil
+		}, WithTags("tag1", "tag2"))
+		_, _, _ = Get(ctx, cacheManager, namespace, "key1", func() (string, error) {
+			return "value1", nil
+		}, WithTags("tag1"))
+
+		count, keys, err := PreviewDeleteByTags(ctx, cacheManager, []string{"tag1", "tag2"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, []string{
+			loadDefaultKeyPrefix() + ":" + namespace + ":key1",
+			loadDefaultKeyPrefix() + ":" + namespace + ":key2",
+		}, keys)
+
+		// 预览不应删除缓存
+		_, _, cached := Get(ctx, cacheManager, namespace, "key1", func() (string, error) {
+			return "new value1", nil
+		})
+		assert.True(t, cached)
+	})
+
+	t.Run("预览不存在的标签", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		count, keys, err := PreviewDeleteByTags(ctx, cacheManager, []string{"non_existing_tag"})
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("只返回部分key作为样本", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		total := previewSampleSize + 10
+		for n := 0; n < total; n++ {
+			assert.NoError(t, Set(ctx, cacheManager, namespace, fmt.Sprintf("preview_%03d", n), n, WithTags("preview_many")))
+		}
+
+		count, keys, err := PreviewDeleteByTags(ctx, cacheManager, []string{"preview_many"})
+		assert.NoError(t, err)
+		assert.Equal(t, total, count)
+		assert.Len(t, keys, previewSampleSize)
+		assert.Equal(t, cacheManager.buildKey(namespace, "preview_000"), keys[0])
+	})
+
+	t.Run("store不支持读取标签", func(t *testing.T) {
+		_, _, err := PreviewDeleteByTags(ctx, MockCacheManager, []string{"tag1"})
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
+
+func TestGetWithSlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	expiration := 100 * time.Millisecond
+
+	run := func(t *testing.T, cacheManager *CacheManager) {
+		key := "sliding"
+		_, err, _ := Get(ctx, cacheManager, namespace, key, func() (string, error) {
+			return "value", nil
+		}, WithExpiration(expiration), WithSlidingExpiration(expiration))
+		assert.NoError(t, err)
+
+		// 持续读取，总时长超过原始有效期，缓存应一直命中
+		for n := 0; n < 5; n++ {
+			time.Sleep(expiration / 2)
+			_, _, cached := Get(ctx, cacheManager, namespace, key, func() (string, error) {
+				return "new value", nil
+			}, WithSlidingExpiration(expiration))
+			assert.True(t, cached)
+		}
+
+		// 停止读取后应正常过期
+		time.Sleep(expiration + 20*time.Millisecond)
+		_, _, cached := Get(ctx, cacheManager, namespace, key, func() (string, error) {
+			return "new value", nil
+		})
+		assert.False(t, cached)
+	}
+
+	t.Run("store支持修改有效期", func(t *testing.T) {
+		run(t, newTestGoCacheManager(t))
+	})
+
+	t.Run("store不支持修改有效期时重新写入", func(t *testing.T) {
+		run(t, MockCacheManager)
+	})
+}
+
+func TestGetWithMeta(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("并发未命中时只有一个请求执行fn", func(t *testing.T) {
+		key := "meta_shared"
+		coalesced := testutil.ToFloat64(CacheCoalescedTotal.WithLabelValues(namespace))
+		var wg sync.WaitGroup
+		var executed, shared atomic.Int32
+		start := make(chan struct{})
+		for n := 0; n < 10; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				_, err, meta := GetWithMeta(ctx, MockCacheManager, namespace, key, func() (string, error) {
+					time.Sleep(50 * time.Millisecond)
+					return "value", nil
+				})
+				assert.NoError(t, err)
+				assert.False(t, meta.Cached)
+				assert.NotEqual(t, meta.Shared, meta.Computed)
+				if meta.Shared {
+					shared.Add(1)
+				} else {
+					executed.Add(1)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), executed.Load())
+		assert.Equal(t, int32(9), shared.Load())
+		assert.Equal(t, float64(9), testutil.ToFloat64(CacheCoalescedTotal.WithLabelValues(namespace))-coalesced)
+
+		_, _, meta := GetWithMeta(ctx, MockCacheManager, namespace, key, func() (string, error) {
+			return "new value", nil
+		})
+		assert.True(t, meta.Cached)
+		assert.False(t, meta.Shared)
+		assert.False(t, meta.Computed)
+	})
+}
+
+func TestSetCacheEpoch(t *testing.T) {
+	ctx := context.Background()
+	t.Cleanup(func() { SetCacheEpoch(0) })
+
+	key := "epoch"
+	_, _, _ = Get(ctx, MockCacheManager, namespace, key, func() (string, error) {
+		return "value", nil
+	})
+
+	// 修改纪元后之前的缓存无法读取
+	SetCacheEpoch(1)
+	value, _, cached := Get(ctx, MockCacheManager, namespace, key, func() (string, error) {
+		return "epoch1 value", nil
+	})
+	assert.False(t, cached)
+	assert.Equal(t, "epoch1 value", value)
+
+	// 删除只影响当前纪元
+	assert.NoError(t, Delete(ctx, MockCacheManager, namespace, key))
+	_, _, cached = Get(ctx, MockCacheManager, namespace, key, func() (string, error) {
+		return "epoch1 value", nil
+	})
+	assert.False(t, cached)
+
+	// 恢复纪元后旧缓存仍然存在
+	SetCacheEpoch(0)
+	value, _, cached = Get(ctx, MockCacheManager, namespace, key, func() (string, error) {
+		return "new value", nil
+	})
+	assert.True(t, cached)
+	assert.Equal(t, "value", value)
+}
+
+func TestGetDecodeError(t *testing.T) {
+	ctx := context.Background()
+
+	type user struct {
+		Name string
+	}
+
+	key := "decode_error"
+	_, _, _ = Get(ctx, MockCacheManager, namespace, key, func() (string, error) {
+		return "not a user", nil
+	})
+
+	_, err, _ := Get(ctx, MockCacheManager, namespace, key, func() (user, error) {
+		return user{}, nil
+	})
+	var decodeErr *DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, namespace, decodeErr.Namespace)
+	assert.Equal(t, key, decodeErr.Key)
+	assert.Equal(t, "cacheable.user", decodeErr.Type)
+	assert.Equal(t, "cacheable: decode "+namespace+"/"+keyHash(key)+" into cacheable.user: "+decodeErr.Err.Error(), err.Error())
+	assert.NotContains(t, err.Error(), key)
+}
+
+func TestGetWithMetaAge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cacheManager := newTestGoCacheManager(t, WithEntryAge(), WithClock(func() time.Time { return now }))
+
+	_, _, meta := GetWithMeta(ctx, cacheManager, namespace, "age", func() (string, error) {
+		return "value", nil
+	})
+	assert.Equal(t, time.Duration(0), meta.Age)
+
+	now = now.Add(90 * time.Second)
+	_, _, meta = GetWithMeta(ctx, cacheManager, namespace, "age", func() (string, error) {
+		return "new value", nil
+	})
+	assert.True(t, meta.Cached)
+	assert.Equal(t, 90*time.Second, meta.Age)
+
+	// 旧版本写入的缓存没有写入时间
+	_ = cacheManager.cache.Set(ctx, cacheManager.buildKey(namespace, "legacy_age"), []byte(`"legacy"`))
+	value, _, meta := GetWithMeta(ctx, cacheManager, namespace, "legacy_age", func() (string, error) {
+		return "new value", nil
+	})
+	assert.True(t, meta.Cached)
+	assert.Equal(t, "legacy", value)
+	assert.Equal(t, time.Duration(0), meta.Age)
+
+	// 默认不为写入时间单独写入头部，保持与旧版本相同的格式
+	plain := newTestGoCacheManager(t, WithClock(func() time.Time { return now }))
+	_, _, _ = GetWithMeta(ctx, plain, namespace, "plain_age", func() (map[string]string, error) {
+		return map[string]string{"name": "alice"}, nil
+	})
+	stored, err := plain.cache.Get(ctx, plain.buildKey(namespace, "plain_age"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{"name":"alice"}`), stored)
+}
+
+func TestGetOrZero(t *testing.T) {
+	ctx := context.Background()
+	var reported []error
+	cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+		OnError: func(ctx context.Context, namespace string, key string, err error) {
+			reported = append(reported, err)
+		},
+	}))
+
+	value := GetOrZero(ctx, cacheManager, namespace, "or_zero", func() (string, error) {
+		return "Alice", nil
+	})
+	assert.Equal(t, "Alice", value)
+
+	// fn出错时返回零值，错误上报给OnError，不写入缓存
+	expectedErr := errors.New("fn error")
+	value = GetOrZero(ctx, cacheManager, namespace, "or_zero_error", func() (string, error) {
+		return "Bob", expectedErr
+	})
+	assert.Equal(t, "", value)
+	assert.Equal(t, []error{expectedErr}, reported)
+
+	value = GetOrZero(ctx, cacheManager, namespace, "or_zero_error", func() (string, error) {
+		return "Bob", nil
+	})
+	assert.Equal(t, "Bob", value)
+
+	// 解码失败同样返回零值
+	_ = cacheManager.cache.Set(ctx, cacheManager.buildKey(namespace, "or_zero_corrupted"), []byte("not json"))
+	value = GetOrZero(ctx, cacheManager, namespace, "or_zero_corrupted", func() (string, error) {
+		return "Carol", nil
+	})
+	assert.Equal(t, "", value)
+	assert.Len(t, reported, 2)
+	var decodeErr *DecodeError
+	assert.ErrorAs(t, reported[1], &decodeErr)
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	cacheManager := NewCacheManager(redisStore)
+	assert.Same(t, redisStore, cacheManager.Store())
+
+	// 通过store写入的值可以被Get读取
+	assert.NoError(t, cacheManager.Store().Set(ctx, cacheManager.buildKey(namespace, "raw"), []byte(`"raw"`)))
+	value, err, cached := Get(ctx, cacheManager, namespace, "raw", func() (string, error) {
+		return "fn", nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, "raw", value)
+}
+
+func TestWithKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	sharedStore := NewGoCacheStore(gocache.New(5*time.Minute, 10*time.Minute))
+	app1 := NewCacheManager(sharedStore, WithKeyPrefix("app1"))
+	app2 := NewCacheManager(sharedStore, WithKeyPrefix("app2"))
+
+	value, _, _ := Get(ctx, app1, namespace, "key", func() (string, error) {
+		return "app1", nil
+	})
+	assert.Equal(t, "app1", value)
+	value, _, cached := Get(ctx, app2, namespace, "key", func() (string, error) {
+		return "app2", nil
+	})
+	assert.False(t, cached)
+	assert.Equal(t, "app2", value)
+	assert.Equal(t, "app1:"+namespace+":key", app1.buildKey(namespace, "key"))
+
+	// 删除只影响自己的前缀
+	assert.NoError(t, app1.Delete(ctx, namespace, "key"))
+	_, err := sharedStore.Get(ctx, app2.buildKey(namespace, "key"))
+	assert.NoError(t, err)
+
+	// 未设置时使用全局前缀
+	assert.Equal(t, loadDefaultKeyPrefix()+":"+namespace+":key", newTestGoCacheManager(t).buildKey(namespace, "key"))
+}
+
+func TestWithKeyBuilder(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t, WithKeyPrefix("app"), WithKeyBuilder(func(prefix, namespace, key string) string {
+		return prefix + "/{" + namespace + "}/" + key
+	}))
+	assert.Equal(t, "app/{"+namespace+"}/key", cacheManager.buildKey(namespace, "key"))
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "builder_key", func() (string, error) {
+		return "value", nil
+	})
+	_, err := cacheManager.cache.Get(ctx, "app/{"+namespace+"}/builder_key")
+	assert.NoError(t, err)
+
+	// 删除使用相同的key
+	assert.NoError(t, Delete(ctx, cacheManager, namespace, "builder_key"))
+	exists, err := Exists(ctx, cacheManager, namespace, "builder_key")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// 枚举namespace使用相同的前缀
+	assert.NoError(t, Set(ctx, cacheManager, namespace, "builder_export", 1))
+	values, err := Export[int](ctx, cacheManager, namespace)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"builder_export": 1}, values)
+
+	// 哈希后的key同样经过builder
+	hashed := newTestGoCacheManager(t, WithAutoHashKeyAbove(10), WithKeyBuilder(func(prefix, namespace, key string) string {
+		return "{" + namespace + "}:" + key
+	}))
+	assert.Equal(t, "{"+namespace+"}:h:"+hashed.hashKey("a_very_long_key"), hashed.buildKey(namespace, "a_very_long_key"))
+}
+
+func TestWithDefaultExpiration(t *testing.T) {
+	ctx := context.Background()
+	var written Options
+	cacheManager := newTestGoCacheManager(t, WithDefaultExpiration(5*time.Minute), WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			written = options
+		},
+	}))
+	cacheManager.RegisterNamespace("default_expiration_ns", NamespaceConfig{Expiration: time.Minute})
+	fn := func() (string, error) {
+		return "value", nil
+	}
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "default_expiration", fn)
+	assert.Equal(t, 5*time.Minute, written.Expiration)
+
+	// 调用时的配置和namespace配置优先
+	_, _, _ = Get(ctx, cacheManager, namespace, "default_expiration_call", fn, WithExpiration(time.Second))
+	assert.Equal(t, time.Second, written.Expiration)
+	_, _, _ = Get(ctx, cacheManager, "default_expiration_ns", "key", fn)
+	assert.Equal(t, time.Minute, written.Expiration)
+
+	// 未设置时使用全局默认有效期
+	other := newTestGoCacheManager(t, WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			written = options
+		},
+	}))
+	_, _, _ = Get(ctx, other, namespace, "default_expiration", fn)
+	assert.Equal(t, loadDefaultExpiration(), written.Expiration)
+}
+
+func TestWithAutoHashKeyAbove(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t, WithAutoHashKeyAbove(64))
+
+	shortKey := "short"
+	assert.Equal(t, loadDefaultKeyPrefix()+":"+namespace+":"+shortKey, cacheManager.buildKey(namespace, shortKey))
+
+	longKey := strings.Repeat("composite-", 20)
+	storeKey := cacheManager.buildKey(namespace, longKey)
+	assert.True(t, strings.HasPrefix(storeKey, loadDefaultKeyPrefix()+":"+namespace+":h:"))
+	assert.Len(t, storeKey, len(loadDefaultKeyPrefix()+":"+namespace+":h:")+64)
+
+	// 读取、写入和删除使用相同的key
+	_, _, _ = Get(ctx, cacheManager, namespace, longKey, func() (string, error) {
+		return "value", nil
+	})
+	_, err := cacheManager.cache.Get(ctx, storeKey)
+	assert.NoError(t, err)
+	value, _, cached := Get(ctx, cacheManager, namespace, longKey, func() (string, error) {
+		return "new value", nil
+	})
+	assert.True(t, cached)
+	assert.Equal(t, "value", value)
+
+	assert.NoError(t, cacheManager.Delete(ctx, namespace, longKey))
+	_, err = cacheManager.cache.Get(ctx, storeKey)
+	assert.Error(t, err)
+}
+
+func TestGetExpirationSemantics(t *testing.T) {
+	ctx := context.Background()
+	var expirations []time.Duration
+	cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			expirations = append(expirations, options.Expiration)
+		},
+	}))
+
+	t.Run("默认有效期", func(t *testing.T) {
+		expirations = nil
+		_, _, _ = Get(ctx, cacheManager, namespace, "default_ttl", func() (string, error) {
+			return "value", nil
+		})
+		_, _, _ = Get(ctx, cacheManager, namespace, "zero_ttl", func() (string, error) {
+			return "value", nil
+		}, WithExpiration(0))
+		assert.Equal(t, []time.Duration{loadDefaultExpiration(), loadDefaultExpiration()}, expirations)
+	})
+
+	t.Run("指定有效期", func(t *testing.T) {
+		expirations = nil
+		_, _, _ = Get(ctx, cacheManager, namespace, "explicit_ttl", func() (string, error) {
+			return "value", nil
+		}, WithExpiration(time.Minute))
+		assert.Equal(t, []time.Duration{time.Minute}, expirations)
+	})
+
+	t.Run("不写入缓存", func(t *testing.T) {
+		expirations = nil
+		calls := 0
+		for n := 0; n < 2; n++ {
+			value, err, cached := Get(ctx, cacheManager, namespace, "no_cache", func() (string, error) {
+				calls++
+				return "value", nil
+			}, WithNoCache())
+			assert.NoError(t, err)
+			assert.False(t, cached)
+			assert.Equal(t, "value", value)
+		}
+		assert.Equal(t, 2, calls)
+		assert.Empty(t, expirations)
+
+		// 已有的缓存仍然会被读取
+		value, _, cached := Get(ctx, cacheManager, namespace, "explicit_ttl", func() (string, error) {
+			return "new value", nil
+		}, WithNoCache())
+		assert.True(t, cached)
+		assert.Equal(t, "value", value)
+	})
+}
+
+func TestGetRawAndSetRaw(t *testing.T) {
+	ctx := context.Background()
+	var tags []string
+	cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			tags = options.Tags
+		},
+	}))
+	// 与json.Marshal的结果不同，需要原样保留
+	raw := []byte(`{ "name" : "Alice" }`)
+
+	assert.NoError(t, SetRaw(ctx, cacheManager, namespace, "raw", raw, WithTags("raw_tag")))
+	assert.Equal(t, []string{"raw_tag"}, tags)
+	value, err, cached := GetRaw(ctx, cacheManager, namespace, "raw", func() ([]byte, error) {
+		return nil, errors.New("should not be called")
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, raw, value)
+
+	// 与泛型Get共用key，旧版json格式可以直接读取
+	var decoded map[string]string
+	decoded, err, cached = Get(ctx, cacheManager, namespace, "raw", func() (map[string]string, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, "Alice", decoded["name"])
+
+	value, err, cached = GetRaw(ctx, cacheManager, namespace, "raw_miss", func() ([]byte, error) {
+		return raw, nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, raw, value)
+
+	assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"raw_tag"}))
+	_, _, cached = GetRaw(ctx, cacheManager, namespace, "raw", func() ([]byte, error) {
+		return raw, nil
+	})
+	assert.False(t, cached)
+
+	// 二进制数据原样保存，不会被编码为base64
+	binary := []byte{0x00, 0xff, 0x89, 'P', 'N', 'G', 0x0d, 0x0a}
+	assert.NoError(t, SetRaw(ctx, cacheManager, namespace, "raw_binary", binary))
+	stored, err := cacheManager.Store().Get(ctx, cacheManager.buildKey(namespace, "raw_binary"))
+	assert.NoError(t, err)
+	data, err := toBytes(stored)
+	assert.NoError(t, err)
+	e, err := decodeEntry(data)
+	assert.NoError(t, err)
+	assert.Equal(t, binary, e.payload)
+	value, err, cached = GetRaw(ctx, cacheManager, namespace, "raw_binary", nil)
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, binary, value)
+}
+
+func TestSet(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t, WithTypeCheck())
+	type account struct {
+		ID   int
+		Name string
+	}
+
+	assert.NoError(t, Set(ctx, cacheManager, namespace, "set_account", account{ID: 1, Name: "Alice"}, WithTags("set_tag")))
+	value, err, cached := Get(ctx, cacheManager, namespace, "set_account", func() (account, error) {
+		return account{}, errors.New("should not be called")
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, account{ID: 1, Name: "Alice"}, value)
+
+	// 与Get使用相同的Codec
+	assert.NoError(t, Set(ctx, cacheManager, namespace, "set_gob", []int{1, 2}, WithCodec(GobCodec)))
+	ints, err, cached := Get(ctx, cacheManager, namespace, "set_gob", func() ([]int, error) {
+		return nil, nil
+	}, WithCodec(GobCodec))
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, []int{1, 2}, ints)
+
+	assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"set_tag"}))
+	_, _, cached = Get(ctx, cacheManager, namespace, "set_account", func() (account, error) {
+		return account{}, nil
+	})
+	assert.False(t, cached)
+
+	assert.ErrorIs(t, Set(ctx, cacheManager, "", "key", 1), ErrEmptyNamespace)
+}
+
+func TestDynamicTagsPanic(t *testing.T) {
+	ctx := context.Background()
+	panicking := WithDynamicTags(func() []string {
+		var teams map[string][]string
+		teams["missing"] = nil
+		return nil
+	})
+
+	t.Run("默认写入不包含panic的tag", func(t *testing.T) {
+		var reported []error
+		var tags []string
+		cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+			OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+				tags = options.Tags
+			},
+			OnError: func(ctx context.Context, namespace string, key string, err error) {
+				reported = append(reported, err)
+			},
+		}))
+
+		value, err, _ := Get(ctx, cacheManager, namespace, "tag_panic", func() (string, error) {
+			return "value", nil
+		}, WithTags("static"), panicking)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, []string{"static"}, tags)
+		assert.Len(t, reported, 1)
+		assert.ErrorIs(t, reported[0], ErrTagFuncPanic)
+
+		_, _, cached := Get(ctx, cacheManager, namespace, "tag_panic", func() (string, error) {
+			return "new value", nil
+		})
+		assert.True(t, cached)
+	})
+
+	t.Run("配置为不写入缓存", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithNoCacheOnTagPanic())
+
+		value, err, _ := Get(ctx, cacheManager, namespace, "tag_panic_no_cache", func() (string, error) {
+			return "value", nil
+		}, panicking)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+
+		_, _, cached := Get(ctx, cacheManager, namespace, "tag_panic_no_cache", func() (string, error) {
+			return "new value", nil
+		})
+		assert.False(t, cached)
+	})
+}
+
+func TestWithKeyHashFunc(t *testing.T) {
+	longKey := strings.Repeat("composite-", 20)
+	cacheManager := newTestGoCacheManager(t, WithAutoHashKeyAbove(64), WithKeyHashFunc(func() hash.Hash {
+		return xxhash.New()
+	}))
+
+	sum := xxhash.Sum64String(longKey)
+	expected := hex.EncodeToString(binary.BigEndian.AppendUint64(nil, sum))
+	assert.Equal(t, loadDefaultKeyPrefix()+":"+namespace+":h:"+expected, cacheManager.buildKey(namespace, longKey))
+}
+
+func BenchmarkBuildKeyHash(b *testing.B) {
+	longKey := strings.Repeat("composite-", 50)
+	hashFuncs := []struct {
+		name    string
+		newHash func() hash.Hash
+	}{
+		{"sha256", nil},
+		{"xxhash", func() hash.Hash { return xxhash.New() }},
+	}
+	for _, h := range hashFuncs {
+		b.Run(h.name, func(b *testing.B) {
+			cacheManager := NewCacheManager(nil, WithAutoHashKeyAbove(64), WithKeyHashFunc(h.newHash))
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				cacheManager.buildKey(namespace, longKey)
+			}
+		})
+	}
+}
+
+func TestGetWithTypeCheck(t *testing.T) {
+	ctx := context.Background()
+	type userV1 struct {
+		Name string
+	}
+	type userV2 struct {
+		Name  string
+		Email string
+	}
+
+	t.Run("类型变化时重新计算", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithTypeCheck())
+		_, _, _ = Get(ctx, cacheManager, namespace, "type_check", func() (userV1, error) {
+			return userV1{Name: "Alice"}, nil
+		})
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "type_check", func() (userV2, error) {
+			return userV2{Name: "Alice", Email: "alice@example.com"}, nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "alice@example.com", value.Email)
+
+		// 已被覆盖为新类型
+		value, err, cached = Get(ctx, cacheManager, namespace, "type_check", func() (userV2, error) {
+			return userV2{}, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "alice@example.com", value.Email)
+	})
+
+	t.Run("默认不校验", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, namespace, "type_check", func() (userV1, error) {
+			return userV1{Name: "Alice"}, nil
+		})
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "type_check", func() (userV2, error) {
+			return userV2{Name: "Alice", Email: "alice@example.com"}, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Empty(t, value.Email)
+	})
+}
+
+// ctxCheckingStore 写入时检查ctx是否已取消，模拟会响应取消的store
+type ctxCheckingStore struct {
+	*GoCacheStore
+}
+
+func (s *ctxCheckingStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.GoCacheStore.Set(ctx, key, value, options...)
+}
+
+func TestGetSharedCancellation(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := NewCacheManager(&ctxCheckingStore{NewGoCacheStore(gocache.New(5*time.Minute, 10*time.Minute))})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	fn := func() (string, error) {
+		close(started)
+		<-release
+		return "value", nil
+	}
+
+	// 发起计算的请求取消后可以立即返回
+	cancelCtx, cancel := context.WithCancel(ctx)
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err, _ := Get(cancelCtx, cacheManager, namespace, "shared_cancel", fn)
+		firstErr <- err
+	}()
+	<-started
+
+	secondResult := make(chan string, 1)
+	go func() {
+		value, err, _ := Get(ctx, cacheManager, namespace, "shared_cancel", func() (string, error) {
+			return "not shared", nil
+		})
+		assert.NoError(t, err)
+		secondResult <- value
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	// 其他请求不受影响，计算结果正常写入缓存
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Equal(t, "value", <-secondResult)
+	value, _, cached := Get(ctx, cacheManager, namespace, "shared_cancel", func() (string, error) {
+		return "new value", nil
+	})
+	assert.True(t, cached)
+	assert.Equal(t, "value", value)
+}
+
+func TestGetPanicInFn(t *testing.T) {
+	ctx := context.Background()
+	_, err, _ := Get(ctx, newTestGoCacheManager(t), namespace, "fn_panic", func() (string, error) {
+		panic("boom")
+	})
+	assert.ErrorContains(t, err, "boom")
+}
+
+// tagRecordingStore 记录每次写入的tag
+type tagRecordingStore struct {
+	*GoCacheStore
+	tags [][]string
+}
+
+func (s *tagRecordingStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	s.tags = append(s.tags, store.ApplyOptions(options...).Tags)
+	return s.GoCacheStore.Set(ctx, key, value, options...)
+}
+
+func TestCanonicalTags(t *testing.T) {
+	ctx := context.Background()
+	recorder := &tagRecordingStore{GoCacheStore: NewGoCacheStore(gocache.New(5*time.Minute, 10*time.Minute))}
+	cacheManager := NewCacheManager(recorder)
+
+	optionSets := [][]Option{
+		{WithTags("b", "a"), WithDynamicTags(func() []string { return []string{"c", "a"} })},
+		{WithTags("c"), WithTags("a", "b", "b")},
+		{WithDynamicTags(func() []string { return []string{"a"} }), WithTags("c", "b")},
+	}
+	for n, opts := range optionSets {
+		_, err, _ := Get(ctx, cacheManager, namespace, fmt.Sprintf("canonical_%d", n), func() (string, error) {
+			return "value", nil
+		}, opts...)
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, recorder.tags, len(optionSets))
+	for _, tags := range recorder.tags {
+		assert.Equal(t, []string{"a", "b", "c"}, tags)
+	}
+}
+
+func TestGetInto(t *testing.T) {
+	ctx := context.Background()
+	type profile struct {
+		Name  string
+		Email string `json:",omitempty"`
+	}
+	cacheManager := newTestGoCacheManager(t)
+	_, _, _ = Get(ctx, cacheManager, namespace, "into_with_email", func() (profile, error) {
+		return profile{Name: "Alice", Email: "alice@example.com"}, nil
+	})
+	_, _, _ = Get(ctx, cacheManager, namespace, "into_without_email", func() (profile, error) {
+		return profile{Name: "Bob"}, nil
+	})
+
+	var dst profile
+	err, cached := GetInto(ctx, cacheManager, namespace, "into_with_email", &dst, func() (profile, error) {
+		return profile{}, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, "alice@example.com", dst.Email)
+
+	// 复用dst时，缓存值中没有的字段不会残留
+	err, _ = GetInto(ctx, cacheManager, namespace, "into_without_email", &dst, func() (profile, error) {
+		return profile{}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, profile{Name: "Bob"}, dst)
+
+	// 需要合并时保留原有字段
+	dst = profile{Name: "Alice", Email: "alice@example.com"}
+	err, _ = GetInto(ctx, cacheManager, namespace, "into_without_email", &dst, func() (profile, error) {
+		return profile{}, nil
+	}, WithMergeInto())
+	assert.NoError(t, err)
+	assert.Equal(t, profile{Name: "Bob", Email: "alice@example.com"}, dst)
+}
+
+func TestGetPointer(t *testing.T) {
+	ctx := context.Background()
+	type user struct {
+		Name string
+	}
+	cacheManager := newTestGoCacheManager(t)
+	fail := func() (*user, error) {
+		return nil, errors.New("should not be called")
+	}
+
+	t.Run("缓存nil指针", func(t *testing.T) {
+		value, err, cached := Get(ctx, cacheManager, namespace, "ptr_nil", func() (*user, error) {
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Nil(t, value)
+
+		value, err, cached = Get(ctx, cacheManager, namespace, "ptr_nil", fail)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Nil(t, value)
+
+		// GetInto同样还原为nil，不保留dst原有的值
+		dst := &user{Name: "stale"}
+		err, cached = GetInto(ctx, cacheManager, namespace, "ptr_nil", &dst, fail, WithMergeInto())
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Nil(t, dst)
+	})
+
+	t.Run("缓存有值的指针", func(t *testing.T) {
+		_, _, _ = Get(ctx, cacheManager, namespace, "ptr_value", func() (*user, error) {
+			return &user{Name: "Alice"}, nil
+		})
+		value, err, cached := Get(ctx, cacheManager, namespace, "ptr_value", fail)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, &user{Name: "Alice"}, value)
+	})
+
+	t.Run("缓存空结构体与nil区分", func(t *testing.T) {
+		_, _, _ = Get(ctx, cacheManager, namespace, "ptr_empty", func() (*user, error) {
+			return &user{}, nil
+		})
+		value, err, cached := Get(ctx, cacheManager, namespace, "ptr_empty", fail)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.NotNil(t, value)
+		assert.Equal(t, &user{}, value)
+	})
+}
+
+func TestEmptyNamespaceAndKey(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+	fn := func() (string, error) {
+		return "value", nil
+	}
+
+	t.Run("namespace为空", func(t *testing.T) {
+		_, err, _ := Get(ctx, cacheManager, "", "key", fn)
+		assert.ErrorIs(t, err, ErrEmptyNamespace)
+		assert.ErrorIs(t, cacheManager.Delete(ctx, "", "key"), ErrEmptyNamespace)
+		assert.ErrorIs(t, cacheManager.SetRaw(ctx, "", "key", []byte("value")), ErrEmptyNamespace)
+	})
+
+	t.Run("key为空", func(t *testing.T) {
+		assert.Equal(t, loadDefaultKeyPrefix()+":"+namespace+":", cacheManager.buildKey(namespace, ""))
+		_, err, _ := Get(ctx, cacheManager, namespace, "", fn)
+		assert.NoError(t, err)
+		value, err, cached := Get(ctx, cacheManager, namespace, "", fn)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "value", value)
+		assert.NoError(t, cacheManager.Delete(ctx, namespace, ""))
+	})
+
+	t.Run("namespace和key都为空", func(t *testing.T) {
+		_, err, _ := Get(ctx, cacheManager, "", "", fn)
+		assert.ErrorIs(t, err, ErrEmptyNamespace)
+		assert.ErrorIs(t, cacheManager.Delete(ctx, "", ""), ErrEmptyNamespace)
+	})
+}
+
+func TestGetEmptyStoredValue(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+
+	// 空字符串和空[]byte编码后不为空，可以正常读取
+	_, _, _ = Get(ctx, cacheManager, namespace, "empty_string", func() (string, error) {
+		return "", nil
+	})
+	str, err, cached := Get(ctx, cacheManager, namespace, "empty_string", func() (string, error) {
+		return "new value", nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, "", str)
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "empty_bytes", func() ([]byte, error) {
+		return []byte{}, nil
+	})
+	data, err, cached := Get(ctx, cacheManager, namespace, "empty_bytes", func() ([]byte, error) {
+		return []byte("new value"), nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Empty(t, data)
+
+	// 外部写入的空值返回明确的错误，而不是json解析错误
+	_ = cacheManager.cache.Set(ctx, cacheManager.buildKey(namespace, "external_empty"), []byte{})
+	_, err, _ = Get(ctx, cacheManager, namespace, "external_empty", func() (map[string]string, error) {
+		return nil, nil
+	})
+	var decodeErr *DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.ErrorIs(t, err, ErrEmptyValue)
+}
+
+func TestSetDefaultsConcurrently(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+	prefix := loadDefaultKeyPrefix()
+	expiration := loadDefaultExpiration()
+	codec := loadDefaultCodec()
+	defer SetDefaultKeyPrefix(prefix)
+	defer SetDefaultExpiration(expiration)
+	defer SetDefaultCodec(codec)
+
+	// 使用-race运行时检查全局默认值的读写没有数据竞争
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for m := 0; m < 100; m++ {
+				SetDefaultKeyPrefix(prefix)
+				SetDefaultExpiration(expiration)
+				SetDefaultCodec(codec)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for m := 0; m < 100; m++ {
+				_, err, _ := Get(ctx, cacheManager, namespace, fmt.Sprint("defaults_race_", m), func() (int, error) {
+					return m, nil
+				})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCacheMissTotal(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+	ns := "miss_total"
+	fn := func() (string, error) {
+		return "value", nil
+	}
+
+	_, _, _ = Get(ctx, cacheManager, ns, "key", fn)
+	assert.Equal(t, float64(1), testutil.ToFloat64(CacheMissTotal.WithLabelValues(ns)))
+	_, _, _ = Get(ctx, cacheManager, ns, "key", fn)
+	assert.Equal(t, float64(1), testutil.ToFloat64(CacheMissTotal.WithLabelValues(ns)))
+	_, _, _ = Get(ctx, cacheManager, ns, "other", fn)
+	assert.Equal(t, float64(2), testutil.ToFloat64(CacheMissTotal.WithLabelValues(ns)))
+}
+
+type stringerValue string
+
+func (s stringerValue) String() string {
+	return string(s)
+}
+
+func TestToBytes(t *testing.T) {
+	raw := []byte("value")
+	cases := map[string]any{
+		"go-cache的[]byte": raw,
+		"redis的string":    "value",
+		"json.RawMessage": json.RawMessage("value"),
+		"*[]byte":         &raw,
+		"fmt.Stringer":    stringerValue("value"),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			value, err := toBytes(data)
+			assert.NoError(t, err)
+			assert.Equal(t, raw, value)
+		})
+	}
+
+	t.Run("不支持的类型", func(t *testing.T) {
+		var nilBytes *[]byte
+		for _, data := range []any{123, nil, nilBytes} {
+			_, err := toBytes(data)
+			assert.ErrorIs(t, err, ErrUnsupportedDataType)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+// Package cacheabletest 提供测试go-cacheable相关逻辑的工具
+package cacheabletest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/eko/gocache/store/go_cache/v4"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// Operation store的操作类型，Get和GetWithTTL都属于OpGet
+type Operation string
+
+const (
+	OpGet        Operation = "get"
+	OpSet        Operation = "set"
+	OpDelete     Operation = "delete"
+	OpInvalidate Operation = "invalidate"
+)
+
+// ErrInjected FailNext没有指定错误时返回的错误
+var ErrInjected = errors.New("cacheabletest: injected store error")
+
+type fault struct {
+	remaining int
+	err       error
+	latency   time.Duration
+	calls     int
+}
+
+// FaultInjectingStore 可以按需返回错误或增加延迟的store，用于确定性地测试重试、熔断、降级等逻辑，
+// 没有注入故障的操作直接转发给底层store
+type FaultInjectingStore struct {
+	store.StoreInterface
+
+	mu     sync.Mutex
+	faults map[Operation]*fault
+}
+
+// NewFaultInjectingStore 包装underlying，underlying为nil时使用内存中的go-cache
+func NewFaultInjectingStore(underlying store.StoreInterface) *FaultInjectingStore {
+	if underlying == nil {
+		underlying = go_cache.NewGoCache(gocache.New(5*time.Minute, 10*time.Minute))
+	}
+	return &FaultInjectingStore{
+		StoreInterface: underlying,
+		faults:         make(map[Operation]*fault),
+	}
+}
+
+// FailNext 接下来n次op操作返回err，err为nil时返回ErrInjected
+func (s *FaultInjectingStore) FailNext(op Operation, n int, err error) {
+	if err == nil {
+		err = ErrInjected
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.fault(op)
+	f.remaining = n
+	f.err = err
+}
+
+// SetLatency 每次op操作前等待d，ctx取消时提前返回ctx.Err()
+func (s *FaultInjectingStore) SetLatency(op Operation, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fault(op).latency = d
+}
+
+// Calls 返回op操作被调用的次数，包括注入了错误的调用
+func (s *FaultInjectingStore) Calls(op Operation) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fault(op).calls
+}
+
+// Reset 清除所有注入的故障和调用次数
+func (s *FaultInjectingStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[Operation]*fault)
+}
+
+func (s *FaultInjectingStore) fault(op Operation) *fault {
+	f, ok := s.faults[op]
+	if !ok {
+		f = &fault{}
+		s.faults[op] = f
+	}
+	return f
+}
+
+// inject 记录调用并返回需要注入的错误
+func (s *FaultInjectingStore) inject(ctx context.Context, op Operation) error {
+	s.mu.Lock()
+	f := s.fault(op)
+	f.calls++
+	latency := f.latency
+	var err error
+	if f.remaining > 0 {
+		f.remaining--
+		err = f.err
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+	return err
+}
+
+func (s *FaultInjectingStore) Get(ctx context.Context, key any) (any, error) {
+	if err := s.inject(ctx, OpGet); err != nil {
+		return nil, err
+	}
+	return s.StoreInterface.Get(ctx, key)
+}
+
+func (s *FaultInjectingStore) GetWithTTL(ctx context.Context, key any) (any, time.Duration, error) {
+	if err := s.inject(ctx, OpGet); err != nil {
+		return nil, 0, err
+	}
+	return s.StoreInterface.GetWithTTL(ctx, key)
+}
+
+func (s *FaultInjectingStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	if err := s.inject(ctx, OpSet); err != nil {
+		return err
+	}
+	return s.StoreInterface.Set(ctx, key, value, options...)
+}
+
+func (s *FaultInjectingStore) Delete(ctx context.Context, key any) error {
+	if err := s.inject(ctx, OpDelete); err != nil {
+		return err
+	}
+	return s.StoreInterface.Delete(ctx, key)
+}
+
+func (s *FaultInjectingStore) Invalidate(ctx context.Context, options ...store.InvalidateOption) error {
+	if err := s.inject(ctx, OpInvalidate); err != nil {
+		return err
+	}
+	return s.StoreInterface.Invalidate(ctx, options...)
+}
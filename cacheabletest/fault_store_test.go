@@ -0,0 +1,60 @@
+package cacheabletest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjectingStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("注入指定次数的错误", func(t *testing.T) {
+		s := NewFaultInjectingStore(nil)
+		s.FailNext(OpSet, 2, nil)
+
+		assert.ErrorIs(t, s.Set(ctx, "key", []byte("value")), ErrInjected)
+		assert.ErrorIs(t, s.Set(ctx, "key", []byte("value")), ErrInjected)
+		assert.NoError(t, s.Set(ctx, "key", []byte("value")))
+		assert.Equal(t, 3, s.Calls(OpSet))
+
+		value, err := s.Get(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("注入指定类型的错误", func(t *testing.T) {
+		s := NewFaultInjectingStore(nil)
+		customErr := errors.New("connection reset")
+		s.FailNext(OpGet, 1, customErr)
+
+		_, err := s.Get(ctx, "key")
+		assert.ErrorIs(t, err, customErr)
+		_, err = s.Get(ctx, "key")
+		assert.ErrorIs(t, err, store.NotFound{})
+	})
+
+	t.Run("注入延迟", func(t *testing.T) {
+		s := NewFaultInjectingStore(nil)
+		s.SetLatency(OpDelete, 50*time.Millisecond)
+
+		start := time.Now()
+		assert.NoError(t, s.Delete(ctx, "key"))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, s.Delete(timeoutCtx, "key"), context.DeadlineExceeded)
+	})
+
+	t.Run("重置", func(t *testing.T) {
+		s := NewFaultInjectingStore(nil)
+		s.FailNext(OpInvalidate, 1, nil)
+		s.Reset()
+		assert.NoError(t, s.Invalidate(ctx, store.WithInvalidateTags([]string{"tag"})))
+	})
+}
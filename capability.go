@@ -0,0 +1,132 @@
+package cacheable
+
+import (
+	"context"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// 以下接口是store的可选能力，store.StoreInterface并没有统一提供这些操作，
+// CacheManager在运行时通过类型断言检测store是否支持，不支持时回退到通用实现
+
+// TagUnlinker 可以异步删除tag关联的全部key，例如使用redis的UNLINK代替DEL，由服务端在后台回收内存
+type TagUnlinker interface {
+	UnlinkTags(ctx context.Context, tags []string) error
+}
+
+// TagKeysReader 可以读取tag关联的全部key
+type TagKeysReader interface {
+	TagKeys(ctx context.Context, tag string) ([]string, error)
+}
+
+// TTLUpdater 可以修改已有key的有效期，例如redis的EXPIRE命令
+type TTLUpdater interface {
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+}
+
+// Locker 基于store实现的分布式锁，锁必须带有过期时间，持有者崩溃后锁会自动释放。
+// token用于标识持有者，只有持有者才能释放锁
+type Locker interface {
+	TryLock(ctx context.Context, name string, token string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, name string, token string) error
+}
+
+// LockExtender 由Locker可选实现，锁的值仍为token时将过期时间重置为ttl，返回false表示锁已经不属于token。
+// 实现后持有写锁期间会定期续期，fn的执行时间可以超过锁的过期时间
+type LockExtender interface {
+	ExtendLock(ctx context.Context, name string, token string, ttl time.Duration) (bool, error)
+}
+
+// TagSupporter 由store可选实现，SupportsTags返回false表示store不支持tag，
+// 此时DeleteByTags返回ErrTagsUnsupported，除非CacheManager开启了WithTagIndex
+type TagSupporter interface {
+	SupportsTags() bool
+}
+
+// KeysDeleter 可以在一次请求中删除多个key，例如redis的pipeline。
+// 部分key删除失败时可以返回由KeyDeleteError合并的错误，以便只广播删除成功的key
+type KeysDeleter interface {
+	DeleteKeys(ctx context.Context, keys []string) error
+}
+
+// TTLReader 可以读取key剩余的有效期，key不存在时返回store.NotFound，key没有过期时间时返回0
+type TTLReader interface {
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// KeyScanner 可以枚举以prefix开头的全部key，例如redis的SCAN，结果中可能包含已过期但还未清理的key
+type KeyScanner interface {
+	ScanKeys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// MultiGetter 可以在一次请求中读取多个key，返回值与keys一一对应，不存在的key对应nil
+type MultiGetter interface {
+	GetMany(ctx context.Context, keys []string) ([]any, error)
+}
+
+// MultiSetter 可以在一次请求中写入多个不带tag的key，values与keys一一对应。
+// MGet写回未命中的结果时使用，不实现时逐个写入
+type MultiSetter interface {
+	SetMany(ctx context.Context, keys []string, values [][]byte, expiration time.Duration) error
+}
+
+// AbsentSetter 可以在key不存在时原子地写入，例如redis的SET NX，返回是否写入成功
+type AbsentSetter interface {
+	SetIfAbsent(ctx context.Context, key any, value any, options ...store.Option) (bool, error)
+}
+
+// Pinger 可以检查store是否可用，例如redis的PING
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Capabilities 描述store实现了哪些可选能力，批量操作在store不支持时由CacheManager逐个执行，结果相同但性能较差
+type Capabilities struct {
+	// MultiGet 批量读取由store一次完成
+	MultiGet bool
+	// MultiSet 批量写入由store一次完成
+	MultiSet bool
+	// MultiDelete 批量删除由store一次完成
+	MultiDelete bool
+	// TagKeys 可以读取tag关联的key，PreviewDeleteByTags和TagStats可用
+	TagKeys bool
+	// KeyScan 可以枚举namespace下的key，Export、Entries和ClearNamespace可用
+	KeyScan   bool
+	TTLRead   bool
+	TTLUpdate bool
+	Lock      bool
+	// SetIfAbsent 可以原子地写入不存在的key，SetIfAbsent可用
+	SetIfAbsent bool
+	// Tags store支持tag，未实现TagSupporter的store视为支持
+	Tags bool
+}
+
+// Capabilities 返回store实现的可选能力，用于判断批量操作是否高效
+func (i *CacheManager) Capabilities() Capabilities {
+	_, multiGet := i.cache.(MultiGetter)
+	_, multiSet := i.cache.(MultiSetter)
+	_, multiDelete := i.cache.(KeysDeleter)
+	_, tagKeys := i.cache.(TagKeysReader)
+	_, keyScan := i.cache.(KeyScanner)
+	_, ttlRead := i.cache.(TTLReader)
+	_, ttlUpdate := i.cache.(TTLUpdater)
+	_, lock := i.cache.(Locker)
+	_, setIfAbsent := i.cache.(AbsentSetter)
+	tags := true
+	if supporter, ok := i.cache.(TagSupporter); ok {
+		tags = supporter.SupportsTags()
+	}
+	return Capabilities{
+		MultiGet:    multiGet,
+		MultiSet:    multiSet,
+		MultiDelete: multiDelete,
+		TagKeys:     tagKeys || i.tagIndex,
+		KeyScan:     keyScan,
+		TTLRead:     ttlRead,
+		TTLUpdate:   ttlUpdate,
+		Lock:        lock,
+		SetIfAbsent: setIfAbsent,
+		Tags:        tags || i.tagIndex,
+	}
+}
@@ -0,0 +1,37 @@
+package cacheable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// checksumTable 校验和使用CRC-32C
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WithChecksum 写入缓存时在头部记录payload（压缩、加密之后）的CRC-32C校验和，读取时校验不一致视为缓存不存在，
+// 重新调用fn并覆盖，同时记录到cache_errors_total{kind="checksum"}，用于发现网络问题等导致的截断或损坏的值。
+// 读取时只要缓存记录了校验和就会校验，与是否开启无关；没有记录校验和的缓存（关闭时或旧版本写入）不做校验
+func WithChecksum() ManagerOption {
+	return func(m *CacheManager) {
+		m.checksum = true
+	}
+}
+
+// sum 记录entry的payload的校验和，没有开启WithChecksum时不做处理
+func (i *CacheManager) sum(e *entry) {
+	if i.checksum {
+		e.checksum = binary.BigEndian.AppendUint32(nil, crc32.Checksum(e.payload, checksumTable))
+	}
+}
+
+// verifyChecksum 校验entry的payload，没有记录校验和时不做校验
+func verifyChecksum(e *entry) error {
+	if e.checksum == nil {
+		return nil
+	}
+	if !bytes.Equal(e.checksum, binary.BigEndian.AppendUint32(nil, crc32.Checksum(e.payload, checksumTable))) {
+		return errChecksumMismatch
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cacheable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithChecksum(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{1}, 32)
+
+	// corrupt 修改store中的值
+	corrupt := func(t *testing.T, cacheManager *CacheManager, ns string, key string, fn func(data []byte) []byte) {
+		storeKey := cacheManager.buildKey(ns, key)
+		stored, err := cacheManager.cache.Get(ctx, storeKey)
+		assert.NoError(t, err)
+		data := append([]byte{}, stored.([]byte)...)
+		assert.NoError(t, cacheManager.cache.Set(ctx, storeKey, fn(data)))
+	}
+
+	t.Run("与压缩和加密一起使用", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithChecksum(), WithEncryption(key))
+		payload := compressiblePayload()
+		for i := 0; i < 2; i++ {
+			value, err, cached := cacheManager.Get(ctx, namespace, "checksum", func() ([]byte, error) {
+				return payload, nil
+			}, WithCompression(CompressionZstd))
+			assert.NoError(t, err)
+			assert.Equal(t, i == 1, cached)
+			assert.Equal(t, payload, value)
+		}
+
+		stored, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey(namespace, "checksum"))
+		e, err := decodeEntry(stored.([]byte))
+		assert.NoError(t, err)
+		assert.Len(t, e.checksum, 4)
+		assert.Equal(t, CompressionZstd, e.compression)
+		assert.NotEmpty(t, e.encryption)
+	})
+
+	for name, modify := range map[string]func(data []byte) []byte{
+		"内容被修改": func(data []byte) []byte {
+			data[len(data)-1] ^= 0xFF
+			return data
+		},
+		"内容被截断": func(data []byte) []byte {
+			return data[:len(data)-3]
+		},
+	} {
+		t.Run(name+"时重新调用fn", func(t *testing.T) {
+			ns := "checksum_corrupted"
+			cacheManager := newTestGoCacheManager(t, WithChecksum())
+			assert.NoError(t, Set(ctx, cacheManager, ns, "user", map[string]string{"name": "Alice"}))
+			corrupt(t, cacheManager, ns, "user", modify)
+			before := testutil.ToFloat64(CacheErrorTotal.WithLabelValues(ns, opGet, errKindChecksum))
+
+			value, err, cached := Get(ctx, cacheManager, ns, "user", func() (map[string]string, error) {
+				return map[string]string{"name": "Bob"}, nil
+			})
+			assert.NoError(t, err)
+			assert.False(t, cached)
+			assert.Equal(t, "Bob", value["name"])
+			assert.Equal(t, before+1, testutil.ToFloat64(CacheErrorTotal.WithLabelValues(ns, opGet, errKindChecksum)))
+
+			// 已经用新值覆盖
+			value, err, cached = Get(ctx, cacheManager, ns, "user", func() (map[string]string, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			assert.True(t, cached)
+			assert.Equal(t, "Bob", value["name"])
+		})
+	}
+
+	t.Run("没有开启时同样校验已记录的校验和", func(t *testing.T) {
+		sharedStore := newGoCacheStore()
+		writer := NewCacheManager(sharedStore, WithChecksum())
+		reader := NewCacheManager(sharedStore)
+		assert.NoError(t, Set(ctx, writer, namespace, "checksum_reader", "value"))
+
+		value, err, cached := Get(ctx, reader, namespace, "checksum_reader", func() (string, error) {
+			return "", errors.New("should not be called")
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "value", value)
+
+		corrupt(t, reader, namespace, "checksum_reader", func(data []byte) []byte {
+			data[len(data)-1] ^= 0xFF
+			return data
+		})
+		value, err, cached = Get(ctx, reader, namespace, "checksum_reader", func() (string, error) {
+			return "recomputed", nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "recomputed", value)
+	})
+
+	t.Run("编码和解码", func(t *testing.T) {
+		e := &entry{checksum: []byte{1, 2, 3, 4}, payload: []byte("payload")}
+		decoded, err := decodeEntry(encodeEntry(e))
+		assert.NoError(t, err)
+		assert.Equal(t, e, decoded)
+
+		header := appendField(nil, fieldChecksum, []byte{1, 2})
+		data := append(append([]byte{}, entryMagic...), entryVersion, byte(len(header)))
+		_, err = decodeEntry(append(data, header...))
+		assert.ErrorIs(t, err, ErrCorruptedEntry)
+	})
+}
@@ -0,0 +1,161 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// CircuitState 熔断器的状态，同时也是cache_circuit_breaker_state的值
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// CircuitBreakerSettings 熔断器配置，字段为0时使用默认值
+type CircuitBreakerSettings struct {
+	// Name 熔断器的名称，作为cache_circuit_breaker_state的name标签，多个CacheManager使用相同的key前缀时需要区分，默认为key前缀
+	Name string
+	// FailureThreshold 连续失败多少次后断开，默认5
+	FailureThreshold int
+	// Window 两次失败的间隔超过Window时重新计数，默认10秒
+	Window time.Duration
+	// OpenTimeout 断开多久后放行一个探测请求，探测成功后恢复，失败则继续断开，默认30秒
+	OpenTimeout time.Duration
+}
+
+// WithCircuitBreaker store连续返回错误（store.NotFound和context错误除外）后断开，断开期间不再访问store，
+// 读取直接调用fn（仍然经过singleflight合并），写入直接跳过，避免store故障时每个请求都等待连接超时。
+// 断开OpenTimeout后放行一个请求探测store是否恢复。状态记录在cache_circuit_breaker_state中，name标签为settings.Name
+func WithCircuitBreaker(settings CircuitBreakerSettings) ManagerOption {
+	return func(m *CacheManager) {
+		if settings.FailureThreshold <= 0 {
+			settings.FailureThreshold = 5
+		}
+		if settings.Window <= 0 {
+			settings.Window = 10 * time.Second
+		}
+		if settings.OpenTimeout <= 0 {
+			settings.OpenTimeout = 30 * time.Second
+		}
+		m.breaker = &circuitBreaker{settings: settings, now: m.now, onStateChange: func(state CircuitState) {
+			name := settings.Name
+			if name == "" {
+				name = m.keyPrefix()
+			}
+			m.metrics.circuitState(name, state)
+		}}
+	}
+}
+
+// CircuitState 返回熔断器当前的状态，没有开启WithCircuitBreaker时总是CircuitClosed
+func (i *CacheManager) CircuitState() CircuitState {
+	if i.breaker == nil {
+		return CircuitClosed
+	}
+	i.breaker.mu.Lock()
+	defer i.breaker.mu.Unlock()
+	return i.breaker.state
+}
+
+type circuitBreaker struct {
+	settings      CircuitBreakerSettings
+	now           func() time.Time
+	onStateChange func(state CircuitState)
+
+	mu          sync.Mutex
+	state       CircuitState
+	failures    int
+	lastFailure time.Time
+	openedAt    time.Time
+	// probing 半开状态下已经放行了探测请求
+	probing bool
+}
+
+// allow 判断是否可以访问store，断开超过OpenTimeout后只放行一个探测请求
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.settings.OpenTimeout {
+			return false
+		}
+		b.setState(CircuitHalfOpen)
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record 记录一次store访问的结果
+func (b *circuitBreaker) record(err error) {
+	if isContextError(err) {
+		b.mu.Lock()
+		//探测请求被取消，允许下一个请求继续探测
+		b.probing = false
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil || errors.Is(err, store.NotFound{}) {
+		b.failures = 0
+		b.probing = false
+		if b.state != CircuitClosed {
+			b.setState(CircuitClosed)
+		}
+		return
+	}
+
+	now := b.now()
+	if now.Sub(b.lastFailure) > b.settings.Window {
+		b.failures = 0
+	}
+	b.failures++
+	b.lastFailure = now
+	if b.state == CircuitHalfOpen || b.failures >= b.settings.FailureThreshold {
+		b.probing = false
+		b.openedAt = now
+		b.setState(CircuitOpen)
+	}
+}
+
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.onStateChange(state)
+}
+
+// errCircuitOpen 熔断器断开，没有访问store
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// guardStore 在熔断器允许时执行op并记录结果，断开时返回errCircuitOpen，没有开启WithCircuitBreaker时直接执行op
+func (i *CacheManager) guardStore(ctx context.Context, op func() error) error {
+	if i.breaker == nil {
+		return op()
+	}
+	if !i.breaker.allow() {
+		return errCircuitOpen
+	}
+	err := op()
+	if err != nil && ctx.Err() != nil {
+		//调用方取消导致的错误不计入失败
+		i.breaker.record(ctx.Err())
+	} else {
+		i.breaker.record(err)
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// downStore down为true时Get和Set返回错误
+type downStore struct {
+	store.StoreInterface
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+var errStoreDown = errors.New("dial tcp: i/o timeout")
+
+func (s *downStore) Get(ctx context.Context, key any) (any, error) {
+	s.calls.Add(1)
+	if s.down.Load() {
+		return nil, errStoreDown
+	}
+	return s.StoreInterface.Get(ctx, key)
+}
+
+func (s *downStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	s.calls.Add(1)
+	if s.down.Load() {
+		return errStoreDown
+	}
+	return s.StoreInterface.Set(ctx, key, value, options...)
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	now := time.Now()
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+	s := &downStore{StoreInterface: newGoCacheStore()}
+	cacheManager := NewCacheManager(s, WithKeyPrefix("breaker"), WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}), WithCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 3, OpenTimeout: time.Minute}))
+	fn := func() (string, error) {
+		return "value", nil
+	}
+	state := func() float64 {
+		return testutil.ToFloat64(CacheCircuitBreakerState.WithLabelValues("breaker"))
+	}
+
+	s.down.Store(true)
+	for n := 0; n < 3; n++ {
+		_, err, _ := Get(ctx, cacheManager, namespace, "breaker_key", fn)
+		assert.ErrorIs(t, err, errStoreDown)
+	}
+	assert.Equal(t, CircuitOpen, cacheManager.CircuitState())
+	assert.Equal(t, float64(CircuitOpen), state())
+
+	t.Run("断开时直接调用fn", func(t *testing.T) {
+		calls := s.calls.Load()
+		value, err, cached := Get(ctx, cacheManager, namespace, "breaker_key", fn)
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, calls, s.calls.Load())
+	})
+
+	t.Run("探测失败继续断开", func(t *testing.T) {
+		advance(time.Minute)
+		_, err, _ := Get(ctx, cacheManager, namespace, "breaker_key", fn)
+		assert.ErrorIs(t, err, errStoreDown)
+		assert.Equal(t, CircuitOpen, cacheManager.CircuitState())
+	})
+
+	t.Run("探测成功后恢复", func(t *testing.T) {
+		s.down.Store(false)
+		advance(time.Minute)
+		value, err, _ := Get(ctx, cacheManager, namespace, "breaker_key", fn)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, CircuitClosed, cacheManager.CircuitState())
+		assert.Equal(t, float64(CircuitClosed), state())
+
+		_, _, cached := Get(ctx, cacheManager, namespace, "breaker_key", fn)
+		assert.True(t, cached)
+	})
+
+	t.Run("窗口外的失败重新计数", func(t *testing.T) {
+		s.down.Store(true)
+		for n := 0; n < 4; n++ {
+			_, _, _ = Get(ctx, cacheManager, namespace, "breaker_window", fn)
+			advance(11 * time.Second)
+		}
+		assert.Equal(t, CircuitClosed, cacheManager.CircuitState())
+	})
+}
+
+func TestCircuitBreakerName(t *testing.T) {
+	ctx := context.Background()
+	fn := func() (string, error) {
+		return "value", nil
+	}
+	//两个CacheManager使用相同的key前缀，按name区分
+	down := &downStore{StoreInterface: newGoCacheStore()}
+	down.down.Store(true)
+	orders := NewCacheManager(down, WithKeyPrefix("shared"), WithCircuitBreaker(CircuitBreakerSettings{Name: "orders", FailureThreshold: 1}))
+	users := NewCacheManager(newGoCacheStore(), WithKeyPrefix("shared"), WithCircuitBreaker(CircuitBreakerSettings{Name: "users", FailureThreshold: 1}))
+
+	_, _, _ = Get(ctx, orders, namespace, "breaker_name", fn)
+	_, _, _ = Get(ctx, users, namespace, "breaker_name", fn)
+	assert.Equal(t, float64(CircuitOpen), testutil.ToFloat64(CacheCircuitBreakerState.WithLabelValues("orders")))
+	assert.Equal(t, float64(CircuitClosed), testutil.ToFloat64(CacheCircuitBreakerState.WithLabelValues("users")))
+}
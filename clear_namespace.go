@@ -0,0 +1,43 @@
+package cacheable
+
+import "context"
+
+// clearBatchSize ClearNamespace每次删除的key数量
+const clearBatchSize = 1000
+
+// ClearNamespace 删除namespace下的全部缓存，包括WithNegativeCache缓存的错误，用于发布时整体失效某类数据。
+// 需要store实现KeyScanner，否则返回ErrNotSupported。
+// redis使用SCAN枚举key，耗时与整个keyspace的大小成正比而不是namespace的大小，key数量很大时应避免频繁调用；
+// 枚举期间新写入的key可能不会被删除
+func (i *CacheManager) ClearNamespace(ctx context.Context, namespace string) error {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	return i.deleteByScan(ctx, namespace, i.namespacePrefix(i.tenantNamespace(ctx, namespace)))
+}
+
+// deleteByScan 分批删除store中以prefix开头的全部key，namespace用于记录指标
+func (i *CacheManager) deleteByScan(ctx context.Context, namespace string, prefix string) error {
+	scanner, ok := i.cache.(KeyScanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	keys, err := scanner.ScanKeys(ctx, prefix)
+	if err != nil {
+		i.metrics.error(namespace, opDelete, errKindStore)
+		return err
+	}
+
+	for start := 0; start < len(keys); start += clearBatchSize {
+		end := min(start+clearBatchSize, len(keys))
+		if err := i.deleteKeys(ctx, keys[start:end]); err != nil {
+			i.metrics.error(namespace, opDelete, errKindStore)
+			return err
+		}
+	}
+	return nil
+}
+
+func ClearNamespace(ctx context.Context, cacheManager *CacheManager, namespace string) error {
+	return cacheManager.ClearNamespace(ctx, namespace)
+}
@@ -0,0 +1,43 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClearNamespace(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"redis":    NewCacheManager(redisStore),
+		"go-cache": newTestGoCacheManager(t),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"clear1", "clear2"} {
+				assert.NoError(t, Set(ctx, cacheManager, "clear_ns", key, "value"))
+			}
+			assert.NoError(t, Set(ctx, cacheManager, "clear_ns_other", "clear1", "value"))
+
+			assert.NoError(t, ClearNamespace(ctx, cacheManager, "clear_ns"))
+
+			for _, key := range []string{"clear1", "clear2"} {
+				exists, err := Exists(ctx, cacheManager, "clear_ns", key)
+				assert.NoError(t, err)
+				assert.False(t, exists)
+			}
+			//前缀相同的其他namespace不受影响
+			exists, err := Exists(ctx, cacheManager, "clear_ns_other", "clear1")
+			assert.NoError(t, err)
+			assert.True(t, exists)
+		})
+	}
+
+	t.Run("store不支持", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore())
+		assert.ErrorIs(t, ClearNamespace(ctx, cacheManager, "clear_ns"), ErrNotSupported)
+	})
+}
@@ -0,0 +1,81 @@
+package cacheable
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"sync/atomic"
+)
+
+// Codec 泛型Get使用的序列化方式，默认使用json，读取时兼容PrimitiveCodec写入的值
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec 使用encoding/json序列化
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec 使用encoding/gob序列化，可以保留json会丢失的类型信息，例如map[string]any中的整数类型。
+	// 不支持nil指针和包含未注册接口类型的值
+	GobCodec Codec = gobCodec{}
+	// PrimitiveCodec string、bool、数值和[]byte以0x00开头的文本保存，跳过json，其他类型使用json。
+	// 升级前的版本无法读取，需要全部实例升级后再启用，默认编码可以读取它写入的值
+	PrimitiveCodec Codec = primitiveCodec{}
+)
+
+// defaultCodec 全局的默认Codec，为空或指向nil时使用内置的编码。可能在读写缓存的同时被修改，使用原子变量
+var defaultCodec atomic.Pointer[Codec]
+
+// SetDefaultCodec 设置泛型Get默认的序列化方式，优先级低于WithCodec和NamespaceConfig.Codec，传入nil恢复内置的编码。
+// 需要在使用前调用，修改后已有的缓存可能无法读取
+func SetDefaultCodec(codec Codec) {
+	defaultCodec.Store(&codec)
+}
+
+func loadDefaultCodec() Codec {
+	if codec := defaultCodec.Load(); codec != nil {
+		return *codec
+	}
+	return nil
+}
+
+// WithCodec 设置本次调用泛型Get使用的序列化方式，优先级高于NamespaceConfig.Codec。
+// 写入和读取同一个key时需要使用相同的Codec
+func WithCodec(codec Codec) Option {
+	return func(o *Options) {
+		o.codec = codec
+	}
+}
+
+// callCodec 返回本次调用使用的Codec，优先级为WithCodec > NamespaceConfig.Codec > SetDefaultCodec
+func (i *CacheManager) callCodec(namespace string, opts []Option) Codec {
+	if codec := applyOptions(opts...).codec; codec != nil {
+		return codec
+	}
+	return i.codecFor(namespace)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
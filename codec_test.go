@@ -0,0 +1,89 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type codecItem struct {
+	Name  string
+	Count int
+	Attrs map[string]any
+}
+
+func TestCodec(t *testing.T) {
+	ctx := context.Background()
+
+	for name, codec := range map[string]Codec{"json": JSONCodec, "gob": GobCodec} {
+		t.Run(name+"往返", func(t *testing.T) {
+			cacheManager := newTestGoCacheManager(t)
+			item := codecItem{Name: "a", Count: 1, Attrs: map[string]any{"k": "v"}}
+
+			for i := 0; i < 2; i++ {
+				value, err, cached := Get(ctx, cacheManager, namespace, "codec_struct", func() (codecItem, error) {
+					return item, nil
+				}, WithCodec(codec))
+				assert.NoError(t, err)
+				assert.Equal(t, i == 1, cached)
+				assert.Equal(t, item, value)
+			}
+
+			for i := 0; i < 2; i++ {
+				value, err, _ := Get(ctx, cacheManager, namespace, "codec_slice", func() ([]codecItem, error) {
+					return []codecItem{{Name: "a"}, {Name: "b"}}, nil
+				}, WithCodec(codec))
+				assert.NoError(t, err)
+				assert.Equal(t, []codecItem{{Name: "a"}, {Name: "b"}}, value)
+			}
+
+			for i := 0; i < 2; i++ {
+				value, err, _ := Get(ctx, cacheManager, namespace, "codec_pointer", func() (*codecItem, error) {
+					return &codecItem{Name: "p", Count: 2}, nil
+				}, WithCodec(codec))
+				assert.NoError(t, err)
+				assert.Equal(t, &codecItem{Name: "p", Count: 2}, value)
+			}
+		})
+	}
+
+	t.Run("gob保留整数类型", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		fn := func() (map[string]any, error) {
+			return map[string]any{"n": 1}, nil
+		}
+		_, _, _ = Get(ctx, cacheManager, namespace, "codec_gob_map", fn, WithCodec(GobCodec))
+		value, err, cached := Get(ctx, cacheManager, namespace, "codec_gob_map", fn, WithCodec(GobCodec))
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, 1, value["n"])
+	})
+
+	t.Run("优先级", func(t *testing.T) {
+		SetDefaultCodec(GobCodec)
+		defer SetDefaultCodec(nil)
+		cacheManager := newTestGoCacheManager(t)
+		cacheManager.RegisterNamespace("codec_json", NamespaceConfig{Codec: JSONCodec})
+
+		_, _, _ = Get(ctx, cacheManager, namespace, "codec_default", func() (codecItem, error) {
+			return codecItem{Name: "gob"}, nil
+		})
+		raw, err := cacheManager.cache.Get(ctx, cacheManager.buildKey(namespace, "codec_default"))
+		assert.NoError(t, err)
+		e, err := cacheManager.decodeValue(cacheManager.buildKey(namespace, "codec_default"), raw.([]byte))
+		assert.NoError(t, err)
+		var decoded codecItem
+		assert.NoError(t, GobCodec.Unmarshal(e.payload, &decoded))
+		assert.Equal(t, "gob", decoded.Name)
+
+		_, _, _ = Get(ctx, cacheManager, "codec_json", "item", func() (codecItem, error) {
+			return codecItem{Name: "json"}, nil
+		})
+		raw, err = cacheManager.cache.Get(ctx, cacheManager.buildKey("codec_json", "item"))
+		assert.NoError(t, err)
+		e, err = cacheManager.decodeValue(cacheManager.buildKey("codec_json", "item"), raw.([]byte))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"Name":"json","Count":0,"Attrs":null}`, string(e.payload))
+	})
+}
@@ -0,0 +1,143 @@
+package cacheable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionAlgo 缓存值的压缩算法，写入时记录在缓存值的头部，读取时不依赖当前的压缩配置
+type CompressionAlgo uint8
+
+const (
+	CompressionNone CompressionAlgo = iota
+	CompressionGzip
+	CompressionZstd
+)
+
+// compressionConfig 压缩配置，level的含义与具体算法一致，例如gzip.BestSpeed、gzip.BestCompression，
+// zstd的level与zstd命令行的级别一致，为0时使用默认级别
+type compressionConfig struct {
+	algo  CompressionAlgo
+	level int
+}
+
+// WithCompression 本次写入使用algo压缩，使用算法的默认级别，优先级高于SetNamespaceCompression，
+// 通过WithCompressionMinSize可以跳过较小的值
+func WithCompression(algo CompressionAlgo) Option {
+	return func(o *Options) {
+		level := 0
+		if algo == CompressionGzip {
+			level = gzip.DefaultCompression
+		}
+		o.compression = &compressionConfig{algo: algo, level: level}
+	}
+}
+
+// WithCompressionMinSize 序列化后小于n字节的值不压缩，较小的值压缩后通常不会变小，反而增加读写的开销
+func WithCompressionMinSize(n int) Option {
+	return func(o *Options) {
+		o.compressionMinSize = n
+	}
+}
+
+var (
+	// zstdEncoders level -> *zstd.Encoder，EncodeAll可以并发调用
+	zstdEncoders sync.Map
+	zstdDecoder  *zstd.Decoder
+	zstdInit     sync.Once
+)
+
+func zstdEncoder(level int) (*zstd.Encoder, error) {
+	if encoder, ok := zstdEncoders.Load(level); ok {
+		return encoder.(*zstd.Encoder), nil
+	}
+	speed := zstd.SpeedDefault
+	if level != 0 {
+		speed = zstd.EncoderLevelFromZstd(level)
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(speed))
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := zstdEncoders.LoadOrStore(level, encoder)
+	return actual.(*zstd.Encoder), nil
+}
+
+func sharedZstdDecoder() (*zstd.Decoder, error) {
+	var err error
+	zstdInit.Do(func() {
+		zstdDecoder, err = zstd.NewReader(nil)
+	})
+	if zstdDecoder == nil && err == nil {
+		err = errors.New("zstd decoder initialization failed")
+	}
+	return zstdDecoder, err
+}
+
+// SetNamespaceCompression 设置namespace的压缩算法和压缩级别，适合压缩收益差异大的namespace共用一个CacheManager，
+// 只影响之后的写入，已有缓存按写入时记录的算法读取
+func (i *CacheManager) SetNamespaceCompression(namespace string, algo CompressionAlgo, level int) {
+	i.namespaceCompression.Store(namespace, compressionConfig{algo: algo, level: level})
+}
+
+func (i *CacheManager) compressionFor(namespace string) compressionConfig {
+	if config, ok := i.namespaceCompression.Load(namespace); ok {
+		return config.(compressionConfig)
+	}
+	return compressionConfig{}
+}
+
+func compress(config compressionConfig, data []byte) ([]byte, error) {
+	switch config.algo {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, config.level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		encoder, err := zstdEncoder(config.level)
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(data, nil), nil
+	default:
+		return nil, errors.New("unsupported compression algorithm")
+	}
+}
+
+func decompress(algo CompressionAlgo, data []byte) ([]byte, error) {
+	switch algo {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case CompressionZstd:
+		decoder, err := sharedZstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(data, nil)
+	default:
+		return nil, errors.New("unsupported compression algorithm")
+	}
+}
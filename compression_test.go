@@ -0,0 +1,186 @@
+package cacheable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compressiblePayload 生成重复度较高的json数据
+func compressiblePayload() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for n := 0; n < 500; n++ {
+		if n > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `{"id":%d,"name":"user-%d","team":"platform","active":true}`, n, n)
+	}
+	buf.WriteString("]")
+	return buf.Bytes()
+}
+
+func TestNamespaceCompression(t *testing.T) {
+	ctx := context.Background()
+	payload := compressiblePayload()
+
+	t.Run("按namespace压缩", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		cacheManager.SetNamespaceCompression("compressed", CompressionGzip, gzip.BestCompression)
+
+		for _, ns := range []string{"compressed", "plain"} {
+			_, err, _ := cacheManager.Get(ctx, ns, "key", func() ([]byte, error) {
+				return payload, nil
+			})
+			assert.NoError(t, err)
+
+			value, err, cached := cacheManager.Get(ctx, ns, "key", func() ([]byte, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			assert.True(t, cached)
+			assert.Equal(t, payload, value)
+		}
+
+		compressed, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey("compressed", "key"))
+		plain, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey("plain", "key"))
+		assert.Less(t, len(compressed.([]byte)), len(payload)/4)
+		plainEntry, err := decodeEntry(plain.([]byte))
+		assert.NoError(t, err)
+		assert.Equal(t, CompressionNone, plainEntry.compression)
+		assert.Equal(t, payload, plainEntry.payload)
+	})
+
+	t.Run("读取不依赖当前的压缩配置", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		cacheManager.SetNamespaceCompression("switch", CompressionGzip, gzip.BestSpeed)
+		_, _, _ = cacheManager.Get(ctx, "switch", "key", func() ([]byte, error) {
+			return payload, nil
+		})
+
+		cacheManager.SetNamespaceCompression("switch", CompressionNone, 0)
+		value, err, cached := cacheManager.Get(ctx, "switch", "key", func() ([]byte, error) {
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, payload, value)
+	})
+}
+
+func BenchmarkCompression(b *testing.B) {
+	payload := compressiblePayload()
+	levels := []struct {
+		name   string
+		config compressionConfig
+	}{
+		{"none", compressionConfig{algo: CompressionNone}},
+		{"gzip-speed", compressionConfig{algo: CompressionGzip, level: gzip.BestSpeed}},
+		{"gzip-default", compressionConfig{algo: CompressionGzip, level: gzip.DefaultCompression}},
+		{"gzip-best", compressionConfig{algo: CompressionGzip, level: gzip.BestCompression}},
+	}
+	for _, level := range levels {
+		b.Run(level.name, func(b *testing.B) {
+			var compressed []byte
+			b.SetBytes(int64(len(payload)))
+			for n := 0; n < b.N; n++ {
+				compressed, _ = compress(level.config, payload)
+			}
+			b.ReportMetric(float64(len(compressed))/float64(len(payload)), "ratio")
+		})
+	}
+}
+
+func TestCompressionMetrics(t *testing.T) {
+	ctx := context.Background()
+	payload := compressiblePayload()
+	cacheManager := newTestGoCacheManager(t)
+	cacheManager.SetNamespaceCompression("compression_metrics", CompressionGzip, gzip.BestSpeed)
+
+	for _, ns := range []string{"compression_metrics", "compression_metrics_plain"} {
+		_, err, _ := cacheManager.Get(ctx, ns, "key", func() ([]byte, error) {
+			return payload, nil
+		})
+		assert.NoError(t, err)
+	}
+
+	ratio := histogramOf(t, CacheCompressionRatio, "compression_metrics")
+	assert.Equal(t, uint64(1), ratio.GetSampleCount())
+	assert.Less(t, ratio.GetSampleSum(), 0.25)
+	assert.Equal(t, uint64(1), histogramOf(t, CacheCompressionDuration, "compression_metrics").GetSampleCount())
+
+	// 未开启压缩的namespace不记录
+	assert.Equal(t, uint64(0), histogramOf(t, CacheCompressionRatio, "compression_metrics_plain").GetSampleCount())
+	assert.Equal(t, uint64(0), histogramOf(t, CacheCompressionDuration, "compression_metrics_plain").GetSampleCount())
+}
+
+func TestWithCompression(t *testing.T) {
+	ctx := context.Background()
+	payload := compressiblePayload()
+
+	for _, algo := range []CompressionAlgo{CompressionGzip, CompressionZstd} {
+		t.Run(fmt.Sprint("algo_", algo), func(t *testing.T) {
+			cacheManager := newTestGoCacheManager(t)
+			key := fmt.Sprint("with_compression_", algo)
+			for i := 0; i < 2; i++ {
+				value, err, cached := cacheManager.Get(ctx, namespace, key, func() ([]byte, error) {
+					return payload, nil
+				}, WithCompression(algo))
+				assert.NoError(t, err)
+				assert.Equal(t, i == 1, cached)
+				assert.Equal(t, payload, value)
+			}
+
+			stored, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey(namespace, key))
+			e, err := decodeEntry(stored.([]byte))
+			assert.NoError(t, err)
+			assert.Equal(t, algo, e.compression)
+			assert.Less(t, len(e.payload), len(payload)/4)
+
+			// 读取时不需要传入压缩配置
+			value, err, cached := cacheManager.Get(ctx, namespace, key, func() ([]byte, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			assert.True(t, cached)
+			assert.Equal(t, payload, value)
+		})
+	}
+
+	t.Run("优先级高于namespace配置", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		cacheManager.SetNamespaceCompression("compression_override", CompressionGzip, gzip.BestSpeed)
+		_, _, _ = cacheManager.Get(ctx, "compression_override", "key", func() ([]byte, error) {
+			return payload, nil
+		}, WithCompression(CompressionZstd))
+
+		stored, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey("compression_override", "key"))
+		e, err := decodeEntry(stored.([]byte))
+		assert.NoError(t, err)
+		assert.Equal(t, CompressionZstd, e.compression)
+	})
+
+	t.Run("小于阈值时不压缩", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		opts := []Option{WithCompression(CompressionZstd), WithCompressionMinSize(1024)}
+		for key, value := range map[string][]byte{"small": []byte("tiny"), "large": payload} {
+			_, _, _ = cacheManager.Get(ctx, namespace, "min_size_"+key, func() ([]byte, error) {
+				return value, nil
+			}, opts...)
+		}
+
+		small, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey(namespace, "min_size_small"))
+		e, err := decodeEntry(small.([]byte))
+		assert.NoError(t, err)
+		assert.Equal(t, CompressionNone, e.compression)
+
+		large, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey(namespace, "min_size_large"))
+		e, err = decodeEntry(large.([]byte))
+		assert.NoError(t, err)
+		assert.Equal(t, CompressionZstd, e.compression)
+	})
+}
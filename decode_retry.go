@@ -0,0 +1,63 @@
+package cacheable
+
+import (
+	"context"
+	"time"
+)
+
+// decodeRetryBackoff 解码失败后等待并发写入完成的时间
+const decodeRetryBackoff = 5 * time.Millisecond
+
+// WithDecodeRetry 读取到无法解码的缓存时，等待一小段时间后重新读取一次，仍然失败时调用fn重新计算并覆盖缓存，
+// 用于部分store并发写入时可能读到写了一半的数据的情况。重试结果记录在cache_decode_retry_total中，
+// 重试频繁出现说明store本身可能有问题
+func WithDecodeRetry() ManagerOption {
+	return func(m *CacheManager) {
+		m.decodeRetry = true
+	}
+}
+
+// retryDecode 重新读取key并解码，check用于进一步校验解码后的数据（例如泛型Get的反序列化），为nil时不校验。
+// 重新读取仍然失败时按缓存不存在处理，key为store中的key
+func (i *CacheManager) retryDecode(ctx context.Context, namespace string, key string, fn func() ([]byte, error), check func(payload []byte) error, opts ...Option) ([]byte, error, Meta) {
+	timer := time.NewTimer(decodeRetryBackoff)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return nil, ctx.Err(), Meta{}
+	case <-timer.C:
+	}
+
+	if payload, meta, ok := i.reread(ctx, key, check); ok {
+		i.metrics.decodeRetry(namespace, "reread")
+		return payload, nil, meta
+	}
+	i.metrics.decodeRetry(namespace, "recompute")
+	return i.loadShared(ctx, namespace, key, fn, opts...)
+}
+
+// reread 重新读取key，读取或解码失败时返回false
+func (i *CacheManager) reread(ctx context.Context, key string, check func(payload []byte) error) ([]byte, Meta, bool) {
+	var meta Meta
+	data, err := i.cache.Get(ctx, key)
+	if err != nil {
+		return nil, meta, false
+	}
+	stored, err := toBytes(data)
+	if err != nil {
+		return nil, meta, false
+	}
+	e, err := i.decodeValue(key, stored)
+	if err != nil {
+		return nil, meta, false
+	}
+	if e.tombstone || (check != nil && check(e.payload) != nil) {
+		return nil, meta, false
+	}
+
+	meta.Cached = true
+	if e.createdAt != 0 {
+		meta.Age = i.now().Sub(time.Unix(0, e.createdAt))
+	}
+	return e.payload, meta, true
+}
@@ -0,0 +1,96 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// tornReadStore 第一次读取时返回写了一半的数据，模拟并发写入时的非原子读取
+type tornReadStore struct {
+	store.StoreInterface
+	reads int
+}
+
+func (s *tornReadStore) Get(ctx context.Context, key any) (any, error) {
+	s.reads++
+	data, err := s.StoreInterface.Get(ctx, key)
+	if err != nil || s.reads > 1 {
+		return data, err
+	}
+	stored := data.([]byte)
+	return stored[:len(stored)-2], nil
+}
+
+func TestDecodeRetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("重新读取成功", func(t *testing.T) {
+		ns := "decode_retry_reread"
+		cacheManager := newTestGoCacheManager(t, WithDecodeRetry())
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (map[string]string, error) {
+			return map[string]string{"name": "Alice"}, nil
+		})
+
+		torn := &tornReadStore{StoreInterface: cacheManager.cache}
+		cacheManager.cache = torn
+		value, err, cached := Get(ctx, cacheManager, ns, "key", func() (map[string]string, error) {
+			return map[string]string{"name": "Bob"}, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "Alice", value["name"])
+		assert.Equal(t, 2, torn.reads)
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheDecodeRetryTotal.WithLabelValues(ns, "reread")))
+	})
+
+	t.Run("重新读取失败时重新计算", func(t *testing.T) {
+		ns := "decode_retry_recompute"
+		cacheManager := newTestGoCacheManager(t, WithDecodeRetry())
+		_ = cacheManager.cache.Set(ctx, cacheManager.buildKey(ns, "key"), []byte(`{"name":`))
+
+		value, err, cached := Get(ctx, cacheManager, ns, "key", func() (map[string]string, error) {
+			return map[string]string{"name": "Bob"}, nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "Bob", value["name"])
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheDecodeRetryTotal.WithLabelValues(ns, "recompute")))
+
+		// 重新计算的结果覆盖了损坏的缓存
+		value, err, cached = Get(ctx, cacheManager, ns, "key", func() (map[string]string, error) {
+			return map[string]string{"name": "Carol"}, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "Bob", value["name"])
+	})
+
+	t.Run("损坏的缓存头部同样重试", func(t *testing.T) {
+		ns := "decode_retry_entry"
+		cacheManager := newTestGoCacheManager(t, WithDecodeRetry())
+		_ = cacheManager.cache.Set(ctx, cacheManager.buildKey(ns, "key"), append(entryMagic, 0xFF))
+
+		value, err, cached := cacheManager.Get(ctx, ns, "key", func() ([]byte, error) {
+			return []byte("value"), nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("默认不重试", func(t *testing.T) {
+		ns := "decode_retry_disabled"
+		cacheManager := newTestGoCacheManager(t)
+		_ = cacheManager.cache.Set(ctx, cacheManager.buildKey(ns, "key"), []byte(`{"name":`))
+
+		_, err, _ := Get(ctx, cacheManager, ns, "key", func() (map[string]string, error) {
+			return map[string]string{"name": "Bob"}, nil
+		})
+		var decodeErr *DecodeError
+		assert.ErrorAs(t, err, &decodeErr)
+	})
+}
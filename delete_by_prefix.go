@@ -0,0 +1,17 @@
+package cacheable
+
+import "context"
+
+// DeleteByPrefix 删除namespace下以keyPrefix开头的全部缓存，例如keyPrefix为"user:42:"时删除user:42:profile、user:42:settings等，
+// 不需要预先设置tag。需要store实现KeyScanner，否则返回ErrNotSupported，限制同ClearNamespace。
+// 开启WithAutoHashKeyAbove后被替换为哈希的key无法按前缀匹配，不会被删除
+func (i *CacheManager) DeleteByPrefix(ctx context.Context, namespace string, keyPrefix string) error {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	return i.deleteByScan(ctx, namespace, i.joinKey(i.tenantNamespace(ctx, namespace), keyPrefix))
+}
+
+func DeleteByPrefix(ctx context.Context, cacheManager *CacheManager, namespace string, keyPrefix string) error {
+	return cacheManager.DeleteByPrefix(ctx, namespace, keyPrefix)
+}
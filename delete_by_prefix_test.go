@@ -0,0 +1,68 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteByPrefix(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"redis":    NewCacheManager(redisStore),
+		"go-cache": newTestGoCacheManager(t),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			ns := "prefix_ns"
+			for _, key := range []string{"user:42:profile", "user:42:settings", "user:420:profile", "user:43:profile"} {
+				assert.NoError(t, Set(ctx, cacheManager, ns, key, "value"))
+			}
+			assert.NoError(t, Set(ctx, cacheManager, "prefix_ns_other", "user:42:profile", "value"))
+
+			assert.NoError(t, DeleteByPrefix(ctx, cacheManager, ns, "user:42:"))
+
+			for key, want := range map[string]bool{
+				"user:42:profile":  false,
+				"user:42:settings": false,
+				"user:420:profile": true,
+				"user:43:profile":  true,
+			} {
+				exists, err := Exists(ctx, cacheManager, ns, key)
+				assert.NoError(t, err)
+				assert.Equal(t, want, exists, key)
+			}
+			//其他namespace不受影响
+			exists, err := Exists(ctx, cacheManager, "prefix_ns_other", "user:42:profile")
+			assert.NoError(t, err)
+			assert.True(t, exists)
+		})
+	}
+
+	t.Run("前缀中的通配符按字面匹配", func(t *testing.T) {
+		cacheManager := NewCacheManager(redisStore)
+		assert.NoError(t, Set(ctx, cacheManager, "prefix_glob", "a*:1", "value"))
+		assert.NoError(t, Set(ctx, cacheManager, "prefix_glob", "ab:1", "value"))
+
+		assert.NoError(t, DeleteByPrefix(ctx, cacheManager, "prefix_glob", "a*"))
+
+		exists, err := Exists(ctx, cacheManager, "prefix_glob", "a*:1")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = Exists(ctx, cacheManager, "prefix_glob", "ab:1")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("store不支持", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore())
+		assert.ErrorIs(t, DeleteByPrefix(ctx, cacheManager, "prefix_ns", "user:"), ErrNotSupported)
+	})
+
+	t.Run("namespace不能为空", func(t *testing.T) {
+		assert.ErrorIs(t, DeleteByPrefix(ctx, newTestGoCacheManager(t), "", "user:"), ErrEmptyNamespace)
+	})
+}
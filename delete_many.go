@@ -0,0 +1,30 @@
+package cacheable
+
+import "context"
+
+// DeleteMany 删除namespace下的多个key，store实现KeysDeleter时（例如redis的pipeline）合并为一次请求，否则逐个删除。
+// 部分key删除失败时不影响其他key，返回合并后的错误
+func (i *CacheManager) DeleteMany(ctx context.Context, namespace string, keys []string) (err error) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	ctx, span := i.startSpan(ctx, "cache.delete_many", namespace)
+	defer func() { endSpan(span, err) }()
+
+	storeKeys := make([]string, 0, len(keys))
+	for _, key := range uniqueKeys(keys) {
+		storeKeys = append(storeKeys, i.tenantKey(ctx, namespace, key))
+	}
+	if err := i.deleteKeys(ctx, storeKeys); err != nil {
+		i.metrics.error(namespace, opDelete, errKindStore)
+		return storeError(opDelete, err)
+	}
+	return nil
+}
+
+func DeleteMany(ctx context.Context, cacheManager *CacheManager, namespace string, keys []string) error {
+	return cacheManager.DeleteMany(ctx, namespace, keys)
+}
@@ -0,0 +1,98 @@
+package cacheable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var errDeleteFailed = errors.New("delete failed")
+
+// failingDeleteStore 删除包含bad的key时失败
+type failingDeleteStore struct {
+	store.StoreInterface
+}
+
+func (s *failingDeleteStore) Delete(ctx context.Context, key any) error {
+	if strings.Contains(key.(string), "bad") {
+		return errDeleteFailed
+	}
+	return s.StoreInterface.Delete(ctx, key)
+}
+
+func TestDeleteMany(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"redis":    NewCacheManager(redisStore),
+		"go-cache": newTestGoCacheManager(t),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			ns := "delete_many"
+			for _, key := range []string{"a", "b", "c"} {
+				assert.NoError(t, Set(ctx, cacheManager, ns, key, "value"))
+			}
+
+			assert.NoError(t, DeleteMany(ctx, cacheManager, ns, []string{"a", "b", "b", "missing"}))
+
+			for key, want := range map[string]bool{"a": false, "b": false, "c": true} {
+				exists, err := Exists(ctx, cacheManager, ns, key)
+				assert.NoError(t, err)
+				assert.Equal(t, want, exists, key)
+			}
+		})
+	}
+
+	t.Run("部分失败时返回合并后的错误", func(t *testing.T) {
+		ns := "delete_many_partial"
+		cacheManager := NewCacheManager(&failingDeleteStore{StoreInterface: newGoCacheStore()})
+		for _, key := range []string{"ok1", "bad1", "ok2", "bad2"} {
+			assert.NoError(t, Set(ctx, cacheManager, ns, key, "value"))
+		}
+
+		err := DeleteMany(ctx, cacheManager, ns, []string{"ok1", "bad1", "ok2", "bad2"})
+		assert.ErrorIs(t, err, errDeleteFailed)
+		assert.ErrorContains(t, err, keyHash(cacheManager.buildKey(ns, "bad1")))
+		assert.ErrorContains(t, err, keyHash(cacheManager.buildKey(ns, "bad2")))
+		assert.NotContains(t, err.Error(), "bad1")
+		var deleteErr *KeyDeleteError
+		assert.ErrorAs(t, err, &deleteErr)
+
+		for key, want := range map[string]bool{"ok1": false, "ok2": false, "bad1": true, "bad2": true} {
+			exists, err := Exists(ctx, cacheManager, ns, key)
+			assert.NoError(t, err)
+			assert.Equal(t, want, exists, key)
+		}
+	})
+
+	t.Run("部分失败时广播删除成功的key", func(t *testing.T) {
+		ns := "delete_many_partial_publish"
+		pubSub := newMemoryPubSub()
+		cacheManager := NewCacheManager(&failingDeleteStore{StoreInterface: newGoCacheStore()}, WithInvalidationChannel(pubSub))
+		t.Cleanup(func() { _ = cacheManager.Close() })
+		var published []invalidationMessage
+		unsubscribe := pubSub.Subscribe(func(message []byte) {
+			var msg invalidationMessage
+			assert.NoError(t, json.Unmarshal(message, &msg))
+			published = append(published, msg)
+		})
+		t.Cleanup(unsubscribe)
+
+		err := DeleteMany(ctx, cacheManager, ns, []string{"ok1", "bad1", "ok2"})
+		assert.ErrorIs(t, err, errDeleteFailed)
+		if assert.Len(t, published, 1) {
+			assert.Equal(t, []string{cacheManager.buildKey(ns, "ok1"), cacheManager.buildKey(ns, "ok2")}, published[0].Keys)
+		}
+	})
+
+	t.Run("namespace不能为空", func(t *testing.T) {
+		assert.ErrorIs(t, DeleteMany(ctx, newTestGoCacheManager(t), "", []string{"a"}), ErrEmptyNamespace)
+	})
+}
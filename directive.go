@@ -0,0 +1,50 @@
+package cacheable
+
+import (
+	"context"
+	"time"
+)
+
+// CacheDirective 由fn根据实际获取到的数据决定缓存策略，例如数据来自降级的数据源时只缓存很短的时间。
+// 与调用时传入的opts的优先级：
+//   - NoCache为true时不写入缓存，忽略其他设置
+//   - TTL大于0时覆盖WithExpiration
+//   - Tags追加到WithTags和WithDynamicTags之后，不会覆盖调用方用于失效的tag
+type CacheDirective struct {
+	NoCache bool
+	TTL     time.Duration
+	Tags    []string
+}
+
+// withDirective 在fn执行后将directive合并到写入配置中
+func withDirective(directive *CacheDirective) Option {
+	return func(o *Options) {
+		o.directive = directive
+	}
+}
+
+// applyDirective 合并fn返回的CacheDirective，需要在fn执行之后调用
+func (o *Options) applyDirective() {
+	if o.directive == nil {
+		return
+	}
+	if o.directive.NoCache {
+		o.NoCache = true
+	}
+	if o.directive.TTL > 0 {
+		o.Expiration = o.directive.TTL
+	}
+	o.Tags = append(o.Tags[:len(o.Tags):len(o.Tags)], o.directive.Tags...)
+}
+
+// GetWithDirective 和Get相同，fn可以额外返回CacheDirective控制本次结果的缓存方式，优先级见CacheDirective。
+// 命中缓存或合并了其他请求的计算时不会调用fn，也不会使用fn返回的CacheDirective
+func GetWithDirective[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func() (T, CacheDirective, error), opts ...Option) (value T, err error, cached bool) {
+	directive := &CacheDirective{}
+	opts = append(opts[:len(opts):len(opts)], withDirective(directive))
+	return Get(ctx, cacheManager, namespace, key, func() (T, error) {
+		v, d, e := fn()
+		*directive = d
+		return v, e
+	}, opts...)
+}
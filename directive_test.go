@@ -0,0 +1,54 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWithDirective(t *testing.T) {
+	ctx := context.Background()
+	var written Options
+	writes := 0
+	cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			writes++
+			written = options
+		},
+	}))
+
+	t.Run("覆盖有效期并追加tag", func(t *testing.T) {
+		value, err, _ := GetWithDirective(ctx, cacheManager, namespace, "directive_degraded", func() (string, CacheDirective, error) {
+			return "degraded", CacheDirective{TTL: 5 * time.Second, Tags: []string{"degraded"}}, nil
+		}, WithExpiration(time.Hour), WithTags("user"))
+		assert.NoError(t, err)
+		assert.Equal(t, "degraded", value)
+		assert.Equal(t, 5*time.Second, written.Expiration)
+		assert.Equal(t, []string{"degraded", "user"}, written.Tags)
+	})
+
+	t.Run("没有设置时使用opts", func(t *testing.T) {
+		_, err, _ := GetWithDirective(ctx, cacheManager, namespace, "directive_empty", func() (string, CacheDirective, error) {
+			return "value", CacheDirective{}, nil
+		}, WithExpiration(time.Hour), WithTags("user"))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, written.Expiration)
+		assert.Equal(t, []string{"user"}, written.Tags)
+	})
+
+	t.Run("不写入缓存", func(t *testing.T) {
+		before := writes
+		_, err, _ := GetWithDirective(ctx, cacheManager, namespace, "directive_no_cache", func() (string, CacheDirective, error) {
+			return "value", CacheDirective{NoCache: true, TTL: time.Minute}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, before, writes)
+
+		_, _, cached := Get(ctx, cacheManager, namespace, "directive_no_cache", func() (string, error) {
+			return "value", nil
+		})
+		assert.False(t, cached)
+	})
+}
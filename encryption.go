@@ -0,0 +1,100 @@
+package cacheable
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// encryptionKeyIDSize 写入缓存头部的key编号长度，取key的sha256前4字节
+const encryptionKeyIDSize = 4
+
+// encryptionKey 一个AES-GCM密钥
+type encryptionKey struct {
+	id   []byte
+	aead cipher.AEAD
+}
+
+// WithEncryption 使用AES-GCM加密写入store的值，key的长度为16、24或32字节，分别对应AES-128、AES-192、AES-256。
+// 加密在压缩之后进行，缓存头部记录key的编号和nonce，更换key时将旧key放在oldKeys中，旧缓存仍然可以读取，
+// 新写入的缓存使用key加密。store中的key和头部字段作为附加数据参与认证，缓存被复制到其他key或头部被修改时无法解密。开启后读取到未加密的缓存返回ErrNotEncrypted，key的长度不正确时panic
+func WithEncryption(key []byte, oldKeys ...[]byte) ManagerOption {
+	keys := make([]*encryptionKey, 0, 1+len(oldKeys))
+	for _, k := range append([][]byte{key}, oldKeys...) {
+		keys = append(keys, mustEncryptionKey(k))
+	}
+	return func(m *CacheManager) {
+		m.encryptionKeys = keys
+	}
+}
+
+func mustEncryptionKey(key []byte) *encryptionKey {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(fmt.Sprintf("cacheable: invalid encryption key: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("cacheable: invalid encryption key: %v", err))
+	}
+	sum := sha256.Sum256(key)
+	return &encryptionKey{id: sum[:encryptionKeyIDSize], aead: aead}
+}
+
+// additionalData 加密的附加数据，包含store中的key和checksum以外的头部字段
+func additionalData(storeKey string, e *entry) []byte {
+	header := e.encodeHeader()
+	ad := make([]byte, 0, binary.MaxVarintLen64+len(storeKey)+len(header))
+	ad = binary.AppendUvarint(ad, uint64(len(storeKey)))
+	ad = append(ad, storeKey...)
+	return append(ad, header...)
+}
+
+// seal 使用当前的key加密写入storeKey的entry的payload，没有开启WithEncryption时不做处理
+func (i *CacheManager) seal(storeKey string, e *entry) error {
+	if len(i.encryptionKeys) == 0 {
+		return nil
+	}
+	key := i.encryptionKeys[0]
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	e.encryption = append(append(make([]byte, 0, len(key.id)+len(nonce)), key.id...), nonce...)
+	e.payload = key.aead.Seal(nil, nonce, e.payload, additionalData(storeKey, e))
+	return nil
+}
+
+// open 解密从storeKey读取的entry的payload，没有开启WithEncryption时读取到加密的缓存同样返回错误
+func (i *CacheManager) open(storeKey string, e *entry) error {
+	if len(e.encryption) == 0 {
+		if len(i.encryptionKeys) > 0 {
+			return ErrNotEncrypted
+		}
+		return nil
+	}
+	if len(e.encryption) < encryptionKeyIDSize {
+		return ErrCorruptedEntry
+	}
+	id, nonce := e.encryption[:encryptionKeyIDSize], e.encryption[encryptionKeyIDSize:]
+	for _, key := range i.encryptionKeys {
+		if !bytes.Equal(key.id, id) {
+			continue
+		}
+		if len(nonce) != key.aead.NonceSize() {
+			return ErrCorruptedEntry
+		}
+		payload, err := key.aead.Open(nil, nonce, e.payload, additionalData(storeKey, e))
+		if err != nil {
+			return fmt.Errorf("cacheable: decrypt cached value: %w", err)
+		}
+		e.payload = payload
+		e.encryption = nil
+		return nil
+	}
+	return ErrUnknownEncryptionKey
+}
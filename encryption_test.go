@@ -0,0 +1,129 @@
+package cacheable
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEncryption(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{1}, 32)
+	sharedStore := newGoCacheStore()
+
+	t.Run("加密后读取", func(t *testing.T) {
+		cacheManager := NewCacheManager(sharedStore, WithEncryption(key))
+		payload := compressiblePayload()
+		for i := 0; i < 2; i++ {
+			value, err, cached := cacheManager.Get(ctx, namespace, "encrypted", func() ([]byte, error) {
+				return payload, nil
+			}, WithCompression(CompressionZstd))
+			assert.NoError(t, err)
+			assert.Equal(t, i == 1, cached)
+			assert.Equal(t, payload, value)
+		}
+
+		stored, _ := sharedStore.Get(ctx, cacheManager.buildKey(namespace, "encrypted"))
+		assert.False(t, bytes.Contains(stored.([]byte), []byte("platform")))
+		e, err := decodeEntry(stored.([]byte))
+		assert.NoError(t, err)
+		assert.Equal(t, CompressionZstd, e.compression)
+		assert.NotEmpty(t, e.encryption)
+	})
+
+	t.Run("未加密的旧缓存", func(t *testing.T) {
+		plain := NewCacheManager(sharedStore)
+		_, _, _ = Get(ctx, plain, namespace, "legacy", func() (string, error) {
+			return "value", nil
+		})
+
+		cacheManager := NewCacheManager(sharedStore, WithEncryption(key))
+		_, err, _ := Get(ctx, cacheManager, namespace, "legacy", func() (string, error) {
+			return "value", nil
+		})
+		assert.ErrorIs(t, err, ErrNotEncrypted)
+
+		// 没有开启加密时无法读取加密的缓存
+		_, err, _ = Get(ctx, plain, namespace, "encrypted", func() (string, error) {
+			return "", nil
+		})
+		assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+	})
+
+	t.Run("更换key", func(t *testing.T) {
+		newKey := bytes.Repeat([]byte{2}, 32)
+		rotated := NewCacheManager(sharedStore, WithEncryption(newKey, key))
+		value, err, cached := rotated.Get(ctx, namespace, "encrypted", func() ([]byte, error) {
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, compressiblePayload(), value)
+
+		_, _, _ = Get(ctx, rotated, namespace, "rotated", func() (string, error) {
+			return "value", nil
+		})
+		_, err, _ = Get(ctx, NewCacheManager(sharedStore, WithEncryption(key)), namespace, "rotated", func() (string, error) {
+			return "", nil
+		})
+		assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+	})
+
+	t.Run("篡改后无法解密", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore(), WithEncryption(key))
+		_, _, _ = Get(ctx, cacheManager, namespace, "tampered", func() (string, error) {
+			return "value", nil
+		})
+		storeKey := cacheManager.buildKey(namespace, "tampered")
+		stored, _ := cacheManager.cache.Get(ctx, storeKey)
+		data := bytes.Clone(stored.([]byte))
+		data[len(data)-1] ^= 0xff
+		assert.NoError(t, cacheManager.cache.Set(ctx, storeKey, data))
+
+		_, err, _ := Get(ctx, cacheManager, namespace, "tampered", func() (string, error) {
+			return "", nil
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("复制到其他key后无法解密", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore(), WithEncryption(key))
+		assert.NoError(t, SetRaw(ctx, cacheManager, namespace, "alice", []byte("alice-ssn")))
+		assert.NoError(t, SetRaw(ctx, cacheManager, namespace, "bob", []byte("bob-ssn")))
+
+		stored, err := cacheManager.cache.Get(ctx, cacheManager.buildKey(namespace, "alice"))
+		assert.NoError(t, err)
+		assert.NoError(t, cacheManager.cache.Set(ctx, cacheManager.buildKey(namespace, "bob"), stored))
+
+		value, err, _ := GetRaw(ctx, cacheManager, namespace, "bob", func() ([]byte, error) {
+			return []byte("reloaded"), nil
+		})
+		assert.Error(t, err)
+		assert.NotEqual(t, []byte("alice-ssn"), value)
+	})
+
+	t.Run("修改头部后无法解密", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore(), WithEncryption(key))
+		assert.NoError(t, SetRaw(ctx, cacheManager, namespace, "header", []byte(`"value"`)))
+		storeKey := cacheManager.buildKey(namespace, "header")
+		stored, _ := cacheManager.cache.Get(ctx, storeKey)
+		e, err := decodeEntry(stored.([]byte))
+		assert.NoError(t, err)
+		e.tombstone = true
+		assert.NoError(t, cacheManager.cache.Set(ctx, storeKey, encodeEntry(e)))
+
+		_, err, _ = GetRaw(ctx, cacheManager, namespace, "header", func() ([]byte, error) {
+			return nil, nil
+		})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, errTombstone)
+	})
+
+	t.Run("key长度不正确", func(t *testing.T) {
+		assert.Panics(t, func() {
+			WithEncryption([]byte("short"))
+		})
+	})
+}
@@ -0,0 +1,253 @@
+package cacheable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+// 缓存值在store中的存储格式：
+//
+//	magic(2字节) | 格式版本(1字节) | 头部长度(uvarint) | 头部字段... | payload
+//
+// 每个头部字段为 字段编号(1字节) | 长度(uvarint) | 内容，读取时跳过不认识的字段，新增字段不影响旧版本读取。
+// 没有需要记录的头部字段时直接写入payload，读取时没有magic的数据按原始payload处理，兼容旧版本写入的缓存。
+// payload本身以magic开头时写入空的头部，避免读取时被误认为头部
+var entryMagic = []byte{0xC5, 0xCA}
+
+const entryVersion = 1
+
+// 头部字段编号，只能新增不能修改
+const (
+	fieldCompression byte = 1
+	fieldCreatedAt   byte = 2
+	fieldTypeName    byte = 3
+	fieldTombstone   byte = 4
+	fieldFreshUntil  byte = 5
+	fieldEncryption  byte = 6
+	fieldExpiresAt   byte = 7
+	fieldVersion     byte = 8
+	fieldChecksum    byte = 9
+)
+
+// entry 是缓存值解析后的结构
+type entry struct {
+	compression CompressionAlgo
+	// createdAt 写入时间，unix纳秒，0表示未记录（旧版本写入的缓存）
+	createdAt int64
+	// typeName 泛型Get写入时T的类型名称，只在开启WithTypeCheck时记录
+	typeName string
+	// tombstone fn返回的错误被WithNegativeCache缓存，payload为错误信息，notFound表示该错误包装了ErrNotFound
+	tombstone bool
+	notFound  bool
+	// freshUntil 超过该时间（unix纳秒）后需要刷新，0表示未开启WithStaleWhileRevalidate和WithServeStaleOnError
+	freshUntil int64
+	// encryption 加密使用的key编号和nonce，为空表示未加密，见WithEncryption
+	encryption []byte
+	// expiresAt 写入时的过期时间（unix纳秒），不包含staleFor，0表示未开启WithRefreshAhead
+	expiresAt int64
+	// version 写入时WithVersion设置的版本
+	version string
+	// checksum payload的CRC-32C，为空表示未记录，见WithChecksum
+	checksum []byte
+	payload  []byte
+}
+
+func (e *entry) hasHeader() bool {
+	return e.compression != CompressionNone || e.createdAt != 0 || e.typeName != "" || e.tombstone || e.freshUntil != 0 || len(e.encryption) > 0 || e.expiresAt != 0 || e.version != "" || len(e.checksum) > 0
+}
+
+func encodeEntry(e *entry) []byte {
+	if !e.hasHeader() && !bytes.HasPrefix(e.payload, entryMagic) {
+		return e.payload
+	}
+
+	header := e.encodeHeader()
+	if len(e.checksum) > 0 {
+		header = appendField(header, fieldChecksum, e.checksum)
+	}
+
+	buf := make([]byte, 0, len(entryMagic)+1+binary.MaxVarintLen64+len(header)+len(e.payload))
+	buf = append(buf, entryMagic...)
+	buf = append(buf, entryVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(header)))
+	buf = append(buf, header...)
+	return append(buf, e.payload...)
+}
+
+// encodeHeader 编码checksum以外的头部字段，checksum在加密之后计算，不作为加密的附加数据
+func (e *entry) encodeHeader() []byte {
+	var header []byte
+	if e.compression != CompressionNone {
+		header = appendField(header, fieldCompression, []byte{byte(e.compression)})
+	}
+	if e.createdAt != 0 {
+		header = appendField(header, fieldCreatedAt, binary.BigEndian.AppendUint64(nil, uint64(e.createdAt)))
+	}
+	if e.typeName != "" {
+		header = appendField(header, fieldTypeName, []byte(e.typeName))
+	}
+	if e.tombstone {
+		var value []byte
+		if e.notFound {
+			value = []byte{1}
+		}
+		header = appendField(header, fieldTombstone, value)
+	}
+	if e.freshUntil != 0 {
+		header = appendField(header, fieldFreshUntil, binary.BigEndian.AppendUint64(nil, uint64(e.freshUntil)))
+	}
+	if len(e.encryption) > 0 {
+		header = appendField(header, fieldEncryption, e.encryption)
+	}
+	if e.expiresAt != 0 {
+		header = appendField(header, fieldExpiresAt, binary.BigEndian.AppendUint64(nil, uint64(e.expiresAt)))
+	}
+	if e.version != "" {
+		header = appendField(header, fieldVersion, []byte(e.version))
+	}
+	return header
+}
+
+func appendField(buf []byte, field byte, value []byte) []byte {
+	buf = append(buf, field)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+func decodeEntry(data []byte) (*entry, error) {
+	if !bytes.HasPrefix(data, entryMagic) {
+		return &entry{payload: data}, nil
+	}
+
+	data = data[len(entryMagic):]
+	if len(data) == 0 || data[0] != entryVersion {
+		return nil, ErrCorruptedEntry
+	}
+	data = data[1:]
+
+	headerLen, n := binary.Uvarint(data)
+	if n <= 0 || headerLen > uint64(len(data)-n) {
+		return nil, ErrCorruptedEntry
+	}
+	header := data[n : n+int(headerLen)]
+	e := &entry{payload: data[n+int(headerLen):]}
+
+	for len(header) > 0 {
+		field := header[0]
+		valueLen, n := binary.Uvarint(header[1:])
+		if n <= 0 || valueLen > uint64(len(header)-1-n) {
+			return nil, ErrCorruptedEntry
+		}
+		value := header[1+n : 1+n+int(valueLen)]
+		header = header[1+n+int(valueLen):]
+
+		switch field {
+		case fieldCompression:
+			if len(value) != 1 {
+				return nil, ErrCorruptedEntry
+			}
+			e.compression = CompressionAlgo(value[0])
+		case fieldCreatedAt:
+			if len(value) != 8 {
+				return nil, ErrCorruptedEntry
+			}
+			e.createdAt = int64(binary.BigEndian.Uint64(value))
+		case fieldTypeName:
+			e.typeName = string(value)
+		case fieldTombstone:
+			e.tombstone = true
+			e.notFound = len(value) == 1 && value[0] == 1
+		case fieldFreshUntil:
+			if len(value) != 8 {
+				return nil, ErrCorruptedEntry
+			}
+			e.freshUntil = int64(binary.BigEndian.Uint64(value))
+		case fieldEncryption:
+			e.encryption = value
+		case fieldExpiresAt:
+			if len(value) != 8 {
+				return nil, ErrCorruptedEntry
+			}
+			e.expiresAt = int64(binary.BigEndian.Uint64(value))
+		case fieldVersion:
+			e.version = string(value)
+		case fieldChecksum:
+			if len(value) != 4 {
+				return nil, ErrCorruptedEntry
+			}
+			e.checksum = value
+		}
+	}
+	return e, nil
+}
+
+// encodeValue 将fn返回的数据转换为写入store中key的格式，effective为最终生效的配置，
+// 决定是否记录类型、刷新时间以及使用的压缩算法
+func (i *CacheManager) encodeValue(namespace string, key string, value []byte, effective Options) ([]byte, error) {
+	e := &entry{payload: value, typeName: effective.typeName, freshUntil: i.freshUntil(effective), expiresAt: i.expiresAt(effective), version: effective.version}
+	config := i.compressionFor(namespace)
+	if effective.compression != nil {
+		config = *effective.compression
+	}
+	if len(value) < effective.compressionMinSize {
+		config = compressionConfig{}
+	}
+	if config.algo != CompressionNone {
+		start := time.Now()
+		compressed, err := compress(config, value)
+		if err != nil {
+			return nil, err
+		}
+		i.metrics.compressionDuration(namespace, time.Since(start))
+		if len(value) > 0 {
+			i.metrics.compressionRatio(namespace, float64(len(compressed))/float64(len(value)))
+		}
+		e.compression = config.algo
+		e.payload = compressed
+	}
+	i.stampCreatedAt(e)
+	if err := i.seal(key, e); err != nil {
+		return nil, err
+	}
+	i.sum(e)
+	return encodeEntry(e), nil
+}
+
+// encodeTombstone 将fn返回的错误转换为写入store的格式，见WithNegativeCache
+func (i *CacheManager) encodeTombstone(key string, message []byte, typeName string, version string, notFound bool) ([]byte, error) {
+	e := &entry{payload: message, typeName: typeName, version: version, tombstone: true, notFound: notFound}
+	i.stampCreatedAt(e)
+	if err := i.seal(key, e); err != nil {
+		return nil, err
+	}
+	i.sum(e)
+	return encodeEntry(e), nil
+}
+
+// stampCreatedAt 记录写入时间，只在开启WithEntryAge或者已经需要写入头部时记录，
+// 没有头部的值与旧版本的格式相同，滚动升级期间旧版本的实例和直接读取store的外部程序仍然可以读取
+func (i *CacheManager) stampCreatedAt(e *entry) {
+	if i.entryAge || e.hasHeader() || len(i.encryptionKeys) > 0 || i.checksum {
+		e.createdAt = i.now().UnixNano()
+	}
+}
+
+// decodeValue 解析从store中key读取的数据，返回的entry中payload已还原为fn返回的数据
+func (i *CacheManager) decodeValue(key string, data []byte) (*entry, error) {
+	e, err := decodeEntry(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksum(e); err != nil {
+		return nil, err
+	}
+	if err := i.open(key, e); err != nil {
+		return nil, err
+	}
+	e.payload, err = decompress(e.compression, e.payload)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,64 @@
+package cacheable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntry(t *testing.T) {
+	t.Run("没有头部字段时直接写入payload", func(t *testing.T) {
+		assert.Equal(t, []byte(`"value"`), encodeEntry(&entry{payload: []byte(`"value"`)}))
+	})
+
+	t.Run("payload以magic开头时写入空的头部", func(t *testing.T) {
+		payload := append(append([]byte{}, entryMagic...), "raw"...)
+		data := encodeEntry(&entry{payload: payload})
+		assert.NotEqual(t, payload, data)
+		decoded, err := decodeEntry(data)
+		assert.NoError(t, err)
+		assert.Equal(t, &entry{payload: payload}, decoded)
+	})
+
+	t.Run("编码和解码", func(t *testing.T) {
+		e := &entry{compression: CompressionGzip, createdAt: 1700000000000000000, payload: []byte("payload")}
+		decoded, err := decodeEntry(encodeEntry(e))
+		assert.NoError(t, err)
+		assert.Equal(t, e, decoded)
+	})
+
+	t.Run("兼容没有头部的旧数据", func(t *testing.T) {
+		decoded, err := decodeEntry([]byte(`{"name":"alice"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, &entry{payload: []byte(`{"name":"alice"}`)}, decoded)
+	})
+
+	t.Run("跳过不认识的头部字段", func(t *testing.T) {
+		header := appendField(nil, 200, []byte("future"))
+		header = appendField(header, fieldCompression, []byte{byte(CompressionGzip)})
+		data := append(append([]byte{}, entryMagic...), entryVersion, byte(len(header)))
+		data = append(append(data, header...), "payload"...)
+
+		decoded, err := decodeEntry(data)
+		assert.NoError(t, err)
+		assert.Equal(t, &entry{compression: CompressionGzip, payload: []byte("payload")}, decoded)
+	})
+
+	t.Run("格式错误", func(t *testing.T) {
+		for _, data := range [][]byte{
+			entryMagic,
+			append(append([]byte{}, entryMagic...), 99),
+			append(append([]byte{}, entryMagic...), entryVersion, 10, fieldCompression),
+		} {
+			_, err := decodeEntry(data)
+			assert.ErrorIs(t, err, ErrCorruptedEntry)
+		}
+	})
+}
+
+func TestEntryTypeName(t *testing.T) {
+	e := &entry{typeName: "cacheable.user", payload: []byte("payload")}
+	decoded, err := decodeEntry(encodeEntry(e))
+	assert.NoError(t, err)
+	assert.Equal(t, e, decoded)
+}
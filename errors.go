@@ -0,0 +1,124 @@
+package cacheable
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotSupported store不具备对应的可选能力时返回
+	ErrNotSupported = errors.New("operation not supported by store")
+	// ErrCorruptedEntry 缓存值的格式无法解析
+	ErrCorruptedEntry = errors.New("corrupted cache entry")
+	// ErrTagsUnsupported store不支持tag，可以通过WithTagIndex由CacheManager维护tag
+	ErrTagsUnsupported = errors.New("tags not supported by store")
+	// ErrTTLUnsupported store无法读取key的剩余有效期，errors.Is(err, ErrNotSupported)同样为true
+	ErrTTLUnsupported = fmt.Errorf("ttl %w", ErrNotSupported)
+	// ErrLockTimeout 等待写锁超时
+	ErrLockTimeout = errors.New("timeout waiting for write lock")
+	// ErrLockLost 持有写锁期间锁已过期或被其他持有者获取，fn的结果不会写入缓存
+	ErrLockLost = errors.New("write lock lost")
+	// ErrEmptyNamespace namespace不能为空，否则key中会出现连续的分隔符，可能与以:开头的namespace冲突
+	ErrEmptyNamespace = errors.New("namespace must not be empty")
+	// ErrReservedNamespace namespace为内部使用的名称，例如写锁使用的__lock__，或者以租户的前缀t=开头
+	ErrReservedNamespace = errors.New("namespace is reserved")
+	// ErrNotEncrypted 开启WithEncryption后读取到未加密的缓存，通常是开启加密前写入的旧缓存
+	ErrNotEncrypted = errors.New("cached value is not encrypted")
+	// ErrUnknownEncryptionKey 缓存使用的加密key不在WithEncryption的key中
+	ErrUnknownEncryptionKey = errors.New("cached value is encrypted with an unknown key")
+	// ErrUnsupportedDataType store返回的数据类型无法转换为[]byte
+	ErrUnsupportedDataType = errors.New("unsupported data type")
+	// ErrEmptyValue 缓存中的值为空，无法反序列化为目标类型，通常是外部直接向store写入了空值
+	ErrEmptyValue = errors.New("cached value is empty")
+	// ErrTagFuncPanic 动态tag函数发生panic
+	ErrTagFuncPanic = errors.New("dynamic tag function panicked")
+	// ErrNotFound fn返回该错误（或包装了该错误）表示数据不存在，配合WithNegativeCache使用
+	ErrNotFound = errors.New("not found")
+	// ErrFetchTimeout fn的执行时间超过WithFetchTimeout
+	ErrFetchTimeout = errors.New("fetch timeout")
+	// ErrMissingTenant 开启WithTenantFromContext后ctx中没有租户，并且没有设置WithDefaultTenant
+	ErrMissingTenant = errors.New("tenant not found in context")
+	// ErrInvalidTenant 租户中包含分隔符:
+	ErrInvalidTenant = errors.New("tenant must not contain ':'")
+	// ErrValueTooLarge Set、SetRaw写入的值超过WithMaxValueSize的限制，值没有写入缓存
+	ErrValueTooLarge = errors.New("value exceeds max cache value size")
+	// ErrInvalidWarmTask 预热任务不是通过NewWarmTask创建的
+	ErrInvalidWarmTask = errors.New("warm task must be created by NewWarmTask")
+
+	errUnexpectedPrimitive = errors.New("cached value is a primitive but the target type is not")
+	errTombstone           = errors.New("cached value is a negative cache entry")
+	errKeyExists           = errors.New("key already exists")
+	errPingMismatch        = errors.New("ping read back an unexpected value")
+	errChecksumMismatch    = errors.New("cached value checksum mismatch")
+)
+
+// DecodeError 缓存值无法反序列化为目标类型，错误信息中只包含key的哈希，避免泄露key中的敏感信息
+type DecodeError struct {
+	Namespace string
+	Key       string
+	Type      string
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cacheable: decode %s/%s into %s: %v", e.Namespace, keyHash(e.Key), e.Type, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// KeyDeleteError 批量删除时某个key删除失败，错误信息中只包含key的哈希。
+// KeysDeleter返回由KeyDeleteError合并的错误时，其余的key视为删除成功
+type KeyDeleteError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyDeleteError) Error() string {
+	return fmt.Sprintf("cacheable: delete %s: %v", keyHash(e.Key), e.Err)
+}
+
+func (e *KeyDeleteError) Unwrap() error {
+	return e.Err
+}
+
+// failedKeys 返回err中删除失败的key，err中有不是KeyDeleteError的错误时无法确定哪些key失败，返回false
+func failedKeys(err error) (map[string]struct{}, bool) {
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else {
+		errs = []error{err}
+	}
+	failed := make(map[string]struct{}, len(errs))
+	for _, err := range errs {
+		var deleteErr *KeyDeleteError
+		if !errors.As(err, &deleteErr) {
+			return nil, false
+		}
+		failed[deleteErr.Key] = struct{}{}
+	}
+	return failed, true
+}
+
+// storeError 包装store返回的错误，保留原始错误，可以通过errors.Is、errors.As判断
+func storeError(operation string, err error) error {
+	return fmt.Errorf("cacheable: store %s: %w", operation, err)
+}
+
+// panicError 将fn的panic转换为错误，panic的值为error时保留原始错误
+func panicError(r any) error {
+	if err, ok := r.(error); ok {
+		return fmt.Errorf("cacheable: fn panicked: %w", err)
+	}
+	return fmt.Errorf("cacheable: fn panicked: %v", r)
+}
+
+// keyHash 返回key的短哈希，用于错误信息和日志
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,92 @@
+package cacheable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errMyNotFound = errors.New("my not found")
+
+type myError struct {
+	Code int
+}
+
+func (e *myError) Error() string {
+	return "my error"
+}
+
+func TestErrorsPreserved(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fn返回的错误原样返回", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, err, cached := Get(ctx, cacheManager, namespace, "err_sentinel", func() (string, error) {
+			return "", errMyNotFound
+		})
+		assert.ErrorIs(t, err, errMyNotFound)
+		assert.False(t, cached)
+
+		_, err, _ = cacheManager.Get(ctx, namespace, "err_custom", func() ([]byte, error) {
+			return nil, &myError{Code: 404}
+		})
+		var target *myError
+		assert.ErrorAs(t, err, &target)
+		assert.Equal(t, 404, target.Code)
+
+		_, err, _ = Get(ctx, cacheManager, namespace, "err_timeout", func() (string, error) {
+			return "", errMyNotFound
+		}, WithFetchTimeout(time.Second))
+		assert.ErrorIs(t, err, errMyNotFound)
+	})
+
+	t.Run("合并等待的请求收到相同的错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		var wg sync.WaitGroup
+		for n := 0; n < 5; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err, _ := Get(ctx, cacheManager, namespace, "err_shared", func() (string, error) {
+					time.Sleep(20 * time.Millisecond)
+					return "", errMyNotFound
+				})
+				assert.ErrorIs(t, err, errMyNotFound)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("panic的值为error时保留原始错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, err, _ := Get(ctx, cacheManager, namespace, "err_panic", func() (string, error) {
+			panic(errMyNotFound)
+		})
+		assert.ErrorIs(t, err, errMyNotFound)
+		assert.ErrorContains(t, err, "fn panicked")
+	})
+
+	t.Run("store的错误可以展开", func(t *testing.T) {
+		cacheManager := NewCacheManager(&flakyStore{StoreInterface: newGoCacheStore(), failures: 100})
+		_, err, _ := Get(ctx, cacheManager, namespace, "err_store", func() (string, error) {
+			return "value", nil
+		})
+		assert.ErrorIs(t, err, errFlaky)
+		assert.ErrorIs(t, Set(ctx, cacheManager, namespace, "err_store", "value"), errFlaky)
+	})
+
+	t.Run("序列化的错误可以展开", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, err, _ := Get(ctx, cacheManager, namespace, "err_marshal", func() (chan int, error) {
+			return make(chan int), nil
+		})
+		var unsupported *json.UnsupportedTypeError
+		assert.ErrorAs(t, err, &unsupported)
+		assert.ErrorContains(t, err, "encode chan int")
+	})
+}
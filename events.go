@@ -0,0 +1,101 @@
+package cacheable
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Op Event对应的操作
+type Op string
+
+const (
+	OpGet          Op = "get"
+	OpSet          Op = "set"
+	OpDelete       Op = "delete"
+	OpDeleteByTags Op = "delete_by_tags"
+)
+
+// Event 一次缓存操作的完整结果，Key为store中实际的key，按tag删除时Key为空，Tags为删除的tag
+type Event struct {
+	Op        Op
+	Namespace string
+	Key       string
+	Tags      []string
+	// Hit、Shared 只用于OpGet，含义与Meta.Cached、Meta.Shared相同
+	Hit    bool
+	Shared bool
+	Err    error
+	// Duration Get为整个读取（包括调用fn）的耗时，Set为写入store的耗时，删除为store删除的耗时
+	Duration time.Duration
+}
+
+// outcome 输出Debug日志时事件的结果：hit、miss、set、error或invalidate
+func (ev Event) outcome() string {
+	switch {
+	case ev.Err != nil:
+		return "error"
+	case ev.Op == OpGet && ev.Hit:
+		return "hit"
+	case ev.Op == OpGet:
+		return "miss"
+	case ev.Op == OpSet:
+		return "set"
+	}
+	return "invalidate"
+}
+
+// WithObserver 每次Get、写入、Delete和DeleteByTags结束后（包括出错时）调用fn，可以基于Event实现自己的指标、追踪或审计日志。
+// fn在请求的goroutine中同步执行，不应有耗时操作，fn中的panic会被忽略，不影响调用方
+func WithObserver(fn func(ev Event)) ManagerOption {
+	return func(m *CacheManager) {
+		m.observer = fn
+	}
+}
+
+// observing 是否需要构造Event，未开启WithObserver并且没有输出Debug日志时调用方可以跳过计算key等开销
+func (i *CacheManager) observing(ctx context.Context) bool {
+	return i.observer != nil || i.debugEnabled(ctx)
+}
+
+// debugEnabled 设置了WithLogger并且开启了Debug级别时，每个Event同时以Debug级别输出，用于排查问题
+func (i *CacheManager) debugEnabled(ctx context.Context) bool {
+	return i.logger != nil && i.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// observe 将一次操作的结果交给WithObserver和Debug日志
+func (i *CacheManager) observe(ctx context.Context, ev Event) {
+	if i.observer != nil {
+		i.callObserver(ev)
+	}
+	if i.debugEnabled(ctx) {
+		i.logEvent(ctx, ev)
+	}
+}
+
+func (i *CacheManager) callObserver(ev Event) {
+	//observer的panic不影响调用方
+	defer func() { _ = recover() }()
+	i.observer(ev)
+}
+
+// logEvent 以Debug级别输出Event，key只输出哈希，避免泄露key中的敏感信息
+func (i *CacheManager) logEvent(ctx context.Context, ev Event) {
+	attrs := []slog.Attr{
+		slog.String("op", string(ev.Op)),
+		slog.String("namespace", ev.Namespace),
+		slog.String("outcome", ev.outcome()),
+		slog.Duration("duration", ev.Duration),
+	}
+	if ev.Key != "" {
+		attrs = append(attrs, slog.String("key_hash", keyHash(ev.Key)))
+	}
+	if len(ev.Tags) > 0 {
+		attrs = append(attrs, slog.String("tags", strings.Join(ev.Tags, ",")))
+	}
+	if ev.Err != nil {
+		attrs = append(attrs, slog.Any("error", ev.Err))
+	}
+	i.logger.LogAttrs(ctx, slog.LevelDebug, "cacheable: event", attrs...)
+}
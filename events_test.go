@@ -0,0 +1,127 @@
+package cacheable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventDebugLog(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cacheManager := newTestGoCacheManager(t, WithLogger(logger))
+	fn := func() (string, error) {
+		return "value", nil
+	}
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "event_key", fn)
+	assert.Contains(t, buf.String(), "op=set")
+	assert.Contains(t, buf.String(), "outcome=miss")
+	assert.Contains(t, buf.String(), "key_hash="+keyHash(cacheManager.buildKey(namespace, "event_key")))
+	assert.NotContains(t, buf.String(), "event_key")
+
+	buf.Reset()
+	_, _, _ = Get(ctx, cacheManager, namespace, "event_key", fn)
+	assert.Contains(t, buf.String(), "outcome=hit")
+
+	buf.Reset()
+	_, _, _ = Get(ctx, cacheManager, namespace, "event_error", func() (string, error) {
+		return "", errors.New("db error")
+	})
+	assert.Contains(t, buf.String(), "outcome=error")
+	assert.Contains(t, buf.String(), "db error")
+
+	buf.Reset()
+	assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"event_tag"}))
+	assert.Contains(t, buf.String(), "outcome=invalidate")
+	assert.Contains(t, buf.String(), "tags=event_tag")
+
+	t.Run("默认的Info级别不输出", func(t *testing.T) {
+		var buf bytes.Buffer
+		cacheManager := newTestGoCacheManager(t, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+		_, _, _ = Get(ctx, cacheManager, namespace, "event_key", fn)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestWithObserver(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var events []Event
+	cacheManager := newTestGoCacheManager(t, WithObserver(func(ev Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	}))
+
+	t.Run("合并的请求", func(t *testing.T) {
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		for n := 0; n < 2; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, _ = Get(ctx, cacheManager, namespace, "observed", func() (string, error) {
+					<-release
+					return "value", nil
+				})
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		var gets, shared int
+		for _, ev := range events {
+			if ev.Op == OpGet {
+				gets++
+				assert.False(t, ev.Hit)
+				if ev.Shared {
+					shared++
+				}
+			}
+		}
+		assert.Equal(t, 2, gets)
+		assert.Equal(t, 1, shared)
+		events = nil
+	})
+
+	t.Run("命中", func(t *testing.T) {
+		_, _, _ = Get(ctx, cacheManager, namespace, "observed", func() (string, error) {
+			return "value", nil
+		})
+		assert.Len(t, events, 1)
+		assert.True(t, events[0].Hit)
+		assert.Equal(t, cacheManager.buildKey(namespace, "observed"), events[0].Key)
+		events = nil
+	})
+
+	t.Run("出错时同样调用", func(t *testing.T) {
+		_, err, _ := Get(ctx, cacheManager, "", "observed", func() (string, error) {
+			return "value", nil
+		})
+		assert.ErrorIs(t, err, ErrEmptyNamespace)
+		assert.Len(t, events, 1)
+		assert.ErrorIs(t, events[0].Err, ErrEmptyNamespace)
+		events = nil
+	})
+
+	t.Run("observer的panic不影响调用方", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithObserver(func(ev Event) {
+			panic("observer bug")
+		}))
+		value, err, _ := Get(ctx, cacheManager, namespace, "observed", func() (string, error) {
+			return "value", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.NoError(t, Delete(ctx, cacheManager, namespace, "observed"))
+	})
+}
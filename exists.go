@@ -0,0 +1,27 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// Exists 判断缓存是否存在，只读取不反序列化，key的拼接与Get相同。
+// WithNegativeCache缓存的错误同样视为存在
+func (i *CacheManager) Exists(ctx context.Context, namespace string, key string) (bool, error) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return false, err
+	}
+	_, err := i.cache.Get(ctx, i.tenantKey(ctx, namespace, key))
+	if errors.Is(err, store.NotFound{}) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func Exists(ctx context.Context, cacheManager *CacheManager, namespace string, key string) (bool, error) {
+	return cacheManager.Exists(ctx, namespace, key)
+}
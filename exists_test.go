@@ -0,0 +1,43 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingGetStore 读取时总是返回err
+type failingGetStore struct {
+	store.StoreInterface
+	err error
+}
+
+func (s *failingGetStore) Get(ctx context.Context, key any) (any, error) {
+	return nil, s.err
+}
+
+func TestExists(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+
+	exists, err := Exists(ctx, cacheManager, namespace, "exists_key")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "exists_key", func() (string, error) {
+		return "value", nil
+	})
+	exists, err = Exists(ctx, cacheManager, namespace, "exists_key")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	t.Run("store出错", func(t *testing.T) {
+		storeErr := errors.New("connection refused")
+		cacheManager := NewCacheManager(&failingGetStore{StoreInterface: newGoCacheStore(), err: storeErr})
+		_, err := Exists(ctx, cacheManager, namespace, "exists_key")
+		assert.ErrorIs(t, err, storeErr)
+	})
+}
@@ -0,0 +1,117 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// ExportEach 按key排序依次读取namespace下的全部缓存并反序列化为T，逐个交给fn处理，用于排查问题时导出缓存内容。
+// 传给fn的key不包含前缀和namespace，被WithAutoHashKeyAbove哈希过的key无法还原，形如h:<哈希>。
+// WithNegativeCache缓存的错误不会导出，无法反序列化的缓存会被跳过，返回跳过的数量，并上报给Hooks.OnError。fn返回错误时停止导出。
+// 需要store实现KeyScanner，否则返回ErrNotSupported
+func ExportEach[T any](ctx context.Context, cacheManager *CacheManager, namespace string, fn func(key string, value T) error) (skipped int, err error) {
+	return cacheManager.eachEntry(ctx, namespace, typeName[T](), func(key string, payload []byte) (bool, error) {
+		var value T
+		if err := decodeWith(cacheManager.codecFor(namespace), payload, &value); err != nil {
+			return false, err
+		}
+		return true, fn(key, value)
+	})
+}
+
+// Entries 返回namespace下全部缓存解压、解密后的原始数据，可以直接通过SetRaw写入其他CacheManager，用于预热和排查问题。
+// key的格式和跳过的缓存与ExportEach相同。
+// 需要枚举并逐个读取整个namespace，耗时与store中key的总数成正比，只适合离线使用，不要在请求路径中调用。
+// 需要store实现KeyScanner，否则返回ErrNotSupported
+func Entries(ctx context.Context, cacheManager *CacheManager, namespace string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	_, err := cacheManager.eachEntry(ctx, namespace, "", func(key string, payload []byte) (bool, error) {
+		result[key] = payload
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// eachEntry 按key排序依次读取namespace下的缓存，将解码后的数据交给fn，跳过错误缓存。
+// fn返回false和错误表示数据无法反序列化为typeName，跳过并上报，返回true和错误时停止枚举
+func (i *CacheManager) eachEntry(ctx context.Context, namespace string, typeName string, fn func(key string, payload []byte) (bool, error)) (skipped int, err error) {
+	if _, err := i.tenant(ctx); err != nil {
+		return 0, err
+	}
+	scanner, ok := i.cache.(KeyScanner)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	prefix := i.namespacePrefix(i.tenantNamespace(ctx, namespace))
+	keys, err := scanner.ScanKeys(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(keys)
+
+	for _, storeKey := range keys {
+		key := strings.TrimPrefix(storeKey, prefix)
+		data, err := i.cache.Get(ctx, storeKey)
+		if errors.Is(err, store.NotFound{}) {
+			//枚举后已过期或被删除
+			continue
+		} else if err != nil {
+			return skipped, err
+		}
+
+		payload, err := i.decodeExported(storeKey, data)
+		if errors.Is(err, errTombstone) {
+			continue
+		}
+		decoded := err == nil
+		if decoded {
+			decoded, err = fn(key, payload)
+		}
+		if !decoded {
+			skipped++
+			i.metrics.error(namespace, opGet, errKindUnmarshal)
+			i.reportError(ctx, namespace, storeKey, &DecodeError{Namespace: namespace, Key: key, Type: typeName, Err: err})
+			continue
+		}
+		if err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+// Export 和ExportEach相同，返回key到值的map，跳过的缓存只上报给Hooks.OnError。
+// namespace较大时建议使用ExportEach，避免一次性占用大量内存
+func Export[T any](ctx context.Context, cacheManager *CacheManager, namespace string) (map[string]T, error) {
+	result := make(map[string]T)
+	_, err := ExportEach(ctx, cacheManager, namespace, func(key string, value T) error {
+		result[key] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (i *CacheManager) decodeExported(storeKey string, data any) ([]byte, error) {
+	stored, err := toBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	e, err := i.decodeValue(storeKey, stored)
+	if err != nil {
+		return nil, err
+	}
+	if e.tombstone {
+		return nil, errTombstone
+	}
+	return e.payload, nil
+}
@@ -0,0 +1,98 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+
+	fill := func(t *testing.T, cacheManager *CacheManager) {
+		for key, age := range map[string]int{"alice": 30, "bob": 40} {
+			_, err, _ := Get(ctx, cacheManager, "export", key, func() (int, error) {
+				return age, nil
+			})
+			assert.NoError(t, err)
+		}
+		// 其他namespace和前缀相同的namespace不会被导出
+		_, _, _ = Get(ctx, cacheManager, "export_other", "carol", func() (int, error) {
+			return 50, nil
+		})
+		_ = cacheManager.cache.Set(ctx, cacheManager.buildKey("export", "corrupted"), []byte("not json"))
+	}
+
+	t.Run("go-cache", func(t *testing.T) {
+		var reported []error
+		cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+			OnError: func(ctx context.Context, namespace string, key string, err error) {
+				reported = append(reported, err)
+			},
+		}))
+		fill(t, cacheManager)
+
+		values, err := Export[int](ctx, cacheManager, "export")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"alice": 30, "bob": 40}, values)
+		assert.Len(t, reported, 1)
+
+		var keys []string
+		skipped, err := ExportEach(ctx, cacheManager, "export", func(key string, value int) error {
+			keys = append(keys, key)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, skipped)
+		assert.Equal(t, []string{"alice", "bob"}, keys)
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		redisStore, _ := newTestRedisStore(t)
+		cacheManager := NewCacheManager(redisStore)
+		fill(t, cacheManager)
+
+		values, err := Export[int](ctx, cacheManager, "export")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"alice": 30, "bob": 40}, values)
+	})
+
+	t.Run("store不支持枚举", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore())
+		_, err := Export[int](ctx, cacheManager, "export")
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
+
+func TestEntries(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+	payload := compressiblePayload()
+	_, _, _ = cacheManager.Get(ctx, "entries", "compressed", func() ([]byte, error) {
+		return payload, nil
+	}, WithCompression(CompressionGzip))
+	_, _, _ = Get(ctx, cacheManager, "entries", "missing", func() (string, error) {
+		return "", ErrNotFound
+	}, WithNegativeCache(time.Minute))
+
+	entries, err := Entries(ctx, cacheManager, "entries")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"compressed": payload}, entries)
+
+	// 导出的数据可以直接写入其他CacheManager
+	target := newTestGoCacheManager(t)
+	for key, data := range entries {
+		assert.NoError(t, target.SetRaw(ctx, "entries", key, data))
+	}
+	value, err, cached := target.Get(ctx, "entries", "compressed", func() ([]byte, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, payload, value)
+
+	_, err = Entries(ctx, NewCacheManager(newGoCacheStore()), "entries")
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
@@ -0,0 +1,67 @@
+package cacheable
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithFetchTimeout 限制fn的执行时间，超时后Get返回ErrFetchTimeout，不写入缓存，合并等待的请求同样返回该错误。
+// 超时与调用方ctx的deadline无关，合并的计算不受发起请求的ctx影响，只受该超时限制。
+// 超时后fn仍在后台运行直到返回，其结果会被丢弃；GetWithContext的fn收到的ctx在超时后会被取消，可以提前结束
+func WithFetchTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.fetchTimeout = d
+	}
+}
+
+// fetchTimeoutContext 返回传给GetWithContext的fn的ctx，设置了WithFetchTimeout时带有对应的超时
+func fetchTimeoutContext(ctx context.Context, opts []Option) (context.Context, context.CancelFunc) {
+	if timeout := applyOptions(opts...).fetchTimeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// callFn 调用fn，设置了WithFetchTimeout时在单独的goroutine中执行，超时后不再等待
+func callFn(options *Options, fn func() ([]byte, error)) ([]byte, error) {
+	if options.fetchTimeout <= 0 {
+		return fn()
+	}
+
+	type result struct {
+		value []byte
+		err   error
+	}
+	//缓冲为1，超时后fn返回时不会阻塞
+	ch := make(chan result, 1)
+	go func() {
+		var r result
+		defer func() {
+			//fn在单独的goroutine中执行，panic需要在这里recover，否则会导致进程退出
+			if p := recover(); p != nil {
+				r.err = panicError(p)
+			}
+			ch <- r
+		}()
+		r.value, r.err = fn()
+	}()
+
+	start := time.Now()
+	timer := time.NewTimer(options.fetchTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		if r.err != nil && isContextError(r.err) && time.Since(start) >= options.fetchTimeout {
+			//GetWithContext的fn因ctx超时提前返回，同样视为超时，避免合并的请求当作发起请求被取消而重新计算
+			return nil, fetchTimeoutError(options.fetchTimeout)
+		}
+		return r.value, r.err
+	case <-timer.C:
+		return nil, fetchTimeoutError(options.fetchTimeout)
+	}
+}
+
+func fetchTimeoutError(timeout time.Duration) error {
+	return fmt.Errorf("%w after %s", ErrFetchTimeout, timeout)
+}
@@ -0,0 +1,112 @@
+package cacheable
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFetchTimeout(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+
+	t.Run("超时返回错误且不写入缓存", func(t *testing.T) {
+		ns, key := "fetch_timeout", "key"
+		release := make(chan struct{})
+		defer close(release)
+
+		start := time.Now()
+		_, err, cached := Get(ctx, cacheManager, ns, key, func() (string, error) {
+			<-release
+			return "slow", nil
+		}, WithFetchTimeout(20*time.Millisecond))
+		assert.ErrorIs(t, err, ErrFetchTimeout)
+		assert.False(t, cached)
+		assert.Less(t, time.Since(start), time.Second)
+
+		exists, err := cacheManager.Exists(ctx, ns, key)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+
+		// 超时不影响之后的读取
+		value, err, cached := Get(ctx, cacheManager, ns, key, func() (string, error) {
+			return "value", nil
+		}, WithFetchTimeout(time.Second))
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("超时后返回的结果被丢弃", func(t *testing.T) {
+		ns, key := "fetch_timeout_late", "key"
+		returned := make(chan struct{})
+		_, err, _ := Get(ctx, cacheManager, ns, key, func() (string, error) {
+			defer close(returned)
+			time.Sleep(50 * time.Millisecond)
+			return "late", nil
+		}, WithFetchTimeout(10*time.Millisecond))
+		assert.ErrorIs(t, err, ErrFetchTimeout)
+
+		<-returned
+		exists, err := cacheManager.Exists(ctx, ns, key)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("合并等待的请求同样超时", func(t *testing.T) {
+		ns, key := "fetch_timeout_shared", "key"
+		release := make(chan struct{})
+		defer close(release)
+
+		var wg sync.WaitGroup
+		for n := 0; n < 5; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err, _ := Get(ctx, cacheManager, ns, key, func() (string, error) {
+					<-release
+					return "slow", nil
+				}, WithFetchTimeout(20*time.Millisecond))
+				assert.ErrorIs(t, err, ErrFetchTimeout)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("与调用方的deadline无关", func(t *testing.T) {
+		callerCtx, cancel := context.WithTimeout(ctx, time.Hour)
+		defer cancel()
+		_, err, _ := Get(callerCtx, cacheManager, "fetch_timeout_caller", "key", func() (string, error) {
+			time.Sleep(50 * time.Millisecond)
+			return "slow", nil
+		}, WithFetchTimeout(10*time.Millisecond))
+		assert.ErrorIs(t, err, ErrFetchTimeout)
+	})
+
+	t.Run("GetWithContext的fn收到带超时的ctx", func(t *testing.T) {
+		_, err, _ := GetWithContext(ctx, cacheManager, "fetch_timeout_ctx", "key", func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}, WithFetchTimeout(10*time.Millisecond))
+		assert.ErrorIs(t, err, ErrFetchTimeout)
+	})
+
+	t.Run("超时的错误不写入负缓存", func(t *testing.T) {
+		ns, key := "fetch_timeout_negative", "key"
+		_, err, _ := Get(ctx, cacheManager, ns, key, func() (string, error) {
+			time.Sleep(50 * time.Millisecond)
+			return "slow", nil
+		}, WithFetchTimeout(10*time.Millisecond), WithNegativeCache(time.Minute), WithNegativeCacheIf(func(error) bool { return true }))
+		assert.ErrorIs(t, err, ErrFetchTimeout)
+
+		value, err, cached := Get(ctx, cacheManager, ns, key, func() (string, error) {
+			return "value", nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "value", value)
+	})
+}
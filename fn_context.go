@@ -0,0 +1,40 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+)
+
+// withFnContext 设置传给fn的context
+func withFnContext(ctx context.Context) Option {
+	return func(o *Options) {
+		o.fnCtx = ctx
+	}
+}
+
+// isContextError 判断err是否由context取消或超时导致
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// GetWithContext 和Get相同，fn接收发起计算的请求的ctx，请求取消时fn可以提前结束。
+// 发起计算的请求取消后fn的结果不会写入缓存；合并等待的其他请求如果ctx仍然有效，
+// 在fn因取消失败时会重新发起计算，不会收到其他请求的取消错误
+func (i *CacheManager) GetWithContext(ctx context.Context, namespace string, key string, fn func(ctx context.Context) ([]byte, error), opts ...Option) (value []byte, err error, cached bool) {
+	opts = append(opts[:len(opts):len(opts)], withFnContext(ctx))
+	return i.Get(ctx, namespace, key, func() ([]byte, error) {
+		fnCtx, cancel := fetchTimeoutContext(ctx, opts)
+		defer cancel()
+		return fn(fnCtx)
+	}, opts...)
+}
+
+// GetWithContext 泛型版本的CacheManager.GetWithContext
+func GetWithContext[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func(ctx context.Context) (T, error), opts ...Option) (value T, err error, cached bool) {
+	opts = append(opts[:len(opts):len(opts)], withFnContext(ctx))
+	return Get(ctx, cacheManager, namespace, key, func() (T, error) {
+		fnCtx, cancel := fetchTimeoutContext(ctx, opts)
+		defer cancel()
+		return fn(fnCtx)
+	}, opts...)
+}
@@ -0,0 +1,82 @@
+package cacheable
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWithContext(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fn接收请求的ctx", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		type ctxKey struct{}
+		reqCtx := context.WithValue(ctx, ctxKey{}, "request")
+		value, err, cached := GetWithContext(reqCtx, cacheManager, namespace, "ctx_value", func(ctx context.Context) (string, error) {
+			return ctx.Value(ctxKey{}).(string), nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "request", value)
+
+		_, _, cached = GetWithContext(ctx, cacheManager, namespace, "ctx_value", func(ctx context.Context) (string, error) {
+			return "", nil
+		})
+		assert.True(t, cached)
+	})
+
+	t.Run("取消后不写入缓存", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		cancelCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		_, _, _ = cacheManager.GetWithContext(cancelCtx, namespace, "ctx_cancel", func(ctx context.Context) ([]byte, error) {
+			cancel()
+			return []byte(`"partial"`), nil
+		})
+
+		calls := 0
+		value, err, cached := GetWithContext(ctx, cacheManager, namespace, "ctx_cancel", func(ctx context.Context) (string, error) {
+			calls++
+			return "full", nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "full", value)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("其他请求取消时重新计算", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		ownerCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		started := make(chan struct{})
+		go func() {
+			_, _, _ = GetWithContext(ownerCtx, cacheManager, namespace, "ctx_shared", func(ctx context.Context) (string, error) {
+				close(started)
+				<-ctx.Done()
+				return "", ctx.Err()
+			})
+		}()
+		<-started
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var value string
+		var err error
+		go func() {
+			defer wg.Done()
+			value, err, _ = GetWithContext(ctx, cacheManager, namespace, "ctx_shared", func(ctx context.Context) (string, error) {
+				return "waiter", nil
+			})
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		wg.Wait()
+		assert.NoError(t, err)
+		assert.Equal(t, "waiter", value)
+	})
+}
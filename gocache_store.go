@@ -0,0 +1,138 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	lib_store "github.com/eko/gocache/lib/v4/store"
+	"github.com/eko/gocache/store/go_cache/v4"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// GoCacheStore 在gocache的go-cache store基础上补充了读取tag等能力，
+// 读写和tag的存储格式与gocache保持一致，可以直接替换go_cache.NewGoCache
+type GoCacheStore struct {
+	*go_cache.GoCacheStore
+	client *gocache.Cache
+	//gocache在Set时会原地修改tag对应的map，读取tag时需要和带tag的Set互斥
+	mu sync.RWMutex
+}
+
+func NewGoCacheStore(client *gocache.Cache, options ...lib_store.Option) *GoCacheStore {
+	return &GoCacheStore{
+		GoCacheStore: go_cache.NewGoCache(client, options...),
+		client:       client,
+	}
+}
+
+func (s *GoCacheStore) Set(ctx context.Context, key any, value any, options ...lib_store.Option) error {
+	if len(lib_store.ApplyOptions(options...).Tags) > 0 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	return s.GoCacheStore.Set(ctx, key, value, options...)
+}
+
+// SetIfAbsent 使用go-cache的Add写入，只在当前进程内是原子的
+func (s *GoCacheStore) SetIfAbsent(_ context.Context, key any, value any, options ...lib_store.Option) (bool, error) {
+	opts := lib_store.ApplyOptions(options...)
+	if s.client.Add(key.(string), value, opts.Expiration) != nil {
+		return false, nil
+	}
+	if len(opts.Tags) == 0 {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range opts.Tags {
+		tagKey := fmt.Sprintf(go_cache.GoCacheTagPattern, tag)
+		cacheKeys, _ := s.client.Get(tagKey)
+		keys, ok := cacheKeys.(map[string]struct{})
+		if !ok {
+			keys = make(map[string]struct{})
+		}
+		keys[key.(string)] = struct{}{}
+		s.client.Set(tagKey, keys, 720*time.Hour)
+	}
+	return true, nil
+}
+
+// TagKeys 返回tag关联的全部key
+func (s *GoCacheStore) TagKeys(_ context.Context, tag string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.client.Get(fmt.Sprintf(go_cache.GoCacheTagPattern, tag))
+	if !ok {
+		return nil, nil
+	}
+	cacheKeys, _ := value.(map[string]struct{})
+	keys := make([]string, 0, len(cacheKeys))
+	for key := range cacheKeys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Expire 修改key的有效期，go-cache不支持单独修改有效期，这里通过读取后重新写入实现，并发写入时不是原子的
+func (s *GoCacheStore) Expire(_ context.Context, key string, expiration time.Duration) error {
+	value, ok := s.client.Get(key)
+	if !ok {
+		return lib_store.NotFoundWithCause(errors.New("value not found in GoCache store"))
+	}
+	return s.client.Replace(key, value, expiration)
+}
+
+// TTL 返回key剩余的有效期
+func (s *GoCacheStore) TTL(_ context.Context, key string) (time.Duration, error) {
+	_, expiration, ok := s.client.GetWithExpiration(key)
+	if !ok {
+		return 0, lib_store.NotFoundWithCause(errors.New("value not found in GoCache store"))
+	}
+	if expiration.IsZero() {
+		return 0, nil
+	}
+	return time.Until(expiration), nil
+}
+
+// ScanKeys 返回以prefix开头的全部未过期的key
+func (s *GoCacheStore) ScanKeys(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for key := range s.client.Items() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// TryLock 使用go-cache的Add加锁，只在当前进程内有效
+func (s *GoCacheStore) TryLock(_ context.Context, name string, token string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client.Add(name, token, ttl) == nil, nil
+}
+
+// ExtendLock 锁仍属于token时重置过期时间
+func (s *GoCacheStore) ExtendLock(_ context.Context, name string, token string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ok := s.client.Get(name); !ok || value != token {
+		return false, nil
+	}
+	return s.client.Replace(name, token, ttl) == nil, nil
+}
+
+func (s *GoCacheStore) Unlock(_ context.Context, name string, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ok := s.client.Get(name); ok && value == token {
+		s.client.Delete(name)
+	}
+	return nil
+}
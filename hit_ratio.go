@@ -0,0 +1,97 @@
+package cacheable
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// WithHitRatioSampler 启动后台采样，每隔interval根据cache_requests_total和cache_hit_total在这段时间内的增量
+// 计算各namespace的命中率，记录到cache_hit_ratio。区间内没有请求的namespace保持上一次的值。
+// 采样在Close时停止
+func WithHitRatioSampler(interval time.Duration) ManagerOption {
+	return func(m *CacheManager) {
+		m.hitRatioInterval = interval
+	}
+}
+
+// hitRatioSampler 定期计算命中率的后台任务
+type hitRatioSampler struct {
+	interval time.Duration
+	metrics  *cacheMetrics
+	stop     chan struct{}
+	done     chan struct{}
+
+	// lastRequests、lastHits 上一次采样时各namespace的计数
+	lastRequests map[string]float64
+	lastHits     map[string]float64
+}
+
+func startHitRatioSampler(interval time.Duration, metrics *cacheMetrics) *hitRatioSampler {
+	s := &hitRatioSampler{
+		interval:     interval,
+		metrics:      metrics,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+		lastRequests: counterValues(metrics.requestTotal),
+		lastHits:     counterValues(metrics.hitTotal),
+	}
+	go s.run()
+	return s
+}
+
+func (s *hitRatioSampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.sample()
+		}
+	}
+}
+
+func (s *hitRatioSampler) sample() {
+	requests := counterValues(s.metrics.requestTotal)
+	hits := counterValues(s.metrics.hitTotal)
+	for namespace, total := range requests {
+		delta := total - s.lastRequests[namespace]
+		if delta <= 0 {
+			continue
+		}
+		s.metrics.hitRatio.WithLabelValues(namespace).Set((hits[namespace] - s.lastHits[namespace]) / delta)
+	}
+	s.lastRequests, s.lastHits = requests, hits
+}
+
+func (s *hitRatioSampler) close() {
+	close(s.stop)
+	<-s.done
+}
+
+// counterValues 读取按namespace区分的计数器的当前值
+func counterValues(vec *prometheus.CounterVec) map[string]float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+
+	values := make(map[string]float64)
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			continue
+		}
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "namespace" {
+				values[label.GetValue()] = m.GetCounter().GetValue()
+			}
+		}
+	}
+	return values
+}
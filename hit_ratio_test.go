@@ -0,0 +1,56 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHitRatioSampler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("按采样区间计算命中率", func(t *testing.T) {
+		ns := "hit_ratio"
+		cacheManager := newTestGoCacheManager(t)
+		sampler := &hitRatioSampler{metrics: defaultCacheMetrics, lastRequests: counterValues(CacheRequestTotal), lastHits: counterValues(CacheHitTotal)}
+
+		// 1次未命中，3次命中
+		for n := 0; n < 4; n++ {
+			_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+				return "value", nil
+			})
+		}
+		sampler.sample()
+		assert.Equal(t, 0.75, testutil.ToFloat64(CacheHitRatio.WithLabelValues(ns)))
+
+		// 没有新请求时保持上一次的值
+		sampler.sample()
+		assert.Equal(t, 0.75, testutil.ToFloat64(CacheHitRatio.WithLabelValues(ns)))
+
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+		sampler.sample()
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheHitRatio.WithLabelValues(ns)))
+	})
+
+	t.Run("后台采样在Close时停止", func(t *testing.T) {
+		ns := "hit_ratio_background"
+		cacheManager := newTestGoCacheManager(t, WithHitRatioSampler(10*time.Millisecond))
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(CacheHitRatio.WithLabelValues(ns)) > 0
+		}, time.Second, 5*time.Millisecond)
+
+		assert.NoError(t, cacheManager.Close())
+		assert.NoError(t, cacheManager.Close())
+	})
+}
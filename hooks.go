@@ -0,0 +1,38 @@
+package cacheable
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Hooks 缓存事件的回调，未设置的回调不会被调用。回调在请求的goroutine中同步执行，不应有耗时操作
+type Hooks struct {
+	// OnSet 写入缓存后调用，key为store中实际的key，options为最终生效的配置，
+	// 包括补全后的有效期和计算后的全部tag，可以用来排查缓存有效期或tag不符合预期的问题
+	OnSet func(ctx context.Context, namespace string, key string, options Options)
+	// OnError 错误不向调用方返回时调用，例如GetOrZero中的错误、动态tag函数的panic，key为store中实际的key
+	OnError func(ctx context.Context, namespace string, key string, err error)
+	// OnSetError 缓存未命中后写入store失败时调用，此时计算结果仍会正常返回给调用方，开启WithStrictSet时不调用
+	OnSetError func(ctx context.Context, namespace string, key string, err error)
+}
+
+func WithHooks(hooks Hooks) ManagerOption {
+	return func(m *CacheManager) {
+		m.hooks = hooks
+	}
+}
+
+// reportError 记录被忽略的错误，调用OnError回调，设置了WithLogger时同时输出日志，key为store中的key。
+// cache_errors_total在出错的位置记录，这里不重复计数
+func (i *CacheManager) reportError(ctx context.Context, namespace string, key string, err error) {
+	if i.logger != nil {
+		i.logger.WarnContext(ctx, "cacheable: ignored error",
+			slog.String("namespace", namespace),
+			slog.String("key_hash", keyHash(key)),
+			slog.Any("error", err),
+		)
+	}
+	if i.hooks.OnError != nil {
+		i.hooks.OnError(ctx, namespace, key, err)
+	}
+}
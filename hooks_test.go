@@ -0,0 +1,80 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diemus/go-cacheable/cacheabletest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnSetHook(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int
+	var gotKey string
+	var got Options
+	cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			calls++
+			gotKey = key
+			got = options
+		},
+	}))
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "on_set", func() (string, error) {
+		return "value", nil
+	}, WithTags("static"), WithDynamicTags(func() []string {
+		return []string{"dynamic"}
+	}))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, cacheManager.buildKey(namespace, "on_set"), gotKey)
+	assert.Equal(t, loadDefaultExpiration(), got.Expiration)
+	assert.Equal(t, []string{"dynamic", "static"}, got.Tags)
+
+	// 命中缓存时不会写入
+	_, _, _ = Get(ctx, cacheManager, namespace, "on_set", func() (string, error) {
+		return "value", nil
+	}, WithExpiration(time.Minute))
+	assert.Equal(t, 1, calls)
+}
+
+func TestSetErrorNonFatal(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("默认返回计算结果", func(t *testing.T) {
+		ns := "set_error"
+		faultStore := cacheabletest.NewFaultInjectingStore(newGoCacheStore())
+		var setErrs []error
+		cacheManager := NewCacheManager(faultStore, WithHooks(Hooks{
+			OnSetError: func(ctx context.Context, namespace string, key string, err error) {
+				setErrs = append(setErrs, err)
+			},
+		}))
+
+		faultStore.FailNext(cacheabletest.OpSet, 1, nil)
+		value, err, cached := Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "value", value)
+		assert.Len(t, setErrs, 1)
+		assert.ErrorIs(t, setErrs[0], cacheabletest.ErrInjected)
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheSetErrorTotal.WithLabelValues(ns)))
+	})
+
+	t.Run("严格模式返回错误", func(t *testing.T) {
+		faultStore := cacheabletest.NewFaultInjectingStore(newGoCacheStore())
+		cacheManager := NewCacheManager(faultStore, WithStrictSet())
+
+		faultStore.FailNext(cacheabletest.OpSet, 1, nil)
+		_, err, _ := Get(ctx, cacheManager, namespace, "strict_set", func() (string, error) {
+			return "value", nil
+		})
+		assert.ErrorIs(t, err, cacheabletest.ErrInjected)
+	})
+}
@@ -0,0 +1,82 @@
+package cacheable
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// inflightCall 记录一次正在进行的计算，计算期间收到key的Delete或tag的DeleteByTags时标记为已删除
+type inflightCall struct {
+	deleted atomic.Bool
+
+	mu          sync.Mutex
+	deletedTags []string
+}
+
+// isDeleted 判断计算期间key或者tags中的任意一个是否被删除，tags为写入时最终生效的tag
+func (c *inflightCall) isDeleted(tags []string) bool {
+	if c.deleted.Load() {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tag := range tags {
+		if slices.Contains(c.deletedTags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *inflightCall) markTagsDeleted(tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deletedTags = append(c.deletedTags, tags...)
+}
+
+// beginInflight 开始跟踪key的一次计算，同一个key可能同时有多个计算（不经过singleflight、Refresh或后台刷新）
+func (i *CacheManager) beginInflight(key string) *inflightCall {
+	i.inflightMu.Lock()
+	defer i.inflightMu.Unlock()
+	if i.inflight == nil {
+		i.inflight = make(map[string]map[*inflightCall]struct{})
+	}
+	if i.inflight[key] == nil {
+		i.inflight[key] = make(map[*inflightCall]struct{})
+	}
+	call := &inflightCall{}
+	i.inflight[key][call] = struct{}{}
+	return call
+}
+
+func (i *CacheManager) endInflight(key string, call *inflightCall) {
+	i.inflightMu.Lock()
+	defer i.inflightMu.Unlock()
+	delete(i.inflight[key], call)
+	if len(i.inflight[key]) == 0 {
+		delete(i.inflight, key)
+	}
+}
+
+func (i *CacheManager) markInflightDeleted(key string) {
+	i.inflightMu.Lock()
+	defer i.inflightMu.Unlock()
+	for call := range i.inflight[key] {
+		call.deleted.Store(true)
+	}
+}
+
+// markInflightTagsDeleted 标记全部计算中的key，写入时带有这些tag的结果不再写入缓存，tags已经按租户隔离
+func (i *CacheManager) markInflightTagsDeleted(tags []string) {
+	if !i.trackInflight {
+		return
+	}
+	i.inflightMu.Lock()
+	defer i.inflightMu.Unlock()
+	for _, calls := range i.inflight {
+		for call := range calls {
+			call.markTagsDeleted(tags)
+		}
+	}
+}
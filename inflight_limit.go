@@ -0,0 +1,73 @@
+package cacheable
+
+import (
+	"context"
+	"time"
+)
+
+// WithMaxInflight 限制同时进行singleflight合并的不同key的数量，防止大量不同key同时未命中时singleflight占用过多内存。
+// 超过限制时新的key等待WithMaxInflightWait设置的时间，仍然没有空位则不经过singleflight直接计算，
+// 并记录到cache_inflight_rejected_total。已经在计算中的key不受限制，仍然会被合并。
+// 位置在计算结束时才释放，请求通过ctx放弃等待后计算仍在进行，继续占用位置
+func WithMaxInflight(n int) ManagerOption {
+	return func(m *CacheManager) {
+		m.maxInflight = n
+	}
+}
+
+// WithMaxInflightWait 设置超过WithMaxInflight限制时等待空位的最长时间，默认不等待
+func WithMaxInflightWait(wait time.Duration) ManagerOption {
+	return func(m *CacheManager) {
+		m.maxInflightWait = wait
+	}
+}
+
+// acquireFlight 为key占用一个singleflight位置，同一个key的多个请求共用一个位置，没有空位时返回false。
+// 位置在计算结束后通过releaseFlight释放，请求放弃等待不会提前释放
+func (i *CacheManager) acquireFlight(ctx context.Context, key string) bool {
+	i.flightMu.Lock()
+	if i.flights[key] > 0 {
+		i.flights[key]++
+		i.flightMu.Unlock()
+		return true
+	}
+	i.flightMu.Unlock()
+
+	select {
+	case i.flightSem <- struct{}{}:
+	default:
+		if i.maxInflightWait <= 0 {
+			return false
+		}
+		timer := time.NewTimer(i.maxInflightWait)
+		defer timer.Stop()
+		select {
+		case i.flightSem <- struct{}{}:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	i.flightMu.Lock()
+	defer i.flightMu.Unlock()
+	if i.flights == nil {
+		i.flights = make(map[string]int)
+	}
+	if i.flights[key] > 0 {
+		//等待期间其他请求已经开始计算该key，共用其位置
+		<-i.flightSem
+	}
+	i.flights[key]++
+	return true
+}
+
+func (i *CacheManager) releaseFlight(key string) {
+	i.flightMu.Lock()
+	defer i.flightMu.Unlock()
+	if i.flights[key]--; i.flights[key] <= 0 {
+		delete(i.flights, key)
+		<-i.flightSem
+	}
+}
@@ -0,0 +1,137 @@
+package cacheable
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxInflight(t *testing.T) {
+	ctx := context.Background()
+
+	// occupy 让key1一直处于计算中，直到release被关闭
+	occupy := func(cacheManager *CacheManager, ns string) (release func(), done <-chan struct{}) {
+		started, releaseCh, doneCh := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			_, _, _ = cacheManager.Get(ctx, ns, "key1", func() ([]byte, error) {
+				close(started)
+				<-releaseCh
+				return []byte("value1"), nil
+			})
+		}()
+		<-started
+		return func() { close(releaseCh) }, doneCh
+	}
+
+	t.Run("超过限制时不合并直接计算", func(t *testing.T) {
+		ns := "max_inflight_bypass"
+		cacheManager := newTestGoCacheManager(t, WithMaxInflight(1))
+		release, done := occupy(cacheManager, ns)
+
+		value, err, meta := cacheManager.GetWithMeta(ctx, ns, "key2", func() ([]byte, error) {
+			return []byte("value2"), nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, meta.Shared)
+		assert.Equal(t, []byte("value2"), value)
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheInflightRejectedTotal.WithLabelValues(ns)))
+
+		release()
+		<-done
+	})
+
+	t.Run("同一个key不受限制", func(t *testing.T) {
+		ns := "max_inflight_same_key"
+		cacheManager := newTestGoCacheManager(t, WithMaxInflight(1))
+		release, done := occupy(cacheManager, ns)
+
+		var wg sync.WaitGroup
+		var shared atomic.Int32
+		for n := 0; n < 3; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, meta := cacheManager.GetWithMeta(ctx, ns, "key1", func() ([]byte, error) {
+					return []byte("other"), nil
+				})
+				if meta.Shared {
+					shared.Add(1)
+				}
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		release()
+		wg.Wait()
+		<-done
+		assert.Equal(t, int32(3), shared.Load())
+		assert.Equal(t, float64(0), testutil.ToFloat64(CacheInflightRejectedTotal.WithLabelValues(ns)))
+	})
+
+	t.Run("等待空位", func(t *testing.T) {
+		ns := "max_inflight_wait"
+		cacheManager := newTestGoCacheManager(t, WithMaxInflight(1), WithMaxInflightWait(time.Second))
+		release, done := occupy(cacheManager, ns)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			release()
+		}()
+		_, err, _ := cacheManager.GetWithMeta(ctx, ns, "key2", func() ([]byte, error) {
+			return []byte("value2"), nil
+		})
+		assert.NoError(t, err)
+		<-done
+		assert.Equal(t, float64(0), testutil.ToFloat64(CacheInflightRejectedTotal.WithLabelValues(ns)))
+		assert.Empty(t, cacheManager.flights)
+	})
+	t.Run("调用方放弃等待后计算结束前仍占用位置", func(t *testing.T) {
+		ns := "max_inflight_cancel"
+		cacheManager := newTestGoCacheManager(t, WithMaxInflight(1))
+		started, releaseCh := make(chan struct{}), make(chan struct{})
+		cancelCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			<-started
+			cancel()
+		}()
+		_, err, _ := cacheManager.GetWithMeta(cancelCtx, ns, "key1", func() ([]byte, error) {
+			close(started)
+			<-releaseCh
+			return []byte("value1"), nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err, meta := cacheManager.GetWithMeta(ctx, ns, "key2", func() ([]byte, error) {
+			return []byte("value2"), nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, meta.Shared)
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheInflightRejectedTotal.WithLabelValues(ns)))
+
+		close(releaseCh)
+		assert.Eventually(t, func() bool {
+			cacheManager.flightMu.Lock()
+			defer cacheManager.flightMu.Unlock()
+			return len(cacheManager.flights) == 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("不合并的计算panic时返回错误", func(t *testing.T) {
+		ns := "max_inflight_panic"
+		cacheManager := newTestGoCacheManager(t, WithMaxInflight(1))
+		release, done := occupy(cacheManager, ns)
+
+		_, err, _ := cacheManager.GetWithMeta(ctx, ns, "key2", func() ([]byte, error) {
+			panic("boom")
+		})
+		assert.ErrorContains(t, err, "boom")
+
+		release()
+		<-done
+	})
+}
@@ -0,0 +1,109 @@
+package cacheable
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflightInvalidation(t *testing.T) {
+	ctx := context.Background()
+
+	// 在fn执行期间删除key，返回删除后再次读取是否命中缓存
+	deleteDuringLoad := func(cacheManager *CacheManager) bool {
+		key := "inflight"
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			value, err, _ := Get(ctx, cacheManager, namespace, key, func() (string, error) {
+				close(started)
+				<-release
+				return "stale value", nil
+			})
+			// 调用方仍然拿到计算结果
+			assert.NoError(t, err)
+			assert.Equal(t, "stale value", value)
+		}()
+
+		<-started
+		assert.NoError(t, Delete(ctx, cacheManager, namespace, key))
+		close(release)
+		<-done
+
+		_, _, cached := Get(ctx, cacheManager, namespace, key, func() (string, error) {
+			return "new value", nil
+		})
+		return cached
+	}
+
+	t.Run("计算期间删除的key不会写入缓存", func(t *testing.T) {
+		assert.False(t, deleteDuringLoad(newTestGoCacheManager(t, WithInflightInvalidation())))
+	})
+
+	t.Run("默认不跟踪计算中的key", func(t *testing.T) {
+		assert.True(t, deleteDuringLoad(newTestGoCacheManager(t)))
+	})
+	t.Run("同一个key的多个计算都会被标记", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithInflightInvalidation())
+		storeKey := cacheManager.buildKey(namespace, "inflight_concurrent")
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		for n := 0; n < 2; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cacheManager.load(ctx, namespace, storeKey, func() ([]byte, error) {
+					started <- struct{}{}
+					<-release
+					return []byte(`"stale value"`), nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		<-started
+		<-started
+		assert.NoError(t, Delete(ctx, cacheManager, namespace, "inflight_concurrent"))
+		close(release)
+		wg.Wait()
+
+		exists, err := Exists(ctx, cacheManager, namespace, "inflight_concurrent")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("计算期间删除的tag不会写入缓存", func(t *testing.T) {
+		for name, opts := range map[string][]ManagerOption{
+			"store的tag": {WithInflightInvalidation()},
+			"tag索引":     {WithInflightInvalidation(), WithTagIndex()},
+		} {
+			t.Run(name, func(t *testing.T) {
+				cacheManager := newTestGoCacheManager(t, opts...)
+				started := make(chan struct{})
+				release := make(chan struct{})
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					_, _, _ = Get(ctx, cacheManager, namespace, "inflight_tagged", func() (string, error) {
+						close(started)
+						<-release
+						return "stale value", nil
+					}, WithTags("inflight_tag"))
+				}()
+
+				<-started
+				assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"inflight_tag"}))
+				close(release)
+				<-done
+
+				exists, err := Exists(ctx, cacheManager, namespace, "inflight_tagged")
+				assert.NoError(t, err)
+				assert.False(t, exists)
+			})
+		}
+	})
+}
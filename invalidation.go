@@ -0,0 +1,174 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type invalidationBatchKey struct{}
+
+// invalidationBatch 请求内收集的待删除key和tag，按CacheManager分组
+type invalidationBatch struct {
+	mu      sync.Mutex
+	pending map[*CacheManager]*pendingInvalidations
+	// managers 记录入队顺序，保证按顺序flush
+	managers []*CacheManager
+}
+
+type pendingInvalidations struct {
+	keys    []string
+	tags    []string
+	keySeen map[string]struct{}
+	tagSeen map[string]struct{}
+}
+
+// WithInvalidationBatch 返回绑定了失效批次的context，之后通过该context调用的QueueDelete和QueueDeleteByTags
+// 只会记录待删除的key和tag，直到FlushInvalidations时才真正删除，通常在数据库事务提交后调用。
+// 没有调用FlushInvalidations（例如事务回滚）时，记录的删除随context一起丢弃
+func WithInvalidationBatch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, invalidationBatchKey{}, &invalidationBatch{
+		pending: make(map[*CacheManager]*pendingInvalidations),
+	})
+}
+
+func invalidationBatchFrom(ctx context.Context) *invalidationBatch {
+	batch, _ := ctx.Value(invalidationBatchKey{}).(*invalidationBatch)
+	return batch
+}
+
+func (b *invalidationBatch) pendingFor(i *CacheManager) *pendingInvalidations {
+	p, ok := b.pending[i]
+	if !ok {
+		p = &pendingInvalidations{keySeen: make(map[string]struct{}), tagSeen: make(map[string]struct{})}
+		b.pending[i] = p
+		b.managers = append(b.managers, i)
+	}
+	return p
+}
+
+// QueueDelete 将key加入context中的失效批次，context没有绑定批次时直接删除
+func (i *CacheManager) QueueDelete(ctx context.Context, namespace string, key string) error {
+	batch := invalidationBatchFrom(ctx)
+	if batch == nil {
+		return i.Delete(ctx, namespace, key)
+	}
+
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	key = i.tenantKey(ctx, namespace, key)
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+	p := batch.pendingFor(i)
+	if _, ok := p.keySeen[key]; !ok {
+		p.keySeen[key] = struct{}{}
+		p.keys = append(p.keys, key)
+	}
+	return nil
+}
+
+// QueueDeleteByTags 将tag加入context中的失效批次，context没有绑定批次时直接删除
+func (i *CacheManager) QueueDeleteByTags(ctx context.Context, tags []string) error {
+	batch := invalidationBatchFrom(ctx)
+	if batch == nil {
+		return i.DeleteByTags(ctx, tags)
+	}
+	if _, err := i.tenant(ctx); err != nil {
+		return err
+	}
+	tags = i.tenantTags(ctx, tags)
+
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+	p := batch.pendingFor(i)
+	for _, tag := range tags {
+		if _, ok := p.tagSeen[tag]; !ok {
+			p.tagSeen[tag] = struct{}{}
+			p.tags = append(p.tags, tag)
+		}
+	}
+	return nil
+}
+
+// FlushInvalidations 执行context中失效批次记录的全部删除，每个CacheManager的key合并为一次删除（store实现KeysDeleter时），
+// tag合并为一次DeleteByTags。执行后批次清空，可以继续使用。某个删除失败不会中断其他删除，返回合并后的错误
+func FlushInvalidations(ctx context.Context) error {
+	batch := invalidationBatchFrom(ctx)
+	if batch == nil {
+		return nil
+	}
+
+	batch.mu.Lock()
+	pending, managers := batch.pending, batch.managers
+	batch.pending = make(map[*CacheManager]*pendingInvalidations)
+	batch.managers = nil
+	batch.mu.Unlock()
+
+	var errs []error
+	for _, i := range managers {
+		p := pending[i]
+		if len(p.keys) > 0 {
+			if err := i.deleteKeys(ctx, p.keys); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(p.tags) > 0 {
+			if err := i.invalidateTags(ctx, p.tags); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deleteKeys 删除多个store中的key，store不支持批量删除时逐个删除，广播删除成功的key
+func (i *CacheManager) deleteKeys(ctx context.Context, keys []string) error {
+	if i.trackInflight {
+		for _, key := range keys {
+			i.markInflightDeleted(key)
+		}
+	}
+	var err error
+	if deleter, ok := i.cache.(KeysDeleter); ok {
+		err = deleter.DeleteKeys(ctx, keys)
+	} else {
+		//逐个删除时某个key失败不影响其他key，返回合并后的错误
+		var errs []error
+		for _, key := range keys {
+			if err := i.cache.Delete(ctx, key); err != nil {
+				errs = append(errs, &KeyDeleteError{Key: key, Err: err})
+			}
+		}
+		err = errors.Join(errs...)
+	}
+
+	deleted := keys
+	if err != nil {
+		//无法确定哪些key删除失败时仍然广播全部key，其他实例多删除本地副本不影响正确性
+		if failed, ok := failedKeys(err); ok {
+			deleted = make([]string, 0, len(keys)-len(failed))
+			for _, key := range keys {
+				if _, ok := failed[key]; !ok {
+					deleted = append(deleted, key)
+				}
+			}
+		}
+	}
+	if len(deleted) > 0 {
+		i.publishInvalidation(ctx, invalidationMessage{Keys: deleted})
+	}
+	return err
+}
+
+// DeleteByTagsAll 依次在每个CacheManager上执行DeleteByTags，用于本地缓存和远程缓存同时使用相同tag的多级缓存。
+// 某个CacheManager失败不会中断其他的删除，返回合并后的错误
+func DeleteByTagsAll(ctx context.Context, tags []string, cacheManagers ...*CacheManager) error {
+	var errs []error
+	for _, i := range cacheManagers {
+		if err := i.DeleteByTags(ctx, tags); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,106 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidationBatch(t *testing.T) {
+	ctx := context.Background()
+
+	fill := func(cacheManager *CacheManager) {
+		for key, tag := range map[string]string{"key1": "tag1", "key2": "tag2", "key3": "tag3"} {
+			_, _, _ = Get(ctx, cacheManager, namespace, key, func() (string, error) {
+				return "value", nil
+			}, WithTags(tag))
+		}
+	}
+	cached := func(cacheManager *CacheManager, key string) bool {
+		_, err := cacheManager.cache.Get(ctx, cacheManager.buildKey(namespace, key))
+		return err == nil
+	}
+
+	t.Run("flush后才删除", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		fill(cacheManager)
+
+		batchCtx := WithInvalidationBatch(ctx)
+		assert.NoError(t, cacheManager.QueueDelete(batchCtx, namespace, "key1"))
+		assert.NoError(t, cacheManager.QueueDelete(batchCtx, namespace, "key1"))
+		assert.NoError(t, cacheManager.QueueDeleteByTags(batchCtx, []string{"tag2"}))
+		assert.True(t, cached(cacheManager, "key1"))
+		assert.True(t, cached(cacheManager, "key2"))
+
+		assert.NoError(t, FlushInvalidations(batchCtx))
+		assert.False(t, cached(cacheManager, "key1"))
+		assert.False(t, cached(cacheManager, "key2"))
+		assert.True(t, cached(cacheManager, "key3"))
+
+		// flush后批次清空
+		fill(cacheManager)
+		assert.NoError(t, FlushInvalidations(batchCtx))
+		assert.True(t, cached(cacheManager, "key1"))
+	})
+
+	t.Run("未flush时丢弃", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		fill(cacheManager)
+
+		batchCtx := WithInvalidationBatch(ctx)
+		assert.NoError(t, cacheManager.QueueDelete(batchCtx, namespace, "key1"))
+		assert.NoError(t, cacheManager.QueueDeleteByTags(batchCtx, []string{"tag2"}))
+
+		assert.True(t, cached(cacheManager, "key1"))
+		assert.True(t, cached(cacheManager, "key2"))
+	})
+
+	t.Run("没有批次时直接删除", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		fill(cacheManager)
+
+		assert.NoError(t, cacheManager.QueueDelete(ctx, namespace, "key1"))
+		assert.NoError(t, cacheManager.QueueDeleteByTags(ctx, []string{"tag2"}))
+		assert.False(t, cached(cacheManager, "key1"))
+		assert.False(t, cached(cacheManager, "key2"))
+		assert.NoError(t, FlushInvalidations(ctx))
+	})
+
+	t.Run("redis合并删除多个key", func(t *testing.T) {
+		redisStore, mr := newTestRedisStore(t)
+		cacheManager := NewCacheManager(redisStore)
+		fill(cacheManager)
+
+		batchCtx := WithInvalidationBatch(ctx)
+		assert.NoError(t, cacheManager.QueueDelete(batchCtx, namespace, "key1"))
+		assert.NoError(t, cacheManager.QueueDelete(batchCtx, namespace, "key2"))
+		assert.NoError(t, FlushInvalidations(batchCtx))
+		assert.False(t, mr.Exists(cacheManager.buildKey(namespace, "key1")))
+		assert.False(t, mr.Exists(cacheManager.buildKey(namespace, "key2")))
+		assert.True(t, mr.Exists(cacheManager.buildKey(namespace, "key3")))
+	})
+}
+
+func TestDeleteByTagsAll(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	local := newTestGoCacheManager(t)
+	remote := NewCacheManager(redisStore)
+	tagless := NewCacheManager(&tagslessStore{newGoCacheStore()})
+
+	for _, cacheManager := range []*CacheManager{local, remote} {
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "all_key", "value", WithTags("all_tag")))
+	}
+
+	// 不支持tag的CacheManager返回错误，不影响其他CacheManager
+	err := DeleteByTagsAll(ctx, []string{"all_tag"}, local, tagless, remote)
+	assert.ErrorIs(t, err, ErrTagsUnsupported)
+	for _, cacheManager := range []*CacheManager{local, remote} {
+		exists, err := Exists(ctx, cacheManager, namespace, "all_key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	}
+
+	assert.NoError(t, DeleteByTagsAll(ctx, []string{"all_tag"}, local, remote))
+}
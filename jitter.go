@@ -0,0 +1,29 @@
+package cacheable
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// WithExpirationJitter 写入时给有效期加上[0, maxJitter)的随机时间，避免同一批写入的缓存在同一时刻过期，
+// 导致大量请求同时穿透。优先级高于WithDefaultExpirationJitter
+func WithExpirationJitter(maxJitter time.Duration) Option {
+	return func(o *Options) {
+		o.expirationJitter = maxJitter
+	}
+}
+
+// WithDefaultExpirationJitter 设置CacheManager默认的有效期随机时间，调用时没有设置WithExpirationJitter时使用
+func WithDefaultExpirationJitter(maxJitter time.Duration) ManagerOption {
+	return func(m *CacheManager) {
+		m.expirationJitter = maxJitter
+	}
+}
+
+// jitter 返回[0, maxJitter)的随机时间，maxJitter不大于0时返回0。math/rand/v2的全局随机数可以并发使用
+func jitter(maxJitter time.Duration) time.Duration {
+	if maxJitter <= 0 {
+		return 0
+	}
+	return rand.N(maxJitter)
+}
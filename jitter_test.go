@@ -0,0 +1,53 @@
+package cacheable
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpirationJitter(t *testing.T) {
+	ctx := context.Background()
+	var expirations []time.Duration
+	hooks := WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			expirations = append(expirations, options.Expiration)
+		},
+	})
+	fn := func() (string, error) {
+		return "value", nil
+	}
+
+	t.Run("有效期加上随机时间", func(t *testing.T) {
+		expirations = nil
+		cacheManager := newTestGoCacheManager(t, hooks)
+		for n := 0; n < 20; n++ {
+			_, _, _ = Get(ctx, cacheManager, namespace, fmt.Sprint("jitter_", n), fn, WithExpiration(time.Minute), WithExpirationJitter(time.Minute))
+		}
+		distinct := make(map[time.Duration]struct{})
+		for _, expiration := range expirations {
+			assert.GreaterOrEqual(t, expiration, time.Minute)
+			assert.Less(t, expiration, 2*time.Minute)
+			distinct[expiration] = struct{}{}
+		}
+		assert.Greater(t, len(distinct), 1)
+	})
+
+	t.Run("使用CacheManager的默认值", func(t *testing.T) {
+		expirations = nil
+		cacheManager := newTestGoCacheManager(t, hooks, WithDefaultExpirationJitter(time.Second))
+		_, _, _ = Get(ctx, cacheManager, namespace, "jitter_default", fn, WithExpiration(time.Minute))
+		assert.GreaterOrEqual(t, expirations[0], time.Minute)
+		assert.Less(t, expirations[0], time.Minute+time.Second)
+	})
+
+	t.Run("没有设置时不变", func(t *testing.T) {
+		expirations = nil
+		cacheManager := newTestGoCacheManager(t, hooks)
+		_, _, _ = Get(ctx, cacheManager, namespace, "jitter_none", fn, WithExpiration(time.Minute))
+		assert.Equal(t, []time.Duration{time.Minute}, expirations)
+	})
+}
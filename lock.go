@@ -0,0 +1,129 @@
+package cacheable
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLockTTL 写锁的过期时间，持有者崩溃时最多阻塞其他写入这么久，等待锁的时间也不会超过它
+var defaultLockTTL = 30 * time.Second
+
+const lockRetryInterval = 10 * time.Millisecond
+
+// writeLock 持有中的写锁
+type writeLock struct {
+	locker   Locker
+	extender LockExtender
+	key      string
+	token    string
+	// lost 续期时发现锁已经不属于当前持有者
+	lost      atomic.Bool
+	stopRenew func()
+}
+
+// acquireLock 获取名为name的写锁，锁的key在内部的namespace中，不会与调用方的key冲突
+func (i *CacheManager) acquireLock(ctx context.Context, namespace string, name string) (*writeLock, error) {
+	locker, ok := i.cache.(Locker)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+	l := &writeLock{locker: locker, key: i.joinKey(lockNamespace, name), token: token, stopRenew: func() {}}
+
+	waitCtx, cancel := context.WithTimeout(ctx, defaultLockTTL)
+	defer cancel()
+	for {
+		locked, err := locker.TryLock(waitCtx, l.key, token, defaultLockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return nil, ErrLockTimeout
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	if extender, ok := locker.(LockExtender); ok {
+		l.extender = extender
+		l.stopRenew = i.renewLock(context.WithoutCancel(ctx), l, namespace)
+	}
+	return l, nil
+}
+
+// renewLock 每隔defaultLockTTL的三分之一续期一次，直到返回的函数被调用。
+// 续期失败时标记锁已丢失，通过Hooks.OnError上报，key为锁在store中的key
+func (i *CacheManager) renewLock(ctx context.Context, l *writeLock, namespace string) func() {
+	ttl := defaultLockTTL
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			ok, err := l.extender.ExtendLock(ctx, l.key, l.token, ttl)
+			if err == nil && !ok {
+				l.lost.Store(true)
+				err = ErrLockLost
+			}
+			if err != nil {
+				i.reportError(ctx, namespace, l.key, err)
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// check 写入前确认仍然持有锁，store支持续期时再续期一次以确认锁没有被其他持有者获取
+func (l *writeLock) check(ctx context.Context) error {
+	if l.lost.Load() {
+		return ErrLockLost
+	}
+	if l.extender == nil {
+		return nil
+	}
+	ok, err := l.extender.ExtendLock(ctx, l.key, l.token, defaultLockTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		l.lost.Store(true)
+		return ErrLockLost
+	}
+	return nil
+}
+
+// release 停止续期并释放锁
+func (l *writeLock) release(ctx context.Context) {
+	l.stopRenew()
+	//调用方的ctx可能已经取消，释放锁不受其影响，释放失败时等待锁自然过期
+	_ = l.locker.Unlock(context.WithoutCancel(ctx), l.key, l.token)
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,151 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	lib_store "github.com/eko/gocache/lib/v4/store"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWriteLock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("同一个锁的写入互斥", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		var running, maxRunning atomic.Int32
+		var wg sync.WaitGroup
+		for n := 0; n < 5; n++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				_, err, _ := Get(ctx, cacheManager, namespace, fmt.Sprintf("locked_%d", n), func() (int, error) {
+					current := running.Add(1)
+					defer running.Add(-1)
+					for {
+						m := maxRunning.Load()
+						if current <= m || maxRunning.CompareAndSwap(m, current) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+					return n, nil
+				}, WithWriteLock("aggregate"))
+				assert.NoError(t, err)
+			}(n)
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), maxRunning.Load())
+	})
+
+	t.Run("fn执行时间超过锁的过期时间时续期", func(t *testing.T) {
+		ttl := defaultLockTTL
+		defaultLockTTL = 60 * time.Millisecond
+		t.Cleanup(func() { defaultLockTTL = ttl })
+
+		cacheManager := newTestGoCacheManager(t)
+		locker := cacheManager.Store().(Locker)
+		lockKey := cacheManager.joinKey(lockNamespace, "renew")
+		_, err, _ := Get(ctx, cacheManager, namespace, "locked_renew", func() (int, error) {
+			time.Sleep(150 * time.Millisecond)
+			locked, err := locker.TryLock(ctx, lockKey, "other", time.Minute)
+			assert.NoError(t, err)
+			assert.False(t, locked)
+			return 1, nil
+		}, WithWriteLock("renew"))
+		assert.NoError(t, err)
+
+		locked, err := locker.TryLock(ctx, lockKey, "other", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, locked)
+	})
+
+	t.Run("锁丢失时不写入缓存", func(t *testing.T) {
+		ttl := defaultLockTTL
+		defaultLockTTL = 60 * time.Millisecond
+		t.Cleanup(func() { defaultLockTTL = ttl })
+
+		var lost atomic.Pointer[string]
+		cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+			OnError: func(_ context.Context, ns string, key string, err error) {
+				if errors.Is(err, ErrLockLost) {
+					lost.Store(&key)
+					assert.Equal(t, namespace, ns)
+				}
+			},
+		}))
+		lockKey := cacheManager.joinKey(lockNamespace, "lost")
+		_, err, _ := Get(ctx, cacheManager, namespace, "locked_lost", func() (int, error) {
+			//模拟锁过期后被其他持有者获取
+			assert.NoError(t, cacheManager.Store().Set(ctx, lockKey, "other", lib_store.WithExpiration(time.Minute)))
+			time.Sleep(50 * time.Millisecond)
+			return 1, nil
+		}, WithWriteLock("lost"))
+		assert.ErrorIs(t, err, ErrLockLost)
+
+		exists, err := Exists(ctx, cacheManager, namespace, "locked_lost")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		if assert.NotNil(t, lost.Load()) {
+			assert.Equal(t, lockKey, *lost.Load())
+		}
+	})
+
+	t.Run("锁的key不会与namespace冲突", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		assert.ErrorIs(t, Set(ctx, cacheManager, lockNamespace, "aggregate", 1), ErrReservedNamespace)
+		assert.NotEqual(t, cacheManager.buildKey("lock", "aggregate"), cacheManager.joinKey(lockNamespace, "aggregate"))
+	})
+
+	t.Run("store不支持分布式锁", func(t *testing.T) {
+		_, err, _ := Get(ctx, MockCacheManager, namespace, "locked_unsupported", func() (int, error) {
+			return 1, nil
+		}, WithWriteLock("aggregate"))
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
+
+func TestLockExtender(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	stores := map[string]Locker{
+		"gocache": NewGoCacheStore(gocache.New(5*time.Minute, 10*time.Minute)),
+		"redis":   redisStore,
+	}
+
+	for name, locker := range stores {
+		t.Run(name, func(t *testing.T) {
+			extender := locker.(LockExtender)
+			locked, err := locker.TryLock(ctx, "extend_lock", "token", time.Minute)
+			assert.NoError(t, err)
+			assert.True(t, locked)
+
+			t.Run("持有者可以续期", func(t *testing.T) {
+				ok, err := extender.ExtendLock(ctx, "extend_lock", "token", time.Hour)
+				assert.NoError(t, err)
+				assert.True(t, ok)
+				ttl, err := locker.(TTLReader).TTL(ctx, "extend_lock")
+				assert.NoError(t, err)
+				assert.Greater(t, ttl, time.Minute)
+			})
+
+			t.Run("其他token不能续期", func(t *testing.T) {
+				ok, err := extender.ExtendLock(ctx, "extend_lock", "other", time.Hour)
+				assert.NoError(t, err)
+				assert.False(t, ok)
+			})
+
+			t.Run("锁不存在时不能续期", func(t *testing.T) {
+				ok, err := extender.ExtendLock(ctx, "extend_missing", "token", time.Hour)
+				assert.NoError(t, err)
+				assert.False(t, ok)
+			})
+		})
+	}
+}
@@ -26,3 +26,8 @@ func TestMain(m *testing.M) {
 	// 退出测试
 	os.Exit(code)
 }
+
+// newTestGoCacheManager 创建独立的go-cache缓存管理器，用于需要store可选能力或自定义配置的测试
+func newTestGoCacheManager(t *testing.T, opts ...ManagerOption) *CacheManager {
+	return NewCacheManager(NewGoCacheStore(gocache.New(5*time.Minute, 10*time.Minute)), opts...)
+}
@@ -0,0 +1,95 @@
+package cacheable
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxValueSize(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := NewCacheManager(newGoCacheStore(), WithMaxValueSize(16))
+	large := strings.Repeat("x", 64)
+
+	t.Run("超过限制的结果正常返回但不写入缓存", func(t *testing.T) {
+		ns := "max_value_size_large"
+		skipped := testutil.ToFloat64(CacheSkippedTooLargeTotal.WithLabelValues(ns))
+		calls := 0
+		fn := func() (string, error) {
+			calls++
+			return large, nil
+		}
+
+		for range 2 {
+			value, err, _ := Get(ctx, cacheManager, ns, "key", fn)
+			assert.NoError(t, err)
+			assert.Equal(t, large, value)
+		}
+		assert.Equal(t, 2, calls)
+
+		exists, err := Exists(ctx, cacheManager, ns, "key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		assert.Equal(t, skipped+2, testutil.ToFloat64(CacheSkippedTooLargeTotal.WithLabelValues(ns)))
+	})
+
+	t.Run("未超过限制的结果正常缓存", func(t *testing.T) {
+		ns := "max_value_size_small"
+		value, err, cached := Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "small", nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "small", value)
+
+		value, err, cached = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "other", nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "small", value)
+	})
+
+	t.Run("Set和SetRaw返回ErrValueTooLarge并删除旧值", func(t *testing.T) {
+		ns := "max_value_size_set"
+		assert.NoError(t, Set(ctx, cacheManager, ns, "set", "old"))
+		assert.ErrorIs(t, Set(ctx, cacheManager, ns, "set", large), ErrValueTooLarge)
+		assert.ErrorIs(t, SetRaw(ctx, cacheManager, ns, "raw", []byte(large)), ErrValueTooLarge)
+
+		for _, key := range []string{"set", "raw"} {
+			exists, err := Exists(ctx, cacheManager, ns, key)
+			assert.NoError(t, err)
+			assert.False(t, exists, key)
+		}
+	})
+
+	t.Run("Refresh的结果超过限制时删除旧值", func(t *testing.T) {
+		ns := "max_value_size_refresh"
+		assert.NoError(t, Set(ctx, cacheManager, ns, "key", "old"))
+
+		value, err := Refresh(ctx, cacheManager, ns, "key", func() (string, error) {
+			return large, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, large, value)
+
+		exists, err := Exists(ctx, cacheManager, ns, "key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("SetIfAbsent返回ErrValueTooLarge", func(t *testing.T) {
+		ns := "max_value_size_if_absent"
+		cacheManager := newTestGoCacheManager(t, WithMaxValueSize(16))
+		ok, err := SetIfAbsent(ctx, cacheManager, ns, "key", large)
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+		assert.False(t, ok)
+
+		exists, err := Exists(ctx, cacheManager, ns, "key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
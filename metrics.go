@@ -1,21 +1,342 @@
 package cacheable
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// 指标在创建第一个CacheManager或ShadowStore、或调用InitMetrics时才会构造，使用当时配置的指标前缀，
+// 在此之前这些变量为nil。旧版本在包初始化时就构造了这些变量，在init中直接注册这些变量的代码需要先调用InitMetrics，
+// 或者改为通过SetMetricsRegisterer注册
+var (
+	CacheRequestTotal *prometheus.CounterVec
+	CacheHitTotal     *prometheus.CounterVec
+	// CacheMissTotal 缓存不存在需要调用fn的请求数，不包括解码失败或类型不匹配后的重新计算
+	CacheMissTotal *prometheus.CounterVec
+	// CacheErrorTotal 读写缓存出错的次数，operation为get、set、delete或invalidate，
+	// kind为store（store返回错误）、marshal、unmarshal或tag（动态tag函数panic），不包括fn返回的错误
+	CacheErrorTotal *prometheus.CounterVec
+	// CacheSetErrorTotal 缓存未命中后写入store失败的次数
+	CacheSetErrorTotal *prometheus.CounterVec
+	// CacheCoalescedTotal 缓存未命中时通过singleflight合并、等待其他请求计算结果的请求数
+	CacheCoalescedTotal *prometheus.CounterVec
+	// CacheInflightRejectedTotal 超过WithMaxInflight限制，没有经过singleflight合并的未命中请求数
+	CacheInflightRejectedTotal *prometheus.CounterVec
+	// CacheSkippedTooLargeTotal 超过WithMaxValueSize限制没有写入缓存的次数
+	CacheSkippedTooLargeTotal *prometheus.CounterVec
+	// CacheHitRatio 各namespace最近一个采样区间的命中率，需要通过WithHitRatioSampler开启
+	CacheHitRatio *prometheus.GaugeVec
+	// CacheDecodeRetryTotal 开启WithDecodeRetry后解码失败的重试次数，result为reread（重新读取成功）或recompute（重新计算）
+	CacheDecodeRetryTotal *prometheus.CounterVec
+	// CacheFetchDuration 缓存未命中时fn的耗时，包括返回错误的调用
+	CacheFetchDuration *prometheus.HistogramVec
+	// CacheStoreDuration 写入store的耗时
+	CacheStoreDuration *prometheus.HistogramVec
+	// CacheValueBytes 写入store的值大小，需要通过WithValueSizeMetrics开启
+	CacheValueBytes *prometheus.HistogramVec
+	// CacheCompressionRatio 压缩后与压缩前的大小之比，只在namespace开启压缩时记录
+	CacheCompressionRatio *prometheus.HistogramVec
+	// CacheCompressionDuration 压缩耗时，只在namespace开启压缩时记录
+	CacheCompressionDuration *prometheus.HistogramVec
+	// CacheCircuitBreakerState WithCircuitBreaker熔断器的状态，0为闭合，1为断开，2为半开，name为CircuitBreakerSettings.Name
+	CacheCircuitBreakerState *prometheus.GaugeVec
+
+	// ShadowStoreRequestTotal ShadowStore中各个后端的请求数，backend为primary或secondary
+	ShadowStoreRequestTotal *prometheus.CounterVec
+	ShadowStoreHitTotal     *prometheus.CounterVec
+	ShadowStoreErrorTotal   *prometheus.CounterVec
+	ShadowStoreDuration     *prometheus.HistogramVec
+	// ShadowStoreDroppedTotal 并发镜像请求达到上限后被丢弃的请求数
+	ShadowStoreDroppedTotal prometheus.Counter
+)
+
 var (
-	CacheRequestTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace: defaultMetricsPrefix,
-		Name:      "cache_requests_total",
-		Help:      "cache_requests_total",
-	}, []string{"namespace"},
-	)
-
-	CacheHitTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace: defaultMetricsPrefix,
-		Name:      "cache_hit_total",
-		Help:      "cache_hit_total",
-	}, []string{"namespace"},
-	)
+	metricsOnce       sync.Once
+	metricsRegisterer prometheus.Registerer
+	metricsBuckets    = prometheus.DefBuckets
+	// defaultCacheMetrics 包级别指标（CacheRequestTotal等）对应的cacheMetrics
+	defaultCacheMetrics *cacheMetrics
 )
+
+// SetMetricsRegisterer 设置指标注册到的Registerer，例如prometheus.DefaultRegisterer，默认不注册。
+// 需要在创建第一个CacheManager之前调用
+func SetMetricsRegisterer(registerer prometheus.Registerer) {
+	metricsRegisterer = registerer
+}
+
+// SetMetricsBuckets 设置cache_fetch_duration_seconds和cache_store_duration_seconds的分桶（单位为秒），
+// 默认为prometheus.DefBuckets。需要在创建第一个CacheManager之前调用
+func SetMetricsBuckets(buckets []float64) {
+	metricsBuckets = buckets
+}
+
+// InitMetrics 使用当前的指标前缀、分桶和Registerer构造并注册包级别的指标，只有第一次调用生效。
+// 创建CacheManager时会自动调用，只有在创建CacheManager之前就需要使用CacheRequestTotal等变量时才需要显式调用
+func InitMetrics() {
+	initMetrics()
+}
+
+// initMetrics 构造全部指标并注册，只会执行一次
+func initMetrics() {
+	metricsOnce.Do(func() {
+		defaultCacheMetrics = newCacheMetrics(loadDefaultMetricsPrefix())
+		m := defaultCacheMetrics
+		CacheRequestTotal = m.requestTotal
+		CacheHitTotal = m.hitTotal
+		CacheMissTotal = m.missTotal
+		CacheErrorTotal = m.errorTotal
+		CacheSetErrorTotal = m.setErrorTotal
+		CacheCoalescedTotal = m.coalescedTotal
+		CacheInflightRejectedTotal = m.inflightRejectedTotal
+		CacheSkippedTooLargeTotal = m.skippedTooLargeTotal
+		CacheHitRatio = m.hitRatio
+		CacheDecodeRetryTotal = m.decodeRetryTotal
+		CacheFetchDuration = m.fetchDuration
+		CacheStoreDuration = m.storeDuration
+		CacheValueBytes = m.valueBytes
+		CacheCompressionRatio = m.compressionRatio
+		CacheCompressionDuration = m.compressionDuration
+		CacheCircuitBreakerState = m.circuitBreakerState
+
+		shadow := newShadowMetrics(loadDefaultMetricsPrefix())
+		ShadowStoreRequestTotal = shadow.requestTotal
+		ShadowStoreHitTotal = shadow.hitTotal
+		ShadowStoreErrorTotal = shadow.errorTotal
+		ShadowStoreDuration = shadow.duration
+		ShadowStoreDroppedTotal = shadow.droppedTotal
+
+		if metricsRegisterer != nil {
+			metricsRegisterer.MustRegister(append(m.collectors(), shadow.collectors()...)...)
+		}
+	})
+}
+
+// shadowMetrics ShadowStore使用的指标，只有包级别的一组
+type shadowMetrics struct {
+	requestTotal *prometheus.CounterVec
+	hitTotal     *prometheus.CounterVec
+	errorTotal   *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	droppedTotal prometheus.Counter
+}
+
+func newShadowMetrics(prefix string) *shadowMetrics {
+	return &shadowMetrics{
+		requestTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "shadow_store_requests_total",
+			Help:      "shadow_store_requests_total",
+		}, []string{"backend", "operation"},
+		),
+		hitTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "shadow_store_hit_total",
+			Help:      "shadow_store_hit_total",
+		}, []string{"backend"},
+		),
+		errorTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "shadow_store_errors_total",
+			Help:      "shadow_store_errors_total",
+		}, []string{"backend", "operation"},
+		),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "shadow_store_duration_seconds",
+			Help:      "shadow_store_duration_seconds",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"},
+		),
+		droppedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "shadow_store_dropped_total",
+			Help:      "shadow_store_dropped_total",
+		}),
+	}
+}
+
+func (m *shadowMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.requestTotal, m.hitTotal, m.errorTotal, m.duration, m.droppedTotal}
+}
+
+// cacheMetrics CacheManager使用的一组指标，默认使用包级别的指标，WithRegistry时每个CacheManager单独创建
+type cacheMetrics struct {
+	requestTotal          *prometheus.CounterVec
+	hitTotal              *prometheus.CounterVec
+	missTotal             *prometheus.CounterVec
+	errorTotal            *prometheus.CounterVec
+	setErrorTotal         *prometheus.CounterVec
+	coalescedTotal        *prometheus.CounterVec
+	inflightRejectedTotal *prometheus.CounterVec
+	skippedTooLargeTotal  *prometheus.CounterVec
+	hitRatio              *prometheus.GaugeVec
+	decodeRetryTotal      *prometheus.CounterVec
+	fetchDuration         *prometheus.HistogramVec
+	storeDuration         *prometheus.HistogramVec
+	valueBytes            *prometheus.HistogramVec
+	compressionRatio      *prometheus.HistogramVec
+	compressionDuration   *prometheus.HistogramVec
+	circuitBreakerState   *prometheus.GaugeVec
+}
+
+func newCacheMetrics(prefix string) *cacheMetrics {
+	return &cacheMetrics{
+		requestTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_requests_total",
+			Help:      "cache_requests_total",
+		}, []string{"namespace"},
+		),
+		hitTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_hit_total",
+			Help:      "cache_hit_total",
+		}, []string{"namespace"},
+		),
+		missTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_miss_total",
+			Help:      "cache_miss_total",
+		}, []string{"namespace"},
+		),
+		errorTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_errors_total",
+			Help:      "cache_errors_total",
+		}, []string{"namespace", "operation", "kind"},
+		),
+		setErrorTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_set_error_total",
+			Help:      "cache_set_error_total",
+		}, []string{"namespace"},
+		),
+		coalescedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_coalesced_total",
+			Help:      "cache_coalesced_total",
+		}, []string{"namespace"},
+		),
+		inflightRejectedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_inflight_rejected_total",
+			Help:      "cache_inflight_rejected_total",
+		}, []string{"namespace"},
+		),
+		skippedTooLargeTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_skipped_too_large_total",
+			Help:      "cache_skipped_too_large_total",
+		}, []string{"namespace"},
+		),
+		hitRatio: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "cache_hit_ratio",
+			Help:      "cache_hit_ratio",
+		}, []string{"namespace"},
+		),
+		decodeRetryTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "cache_decode_retry_total",
+			Help:      "cache_decode_retry_total",
+		}, []string{"namespace", "result"},
+		),
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "cache_fetch_duration_seconds",
+			Help:      "cache_fetch_duration_seconds",
+			Buckets:   metricsBuckets,
+		}, []string{"namespace"},
+		),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "cache_store_duration_seconds",
+			Help:      "cache_store_duration_seconds",
+			Buckets:   metricsBuckets,
+		}, []string{"namespace"},
+		),
+		valueBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "cache_value_bytes",
+			Help:      "cache_value_bytes",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 8), // 64B ~ 1MB
+		}, []string{"namespace"},
+		),
+		compressionRatio: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "cache_compression_ratio",
+			Help:      "cache_compression_ratio",
+			Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10), // 0.1 ~ 1.0
+		}, []string{"namespace"},
+		),
+		compressionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "cache_compression_duration_seconds",
+			Help:      "cache_compression_duration_seconds",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 8), // 10us ~ 160ms
+		}, []string{"namespace"},
+		),
+		circuitBreakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "cache_circuit_breaker_state",
+			Help:      "cache_circuit_breaker_state",
+		}, []string{"name"},
+		),
+	}
+}
+
+func (m *cacheMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.requestTotal,
+		m.hitTotal,
+		m.missTotal,
+		m.errorTotal,
+		m.setErrorTotal,
+		m.coalescedTotal,
+		m.inflightRejectedTotal,
+		m.skippedTooLargeTotal,
+		m.hitRatio,
+		m.decodeRetryTotal,
+		m.fetchDuration,
+		m.storeDuration,
+		m.valueBytes,
+		m.compressionRatio,
+		m.compressionDuration,
+		m.circuitBreakerState,
+	}
+}
+
+// register 将指标注册到reg，已经注册过同名指标时改用已注册的指标
+func (m *cacheMetrics) register(reg prometheus.Registerer) {
+	m.requestTotal = registerOrExisting(reg, m.requestTotal)
+	m.hitTotal = registerOrExisting(reg, m.hitTotal)
+	m.missTotal = registerOrExisting(reg, m.missTotal)
+	m.errorTotal = registerOrExisting(reg, m.errorTotal)
+	m.setErrorTotal = registerOrExisting(reg, m.setErrorTotal)
+	m.coalescedTotal = registerOrExisting(reg, m.coalescedTotal)
+	m.inflightRejectedTotal = registerOrExisting(reg, m.inflightRejectedTotal)
+	m.skippedTooLargeTotal = registerOrExisting(reg, m.skippedTooLargeTotal)
+	m.hitRatio = registerOrExisting(reg, m.hitRatio)
+	m.decodeRetryTotal = registerOrExisting(reg, m.decodeRetryTotal)
+	m.fetchDuration = registerOrExisting(reg, m.fetchDuration)
+	m.storeDuration = registerOrExisting(reg, m.storeDuration)
+	m.valueBytes = registerOrExisting(reg, m.valueBytes)
+	m.compressionRatio = registerOrExisting(reg, m.compressionRatio)
+	m.compressionDuration = registerOrExisting(reg, m.compressionDuration)
+	m.circuitBreakerState = registerOrExisting(reg, m.circuitBreakerState)
+}
+
+func registerOrExisting[C prometheus.Collector](reg prometheus.Registerer, c C) C {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,172 @@
+package cacheable
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cache_errors_total的operation和kind
+const (
+	opGet        = "get"
+	opSet        = "set"
+	opDelete     = "delete"
+	opInvalidate = "invalidate"
+
+	errKindStore     = "store"
+	errKindMarshal   = "marshal"
+	errKindUnmarshal = "unmarshal"
+	errKindTag       = "tag"
+	errKindChecksum  = "checksum"
+)
+
+// metricsRecorder CacheManager记录指标的方式，默认写入prometheus指标，WithMetricsDisabled时不做任何事
+type metricsRecorder interface {
+	request(namespace string, n int)
+	hit(namespace string, n int)
+	miss(namespace string, n int)
+	error(namespace string, operation string, kind string)
+	setError(namespace string)
+	coalesced(namespace string)
+	inflightRejected(namespace string)
+	skippedTooLarge(namespace string)
+	decodeRetry(namespace string, result string)
+	fetchDuration(namespace string, d time.Duration)
+	storeDuration(namespace string, d time.Duration)
+	valueBytes(namespace string, n int)
+	compressionDuration(namespace string, d time.Duration)
+	compressionRatio(namespace string, ratio float64)
+	circuitState(name string, state CircuitState)
+}
+
+// WithMetricsDisabled 不记录CacheManager的指标，用于对延迟敏感、不需要指标的场景，
+// 此时WithHitRatioSampler、WithValueSizeMetrics不会产生数据
+func WithMetricsDisabled() ManagerOption {
+	return func(m *CacheManager) {
+		m.metrics = noopMetrics{}
+		m.ownMetrics = false
+	}
+}
+
+// WithRegistry 为CacheManager单独创建一组指标并注册到reg，而不使用包级别的指标，
+// 用于同一进程内多个CacheManager需要分别统计的场景。多个CacheManager使用同一个reg时共用已注册的指标。
+// 指标名的前缀为WithMetricsPrefix，没有设置时为创建时的SetDefaultMetricsPrefix
+func WithRegistry(reg prometheus.Registerer) ManagerOption {
+	return func(m *CacheManager) {
+		m.metrics = promMetrics{}
+		m.ownMetrics = true
+		m.registry = reg
+	}
+}
+
+// WithMetricsPrefix 设置WithRegistry创建的指标的前缀，与WithRegistry的先后顺序无关。
+// 包级别的指标由全部CacheManager共用，不使用WithRegistry时该选项无效
+func WithMetricsPrefix(prefix string) ManagerOption {
+	return func(m *CacheManager) {
+		m.metricsPrefix = prefix
+	}
+}
+
+// initOwnMetrics 在全部选项生效后构造WithRegistry的指标
+func (i *CacheManager) initOwnMetrics() {
+	prefix := i.metricsPrefix
+	if prefix == "" {
+		prefix = loadDefaultMetricsPrefix()
+	}
+	metrics := newCacheMetrics(prefix)
+	if i.registry != nil {
+		metrics.register(i.registry)
+	}
+	i.metrics = promMetrics{metrics}
+}
+
+// promMetrics 写入prometheus指标，m为nil时使用包级别的指标
+type promMetrics struct {
+	m *cacheMetrics
+}
+
+func (p promMetrics) set() *cacheMetrics {
+	if p.m != nil {
+		return p.m
+	}
+	return defaultCacheMetrics
+}
+
+func (p promMetrics) request(namespace string, n int) {
+	p.set().requestTotal.WithLabelValues(namespace).Add(float64(n))
+}
+
+func (p promMetrics) hit(namespace string, n int) {
+	p.set().hitTotal.WithLabelValues(namespace).Add(float64(n))
+}
+
+func (p promMetrics) miss(namespace string, n int) {
+	p.set().missTotal.WithLabelValues(namespace).Add(float64(n))
+}
+
+func (p promMetrics) error(namespace string, operation string, kind string) {
+	p.set().errorTotal.WithLabelValues(namespace, operation, kind).Inc()
+}
+
+func (p promMetrics) setError(namespace string) {
+	p.set().setErrorTotal.WithLabelValues(namespace).Inc()
+}
+
+func (p promMetrics) coalesced(namespace string) {
+	p.set().coalescedTotal.WithLabelValues(namespace).Inc()
+}
+
+func (p promMetrics) inflightRejected(namespace string) {
+	p.set().inflightRejectedTotal.WithLabelValues(namespace).Inc()
+}
+
+func (p promMetrics) skippedTooLarge(namespace string) {
+	p.set().skippedTooLargeTotal.WithLabelValues(namespace).Inc()
+}
+
+func (p promMetrics) decodeRetry(namespace string, result string) {
+	p.set().decodeRetryTotal.WithLabelValues(namespace, result).Inc()
+}
+
+func (p promMetrics) fetchDuration(namespace string, d time.Duration) {
+	p.set().fetchDuration.WithLabelValues(namespace).Observe(d.Seconds())
+}
+
+func (p promMetrics) storeDuration(namespace string, d time.Duration) {
+	p.set().storeDuration.WithLabelValues(namespace).Observe(d.Seconds())
+}
+
+func (p promMetrics) valueBytes(namespace string, n int) {
+	p.set().valueBytes.WithLabelValues(namespace).Observe(float64(n))
+}
+
+func (p promMetrics) compressionDuration(namespace string, d time.Duration) {
+	p.set().compressionDuration.WithLabelValues(namespace).Observe(d.Seconds())
+}
+
+func (p promMetrics) compressionRatio(namespace string, ratio float64) {
+	p.set().compressionRatio.WithLabelValues(namespace).Observe(ratio)
+}
+
+func (p promMetrics) circuitState(name string, state CircuitState) {
+	p.set().circuitBreakerState.WithLabelValues(name).Set(float64(state))
+}
+
+// noopMetrics 不记录任何指标，方法均为空，不会产生内存分配
+type noopMetrics struct{}
+
+func (noopMetrics) request(string, int)                       {}
+func (noopMetrics) hit(string, int)                           {}
+func (noopMetrics) miss(string, int)                          {}
+func (noopMetrics) error(string, string, string)              {}
+func (noopMetrics) setError(string)                           {}
+func (noopMetrics) coalesced(string)                          {}
+func (noopMetrics) inflightRejected(string)                   {}
+func (noopMetrics) skippedTooLarge(string)                    {}
+func (noopMetrics) decodeRetry(string, string)                {}
+func (noopMetrics) fetchDuration(string, time.Duration)       {}
+func (noopMetrics) storeDuration(string, time.Duration)       {}
+func (noopMetrics) valueBytes(string, int)                    {}
+func (noopMetrics) compressionDuration(string, time.Duration) {}
+func (noopMetrics) compressionRatio(string, float64)          {}
+func (noopMetrics) circuitState(string, CircuitState)         {}
@@ -0,0 +1,117 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMetricsDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("不记录指标", func(t *testing.T) {
+		ns := "metrics_disabled"
+		cacheManager := newTestGoCacheManager(t, WithMetricsDisabled(), WithValueSizeMetrics())
+		for i := 0; i < 2; i++ {
+			_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+				return "value", nil
+			})
+		}
+
+		assert.Equal(t, float64(0), testutil.ToFloat64(CacheRequestTotal.WithLabelValues(ns)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(CacheHitTotal.WithLabelValues(ns)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(CacheMissTotal.WithLabelValues(ns)))
+		assert.Equal(t, uint64(0), histogramOf(t, CacheFetchDuration, ns).GetSampleCount())
+		assert.Equal(t, uint64(0), histogramOf(t, CacheValueBytes, ns).GetSampleCount())
+	})
+
+	t.Run("默认记录指标", func(t *testing.T) {
+		ns := "metrics_enabled"
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheRequestTotal.WithLabelValues(ns)))
+	})
+
+	t.Run("关闭时没有内存分配", func(t *testing.T) {
+		var recorder metricsRecorder = noopMetrics{}
+		allocs := testing.AllocsPerRun(100, func() {
+			recorder.request("ns", 1)
+			recorder.hit("ns", 1)
+			recorder.error("ns", opGet, errKindStore)
+			recorder.fetchDuration("ns", time.Millisecond)
+		})
+		assert.Equal(t, float64(0), allocs)
+	})
+}
+
+func TestWithRegistry(t *testing.T) {
+	ctx := context.Background()
+	ns := "with_registry"
+	get := func(cacheManager *CacheManager) {
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+	}
+
+	t.Run("每个CacheManager单独统计", func(t *testing.T) {
+		reg1, reg2 := prometheus.NewRegistry(), prometheus.NewRegistry()
+		cacheManager1 := newTestGoCacheManager(t, WithRegistry(reg1))
+		cacheManager2 := newTestGoCacheManager(t, WithRegistry(reg2))
+		get(cacheManager1)
+		get(cacheManager1)
+		get(cacheManager2)
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(cacheManager1.metrics.(promMetrics).m.requestTotal.WithLabelValues(ns)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(cacheManager2.metrics.(promMetrics).m.requestTotal.WithLabelValues(ns)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(CacheRequestTotal.WithLabelValues(ns)))
+
+		count, err := testutil.GatherAndCount(reg1, loadDefaultMetricsPrefix()+"_cache_requests_total")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("共用同一个Registerer不会panic", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		var cacheManager1, cacheManager2 *CacheManager
+		assert.NotPanics(t, func() {
+			cacheManager1 = newTestGoCacheManager(t, WithRegistry(reg))
+			cacheManager2 = newTestGoCacheManager(t, WithRegistry(reg))
+		})
+		get(cacheManager1)
+		get(cacheManager2)
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(cacheManager1.metrics.(promMetrics).m.requestTotal.WithLabelValues(ns)))
+	})
+
+	t.Run("WithMetricsPrefix设置指标前缀", func(t *testing.T) {
+		for name, opts := range map[string][]ManagerOption{
+			"前缀在后": {WithRegistry(prometheus.NewRegistry()), WithMetricsPrefix("manager_a")},
+			"前缀在前": {WithMetricsPrefix("manager_a"), WithRegistry(prometheus.NewRegistry())},
+		} {
+			t.Run(name, func(t *testing.T) {
+				cacheManager := newTestGoCacheManager(t, opts...)
+				get(cacheManager)
+				desc := cacheManager.metrics.(promMetrics).m.requestTotal.WithLabelValues(ns).Desc().String()
+				assert.Contains(t, desc, `fqName: "manager_a_cache_requests_total"`)
+			})
+		}
+	})
+
+	t.Run("命中率采样使用各自的指标", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithRegistry(prometheus.NewRegistry()), WithHitRatioSampler(10*time.Millisecond))
+		defer cacheManager.Close()
+		get(cacheManager)
+		get(cacheManager)
+
+		metrics := cacheManager.metrics.(promMetrics).m
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(metrics.hitRatio.WithLabelValues(ns)) == 0.5
+		}, time.Second, 10*time.Millisecond)
+	})
+}
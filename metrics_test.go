@@ -0,0 +1,171 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// histogramOf 读取直方图中指定namespace的采样
+func histogramOf(t *testing.T, vec *prometheus.HistogramVec, namespace string) *dto.Histogram {
+	m := &dto.Metric{}
+	require.NoError(t, vec.WithLabelValues(namespace).(prometheus.Metric).Write(m))
+	return m.GetHistogram()
+}
+
+func TestValueSizeMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("开启后记录写入的值大小", func(t *testing.T) {
+		ns := "value_size_enabled"
+		cacheManager := newTestGoCacheManager(t, WithValueSizeMetrics())
+		_, _, _ = cacheManager.Get(ctx, ns, "key", func() ([]byte, error) {
+			return []byte("12345"), nil
+		})
+
+		// 记录的是实际写入store的大小
+		stored, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey(ns, "key"))
+		h := histogramOf(t, CacheValueBytes, ns)
+		assert.Equal(t, uint64(1), h.GetSampleCount())
+		assert.Equal(t, float64(len(stored.([]byte))), h.GetSampleSum())
+	})
+
+	t.Run("默认不记录", func(t *testing.T) {
+		ns := "value_size_disabled"
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = cacheManager.Get(ctx, ns, "key", func() ([]byte, error) {
+			return []byte("12345"), nil
+		})
+
+		assert.Equal(t, uint64(0), histogramOf(t, CacheValueBytes, ns).GetSampleCount())
+	})
+}
+
+func TestLazyMetrics(t *testing.T) {
+	t.Run("创建CacheManager后指标已初始化", func(t *testing.T) {
+		_ = newTestGoCacheManager(t)
+		assert.NotNil(t, CacheRequestTotal)
+		assert.NotNil(t, CacheHitTotal)
+		assert.NotNil(t, ShadowStoreDroppedTotal)
+	})
+
+	t.Run("只初始化一次", func(t *testing.T) {
+		prefix := loadDefaultMetricsPrefix()
+		t.Cleanup(func() { SetDefaultMetricsPrefix(prefix) })
+
+		InitMetrics()
+		requestTotal := CacheRequestTotal
+		// 之后再设置前缀不影响已构造的指标
+		SetDefaultMetricsPrefix("other")
+		InitMetrics()
+		_ = newTestGoCacheManager(t)
+		assert.Same(t, requestTotal, CacheRequestTotal)
+		assert.Contains(t, CacheRequestTotal.WithLabelValues("ns").Desc().String(), `fqName: "`+prefix+`_cache_requests_total"`)
+	})
+
+	t.Run("使用传入的前缀并可以注册到Registerer", func(t *testing.T) {
+		m := newCacheMetrics("lazy")
+		shadow := newShadowMetrics("lazy")
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(append(m.collectors(), shadow.collectors()...)...)
+
+		m.requestTotal.WithLabelValues("lazy_ns").Inc()
+		shadow.droppedTotal.Inc()
+		families, err := registry.Gather()
+		require.NoError(t, err)
+		names := make([]string, 0, len(families))
+		for _, family := range families {
+			names = append(names, family.GetName())
+		}
+		assert.Contains(t, names, "lazy_cache_requests_total")
+		assert.Contains(t, names, "lazy_shadow_store_dropped_total")
+	})
+}
+
+func TestDurationMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("记录fn和写入的耗时", func(t *testing.T) {
+		ns := "duration_metrics"
+		cacheManager := newTestGoCacheManager(t)
+		for i := 0; i < 2; i++ {
+			_, _, _ = cacheManager.Get(ctx, ns, "key", func() ([]byte, error) {
+				return []byte("value"), nil
+			})
+		}
+
+		// 第二次命中缓存，只记录一次
+		assert.Equal(t, uint64(1), histogramOf(t, CacheFetchDuration, ns).GetSampleCount())
+		assert.Equal(t, uint64(1), histogramOf(t, CacheStoreDuration, ns).GetSampleCount())
+	})
+
+	t.Run("使用设置的分桶", func(t *testing.T) {
+		buckets := metricsBuckets
+		t.Cleanup(func() { SetMetricsBuckets(buckets) })
+
+		SetMetricsBuckets([]float64{0.01, 0.1, 1})
+		m := newCacheMetrics(loadDefaultMetricsPrefix())
+		m.fetchDuration.WithLabelValues("duration_buckets").Observe(0.05)
+
+		h := histogramOf(t, m.fetchDuration, "duration_buckets")
+		upperBounds := make([]float64, 0, len(h.GetBucket()))
+		for _, bucket := range h.GetBucket() {
+			upperBounds = append(upperBounds, bucket.GetUpperBound())
+		}
+		assert.Equal(t, []float64{0.01, 0.1, 1}, upperBounds)
+	})
+}
+
+func TestCacheErrorTotal(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store读取失败", func(t *testing.T) {
+		ns := "error_total_store"
+		cacheManager := NewCacheManager(&failingGetStore{StoreInterface: newGoCacheStore(), err: errors.New("connection refused")})
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheErrorTotal.WithLabelValues(ns, opGet, errKindStore)))
+	})
+
+	t.Run("反序列化失败", func(t *testing.T) {
+		ns := "error_total_unmarshal"
+		cacheManager := newTestGoCacheManager(t)
+		assert.NoError(t, SetRaw(ctx, cacheManager, ns, "key", []byte("not json")))
+		_, err, _ := Get(ctx, cacheManager, ns, "key", func() (map[string]int, error) {
+			return nil, nil
+		})
+		assert.Error(t, err)
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheErrorTotal.WithLabelValues(ns, opGet, errKindUnmarshal)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(CacheErrorTotal.WithLabelValues(ns, opGet, errKindStore)))
+	})
+
+	t.Run("序列化失败", func(t *testing.T) {
+		ns := "error_total_marshal"
+		cacheManager := newTestGoCacheManager(t)
+		_, err, _ := Get(ctx, cacheManager, ns, "key", func() (chan int, error) {
+			return make(chan int), nil
+		})
+		assert.Error(t, err)
+		assert.Equal(t, float64(1), testutil.ToFloat64(CacheErrorTotal.WithLabelValues(ns, opSet, errKindMarshal)))
+	})
+
+	t.Run("fn返回的错误不计入", func(t *testing.T) {
+		ns := "error_total_fn"
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "", errors.New("db error")
+		})
+		for _, operation := range []string{opGet, opSet} {
+			for _, kind := range []string{errKindStore, errKindMarshal, errKindUnmarshal} {
+				assert.Equal(t, float64(0), testutil.ToFloat64(CacheErrorTotal.WithLabelValues(ns, operation, kind)))
+			}
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// MGet 批量获取多个key，先一次读取全部key，再用未命中的key调用一次fn，fn返回的结果写入缓存，
+// store实现MultiSetter时不带tag的结果一次写入。已过期但因WithStaleWhileRevalidate等仍保留的值按未命中处理。
+// 返回值中hits为命中缓存的key，fn没有返回的key不会出现在values中，也不会写入缓存。
+// 相同的未命中key集合的并发调用通过singleflight合并，ctx取消时立即返回，fn在后台继续执行并写入缓存。
+// fn返回错误或panic时不写入缓存，返回已命中的值和fn的错误
+func MGet[T any](ctx context.Context, cacheManager *CacheManager, namespace string, keys []string, fn func(missing []string) (map[string]T, error), opts ...Option) (values map[string]T, err error, hits []string) {
+	if err := cacheManager.checkNamespace(ctx, namespace); err != nil {
+		return nil, err, nil
+	}
+	values = make(map[string]T, len(keys))
+	if len(keys) == 0 {
+		return values, nil, nil
+	}
+
+	keys = uniqueKeys(keys)
+	storeKeys := make([]string, len(keys))
+	for n, key := range keys {
+		storeKeys[n] = cacheManager.tenantKey(ctx, namespace, key)
+	}
+	cacheManager.metrics.request(namespace, len(keys))
+	stored, err := cacheManager.getMany(ctx, storeKeys)
+	if err != nil {
+		cacheManager.metrics.error(namespace, opGet, errKindStore)
+		return nil, err, nil
+	}
+
+	codec := cacheManager.callCodec(namespace, opts)
+	name := ""
+	if cacheManager.typeCheck {
+		name = typeName[T]()
+	}
+	version := applyOptions(opts...).version
+	var missing []string
+	for n, key := range keys {
+		if v, ok := decodeMGetValue[T](cacheManager, codec, name, version, storeKeys[n], stored[n]); ok {
+			values[key] = v
+			hits = append(hits, key)
+			continue
+		}
+		missing = append(missing, key)
+	}
+	cacheManager.metrics.hit(namespace, len(hits))
+	if len(missing) == 0 {
+		return values, nil, hits
+	}
+	cacheManager.metrics.miss(namespace, len(missing))
+
+	//合并key使用store中的key，避免不同namespace的相同key被合并
+	flightKey := make([]string, 0, len(missing))
+	for _, key := range missing {
+		flightKey = append(flightKey, cacheManager.tenantKey(ctx, namespace, key))
+	}
+	writeCtx := context.WithoutCancel(ctx)
+	ch := cacheManager.sg.DoChan("mget:"+strings.Join(flightKey, "\x00"), func() (_ interface{}, err error) {
+		//合并的计算在单独的goroutine中执行，panic转换为错误
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicError(r)
+			}
+		}()
+		loaded, err := fn(missing)
+		if err != nil {
+			return nil, err
+		}
+		writeKeys := make([]string, 0, len(loaded))
+		writeValues := make([][]byte, 0, len(loaded))
+		for _, key := range missing {
+			v, ok := loaded[key]
+			if !ok {
+				continue
+			}
+			data, err := encodeWith(codec, v)
+			if err != nil {
+				return nil, err
+			}
+			writeKeys = append(writeKeys, cacheManager.tenantKey(ctx, namespace, key))
+			writeValues = append(writeValues, data)
+		}
+		options := applyOptions(opts...)
+		options.typeName = name
+		if err := cacheManager.setBatch(writeCtx, namespace, writeKeys, writeValues, options, cacheManager.strictSet); err != nil {
+			return nil, err
+		}
+		return loaded, nil
+	})
+	var result singleflight.Result
+	select {
+	case result = <-ch:
+	case <-ctx.Done():
+		return values, ctx.Err(), hits
+	}
+	if result.Err != nil {
+		return values, result.Err, hits
+	}
+	loaded, ok := result.Val.(map[string]T)
+	if !ok {
+		return values, errors.New("result type error"), hits
+	}
+	for _, key := range missing {
+		if v, ok := loaded[key]; ok {
+			values[key] = v
+		}
+	}
+	return values, nil, hits
+}
+
+// decodeMGetValue 解码批量读取到的值，不存在、无法解码、已过期、类型或版本不匹配或是WithNegativeCache缓存的错误时按未命中处理
+func decodeMGetValue[T any](cacheManager *CacheManager, codec Codec, name string, version string, storeKey string, data any) (value T, ok bool) {
+	if data == nil {
+		return value, false
+	}
+	raw, err := toBytes(data)
+	if err != nil {
+		return value, false
+	}
+	e, err := cacheManager.decodeValue(storeKey, raw)
+	if err != nil || e.tombstone {
+		return value, false
+	}
+	//WithStaleWhileRevalidate、WithServeStaleOnError写入的值过期后仍会保留一段时间，MGet不返回旧值
+	now := cacheManager.now().UnixNano()
+	if (e.freshUntil != 0 && now > e.freshUntil) || (e.expiresAt != 0 && now > e.expiresAt) {
+		return value, false
+	}
+	if (name != "" && e.typeName != "" && e.typeName != name) || e.versionMismatch(version) {
+		return value, false
+	}
+	if err := decodeWith(codec, e.payload, &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// uniqueKeys 去除重复的key，保持原有顺序
+func uniqueKeys(keys []string) []string {
+	seen := make(map[string]struct{}, len(keys))
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, key)
+	}
+	return result
+}
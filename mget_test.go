@@ -0,0 +1,170 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMGet(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"gocache": newTestGoCacheManager(t),
+		"redis":   NewCacheManager(redisStore),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			t.Run("keys为空", func(t *testing.T) {
+				values, err, hits := MGet(ctx, cacheManager, namespace, nil, func(missing []string) (map[string]int, error) {
+					t.Fatal("fn should not be called")
+					return nil, nil
+				})
+				assert.NoError(t, err)
+				assert.Empty(t, values)
+				assert.Empty(t, hits)
+			})
+
+			t.Run("只用未命中的key调用fn", func(t *testing.T) {
+				_, _, _ = Get(ctx, cacheManager, namespace, "mget_1", func() (int, error) {
+					return 1, nil
+				})
+
+				var calls [][]string
+				values, err, hits := MGet(ctx, cacheManager, namespace, []string{"mget_1", "mget_2", "mget_3", "mget_2"}, func(missing []string) (map[string]int, error) {
+					calls = append(calls, missing)
+					//mget_3不存在，不写入缓存
+					return map[string]int{"mget_2": 2}, nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, map[string]int{"mget_1": 1, "mget_2": 2}, values)
+				assert.Equal(t, []string{"mget_1"}, hits)
+				assert.Equal(t, [][]string{{"mget_2", "mget_3"}}, calls)
+
+				values, err, hits = MGet(ctx, cacheManager, namespace, []string{"mget_1", "mget_2"}, func(missing []string) (map[string]int, error) {
+					t.Fatal("fn should not be called")
+					return nil, nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, map[string]int{"mget_1": 1, "mget_2": 2}, values)
+				assert.Equal(t, []string{"mget_1", "mget_2"}, hits)
+			})
+
+			t.Run("fn失败时返回命中的值", func(t *testing.T) {
+				fnErr := errors.New("db error")
+				values, err, hits := MGet(ctx, cacheManager, namespace, []string{"mget_1", "mget_failed"}, func(missing []string) (map[string]int, error) {
+					return map[string]int{"mget_failed": 9}, fnErr
+				})
+				assert.ErrorIs(t, err, fnErr)
+				assert.Equal(t, map[string]int{"mget_1": 1}, values)
+				assert.Equal(t, []string{"mget_1"}, hits)
+
+				_, _, cached := Get(ctx, cacheManager, namespace, "mget_failed", func() (int, error) {
+					return 0, nil
+				})
+				assert.False(t, cached)
+			})
+		})
+	}
+}
+
+func TestMGetCancel(t *testing.T) {
+	t.Run("ctx取消时不等待fn", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err, _ := MGet(ctx, cacheManager, "mget_cancel", []string{"a"}, func(missing []string) (map[string]int, error) {
+				close(started)
+				<-release
+				return map[string]int{"a": 1}, nil
+			})
+			assert.ErrorIs(t, err, context.Canceled)
+		}()
+		<-started
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("MGet没有在ctx取消后返回")
+		}
+		close(release)
+
+		//fn在后台完成后仍然写入缓存
+		assert.Eventually(t, func() bool {
+			exists, err := Exists(context.Background(), cacheManager, "mget_cancel", "a")
+			return err == nil && exists
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("fn panic时返回错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, err, _ := MGet(context.Background(), cacheManager, "mget_panic", []string{"a"}, func(missing []string) (map[string]int, error) {
+			panic("boom")
+		})
+		assert.ErrorContains(t, err, "boom")
+	})
+}
+
+// countingSetStore 记录写入的次数
+type countingSetStore struct {
+	*RedisStore
+	sets, setManys atomic.Int32
+}
+
+func (s *countingSetStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	s.sets.Add(1)
+	return s.RedisStore.Set(ctx, key, value, options...)
+}
+
+func (s *countingSetStore) SetMany(ctx context.Context, keys []string, values [][]byte, expiration time.Duration) error {
+	s.setManys.Add(1)
+	return s.RedisStore.SetMany(ctx, keys, values, expiration)
+}
+
+func TestMGetWrite(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("未命中的结果一次写入", func(t *testing.T) {
+		redisStore, _ := newTestRedisStore(t)
+		s := &countingSetStore{RedisStore: redisStore}
+		cacheManager := NewCacheManager(s)
+		_, err, _ := MGet(ctx, cacheManager, "mget_batch", []string{"a", "b", "c"}, func(missing []string) (map[string]int, error) {
+			return map[string]int{"a": 1, "b": 2, "c": 3}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), s.setManys.Load())
+		assert.Zero(t, s.sets.Load())
+
+		values, err, hits := MGet(ctx, cacheManager, "mget_batch", []string{"a", "b", "c"}, func(missing []string) (map[string]int, error) {
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, values)
+		assert.Len(t, hits, 3)
+	})
+
+	t.Run("过期后保留的旧值按未命中处理", func(t *testing.T) {
+		var now atomic.Int64
+		now.Store(time.Now().UnixNano())
+		cacheManager := newTestGoCacheManager(t, WithClock(func() time.Time { return time.Unix(0, now.Load()) }))
+		assert.NoError(t, Set(ctx, cacheManager, "mget_stale", "a", 1, WithExpiration(time.Minute), WithStaleWhileRevalidate(time.Hour)))
+		now.Add(int64(2 * time.Minute))
+
+		values, err, hits := MGet(ctx, cacheManager, "mget_stale", []string{"a"}, func(missing []string) (map[string]int, error) {
+			assert.Equal(t, []string{"a"}, missing)
+			return map[string]int{"a": 2}, nil
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, hits)
+		assert.Equal(t, map[string]int{"a": 2}, values)
+	})
+}
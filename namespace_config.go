@@ -0,0 +1,80 @@
+package cacheable
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NamespaceConfig namespace的默认配置，Get时优先级为：调用时的opts > NamespaceConfig > 全局默认值
+type NamespaceConfig struct {
+	// Expiration 调用时没有设置WithExpiration时使用，为0时使用全局默认有效期
+	Expiration time.Duration
+	// Codec 泛型Get的序列化方式，为nil时使用默认编码，修改后已有的缓存可能无法读取
+	Codec Codec
+	// Tags 追加到每次写入的tag中
+	Tags []string
+	// Compression、CompressionLevel 与SetNamespaceCompression相同
+	Compression      CompressionAlgo
+	CompressionLevel int
+}
+
+// RegisterNamespace 注册namespace的默认配置，调用时只需要传入key和fn，重复注册时覆盖之前的配置
+func (i *CacheManager) RegisterNamespace(name string, config NamespaceConfig) {
+	i.namespaces.Store(name, config)
+	i.SetNamespaceCompression(name, config.Compression, config.CompressionLevel)
+}
+
+func (i *CacheManager) namespaceConfig(name string) (NamespaceConfig, bool) {
+	config, ok := i.namespaces.Load(name)
+	if !ok {
+		return NamespaceConfig{}, false
+	}
+	return config.(NamespaceConfig), true
+}
+
+// codecFor 返回namespace使用的Codec，没有注册时使用SetDefaultCodec设置的Codec，都没有时返回nil
+func (i *CacheManager) codecFor(name string) Codec {
+	config, _ := i.namespaceConfig(name)
+	if config.Codec != nil {
+		return config.Codec
+	}
+	return loadDefaultCodec()
+}
+
+// applyNamespace 使用namespace的配置补全调用时没有设置的配置
+func (o *Options) applyNamespace(config NamespaceConfig) {
+	if o.Expiration <= 0 {
+		o.Expiration = config.Expiration
+	}
+	o.Tags = append(o.Tags[:len(o.Tags):len(o.Tags)], config.Tags...)
+}
+
+// encodeWith 使用codec序列化v，codec为nil时使用json，错误中保留原始错误
+func encodeWith[T any](codec Codec, v T) (data []byte, err error) {
+	switch codec.(type) {
+	case nil:
+		data, err = json.Marshal(v)
+	case primitiveCodec:
+		//需要知道T是否为interface，不能经过Codec.Marshal
+		data, err = marshalValue(v)
+	default:
+		data, err = codec.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cacheable: encode %s: %w", typeName[T](), err)
+	}
+	return data, nil
+}
+
+// decodeWith 使用codec反序列化到value，codec为nil时按首字节读取json或PrimitiveCodec的文本编码。
+// 编码后的值不会为空，缓存中的空值只可能来自外部写入，返回ErrEmptyValue
+func decodeWith[T any](codec Codec, data []byte, value *T) error {
+	if len(data) == 0 {
+		return ErrEmptyValue
+	}
+	if codec == nil {
+		return unmarshalValue(data, value)
+	}
+	return codec.Unmarshal(data, value)
+}
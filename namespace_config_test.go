@@ -0,0 +1,75 @@
+package cacheable
+
+import (
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterNamespace(t *testing.T) {
+	ctx := context.Background()
+	type account struct {
+		ID   int
+		Name string
+	}
+	var written Options
+	cacheManager := newTestGoCacheManager(t, WithHooks(Hooks{
+		OnSet: func(ctx context.Context, namespace string, key string, options Options) {
+			written = options
+		},
+	}))
+	cacheManager.RegisterNamespace("accounts", NamespaceConfig{
+		Expiration:       time.Minute,
+		Codec:            GobCodec,
+		Tags:             []string{"accounts"},
+		Compression:      CompressionGzip,
+		CompressionLevel: gzip.BestSpeed,
+	})
+
+	t.Run("使用namespace配置", func(t *testing.T) {
+		_, err, _ := Get(ctx, cacheManager, "accounts", "1", func() (account, error) {
+			return account{ID: 1, Name: "Alice"}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, written.Expiration)
+		assert.Equal(t, []string{"accounts"}, written.Tags)
+
+		stored, _ := cacheManager.cache.Get(ctx, cacheManager.buildKey("accounts", "1"))
+		e, err := decodeEntry(stored.([]byte))
+		assert.NoError(t, err)
+		assert.Equal(t, CompressionGzip, e.compression)
+		payload, err := decompress(e.compression, e.payload)
+		assert.NoError(t, err)
+		var decoded account
+		assert.NoError(t, GobCodec.Unmarshal(payload, &decoded))
+		assert.Equal(t, "Alice", decoded.Name)
+
+		value, err, cached := Get(ctx, cacheManager, "accounts", "1", func() (account, error) {
+			return account{}, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, account{ID: 1, Name: "Alice"}, value)
+	})
+
+	t.Run("调用时的opts优先", func(t *testing.T) {
+		_, err, _ := Get(ctx, cacheManager, "accounts", "2", func() (account, error) {
+			return account{ID: 2}, nil
+		}, WithExpiration(time.Hour), WithTags("team:1"))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, written.Expiration)
+		assert.Equal(t, []string{"accounts", "team:1"}, written.Tags)
+	})
+
+	t.Run("未注册的namespace使用全局默认值", func(t *testing.T) {
+		_, err, _ := Get(ctx, cacheManager, "unregistered", "1", func() (account, error) {
+			return account{ID: 1}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, loadDefaultExpiration(), written.Expiration)
+		assert.Empty(t, written.Tags)
+	})
+}
@@ -0,0 +1,73 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithNegativeCache fn返回ErrNotFound时缓存该结果ttl时间，有效期内的Get直接返回错误且cached为true，不再调用fn，
+// 用于避免不存在的数据每次都穿透到数据库。使用WithNegativeCacheIf可以自定义需要缓存的错误
+func WithNegativeCache(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithNegativeCacheIf 设置WithNegativeCache缓存哪些错误，默认只缓存ErrNotFound
+func WithNegativeCacheIf(fn func(err error) bool) Option {
+	return func(o *Options) {
+		o.negativeIf = fn
+	}
+}
+
+// WithCacheErrorIf 同时设置WithNegativeCache和WithNegativeCacheIf，fn返回的错误满足predicate时缓存ttl时间，
+// 有效期内的Get返回信息相同的错误且cached为true，不满足的错误（例如临时性的错误）仍然不缓存
+func WithCacheErrorIf(predicate func(err error) bool, ttl time.Duration) Option {
+	return func(o *Options) {
+		o.negativeTTL = ttl
+		o.negativeIf = predicate
+	}
+}
+
+// NegativeCacheError 命中WithNegativeCache缓存的错误时返回，Message为fn原始错误的信息。
+// 原始错误的类型无法保存，只有原始错误包装了ErrNotFound时errors.Is(err, ErrNotFound)为true
+type NegativeCacheError struct {
+	Message  string
+	notFound bool
+}
+
+func (e *NegativeCacheError) Error() string {
+	return e.Message
+}
+
+func (e *NegativeCacheError) Is(target error) bool {
+	return e.notFound && target == ErrNotFound
+}
+
+// negativeError 还原缓存的错误，原始错误就是ErrNotFound时直接返回ErrNotFound
+func negativeError(e *entry) error {
+	if e.notFound && string(e.payload) == ErrNotFound.Error() {
+		return ErrNotFound
+	}
+	return &NegativeCacheError{Message: string(e.payload), notFound: e.notFound}
+}
+
+// cacheNegative 判断fn返回的err是否需要缓存
+func (o *Options) cacheNegative(err error) bool {
+	if o.negativeTTL <= 0 || o.bypassCache {
+		return false
+	}
+	if o.negativeIf != nil {
+		return o.negativeIf(err)
+	}
+	return errors.Is(err, ErrNotFound)
+}
+
+// setTombstone 写入fn返回的错误，写入失败不影响返回fn的错误
+func (i *CacheManager) setTombstone(ctx context.Context, namespace string, key string, err error, options *Options, call *inflightCall) {
+	options.tombstone = true
+	options.notFound = errors.Is(err, ErrNotFound)
+	options.Expiration = options.negativeTTL
+	_ = i.set(ctx, namespace, key, []byte(err.Error()), options, call, false)
+}
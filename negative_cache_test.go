@@ -0,0 +1,148 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegativeCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("缓存ErrNotFound", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		calls := 0
+		fn := func() (string, error) {
+			calls++
+			return "", fmt.Errorf("user 1: %w", ErrNotFound)
+		}
+
+		_, err, cached := Get(ctx, cacheManager, namespace, "negative_user", fn, WithNegativeCache(time.Minute))
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.False(t, cached)
+
+		_, err, cached = Get(ctx, cacheManager, namespace, "negative_user", fn, WithNegativeCache(time.Minute))
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.EqualError(t, err, "user 1: not found")
+		assert.True(t, cached)
+		assert.Equal(t, 1, calls)
+
+		//不传WithNegativeCache时同样命中
+		_, err, cached = Get(ctx, cacheManager, namespace, "negative_user", fn)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.True(t, cached)
+	})
+
+	t.Run("原始错误为ErrNotFound时返回相同的错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		fn := func() ([]byte, error) {
+			return nil, ErrNotFound
+		}
+		_, _, _ = cacheManager.Get(ctx, namespace, "negative_sentinel", fn, WithNegativeCache(time.Minute))
+		_, err, cached := cacheManager.Get(ctx, namespace, "negative_sentinel", fn, WithNegativeCache(time.Minute))
+		assert.Same(t, ErrNotFound, err)
+		assert.True(t, cached)
+	})
+
+	t.Run("其他错误不缓存", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		calls := 0
+		fn := func() (string, error) {
+			calls++
+			return "", errors.New("db error")
+		}
+		_, _, _ = Get(ctx, cacheManager, namespace, "negative_other", fn, WithNegativeCache(time.Minute))
+		_, err, cached := Get(ctx, cacheManager, namespace, "negative_other", fn, WithNegativeCache(time.Minute))
+		assert.EqualError(t, err, "db error")
+		assert.False(t, cached)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("自定义需要缓存的错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		gone := errors.New("gone")
+		opts := []Option{WithNegativeCache(time.Minute), WithNegativeCacheIf(func(err error) bool {
+			return errors.Is(err, gone)
+		})}
+		_, _, _ = Get(ctx, cacheManager, namespace, "negative_custom", func() (int, error) {
+			return 0, gone
+		}, opts...)
+		_, err, cached := Get(ctx, cacheManager, namespace, "negative_custom", func() (int, error) {
+			return 1, nil
+		}, opts...)
+		var negative *NegativeCacheError
+		assert.ErrorAs(t, err, &negative)
+		assert.Equal(t, "gone", negative.Message)
+		assert.NotErrorIs(t, err, ErrNotFound)
+		assert.True(t, cached)
+	})
+
+	t.Run("WithCacheErrorIf只缓存满足条件的错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		deleted := errors.New("permanently deleted")
+		calls := 0
+		fn := func(err error) func() (string, error) {
+			return func() (string, error) {
+				calls++
+				return "", err
+			}
+		}
+		opt := WithCacheErrorIf(func(err error) bool {
+			return errors.Is(err, deleted)
+		}, time.Minute)
+
+		_, _, _ = Get(ctx, cacheManager, namespace, "cache_error_transient", fn(errors.New("status 500")), opt)
+		_, err, cached := Get(ctx, cacheManager, namespace, "cache_error_transient", fn(errors.New("status 500")), opt)
+		assert.EqualError(t, err, "status 500")
+		assert.False(t, cached)
+		assert.Equal(t, 2, calls)
+
+		_, _, _ = Get(ctx, cacheManager, namespace, "cache_error_deleted", fn(fmt.Errorf("user 1: %w", deleted)), opt)
+		_, err, cached = Get(ctx, cacheManager, namespace, "cache_error_deleted", fn(nil), opt)
+		assert.EqualError(t, err, "user 1: permanently deleted")
+		assert.NotErrorIs(t, err, ErrNotFound)
+		assert.True(t, cached)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("信息为not found的其他错误不会变为ErrNotFound", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		opt := WithCacheErrorIf(func(err error) bool { return true }, time.Minute)
+		_, _, _ = Get(ctx, cacheManager, namespace, "negative_foreign", func() (string, error) {
+			return "", errors.New("not found")
+		}, opt)
+		_, err, cached := Get(ctx, cacheManager, namespace, "negative_foreign", func() (string, error) {
+			return "value", nil
+		}, opt)
+		assert.True(t, cached)
+		assert.EqualError(t, err, "not found")
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("过期后重新调用fn", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, namespace, "negative_expire", func() (string, error) {
+			return "", ErrNotFound
+		}, WithNegativeCache(50*time.Millisecond))
+		time.Sleep(100 * time.Millisecond)
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "negative_expire", func() (string, error) {
+			return "created", nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "created", value)
+	})
+
+	t.Run("存储格式与正常值区分", func(t *testing.T) {
+		stored, err := (&CacheManager{}).encodeTombstone("key", []byte(`"value"`), "", "", false)
+		assert.NoError(t, err)
+		e, err := decodeEntry(stored)
+		assert.NoError(t, err)
+		assert.True(t, e.tombstone)
+	})
+}
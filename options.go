@@ -1,12 +1,66 @@
 package cacheable
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"slices"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
 
 type Option func(o *Options)
 
 type Options struct {
-	Expiration time.Duration
-	Tags       []string
+	Expiration        time.Duration
+	SlidingExpiration time.Duration
+	Tags              []string
+
+	// WriteLock 写锁名称，见WithWriteLock
+	WriteLock string
+	// NoCache 不写入缓存，见WithNoCache
+	NoCache bool
+
+	dynamicTags  []func() []string
+	tagNamespace string
+	// typeName 泛型Get的目标类型，开启WithTypeCheck时写入缓存并在读取时校验
+	typeName string
+	// directive fn返回的CacheDirective，见GetWithDirective
+	directive *CacheDirective
+	// mergeInto GetInto反序列化时保留dst原有的字段
+	mergeInto bool
+	// negativeTTL、negativeIf 缓存fn返回的错误，见WithNegativeCache
+	negativeTTL time.Duration
+	negativeIf  func(err error) bool
+	// expirationJitter 有效期的最大随机时间，见WithExpirationJitter
+	expirationJitter time.Duration
+	// staleFor 过期后仍可以返回旧值的时间，见WithStaleWhileRevalidate
+	staleFor time.Duration
+	// staleOnError 过期后保留旧值的时间，fn失败时返回旧值，见WithServeStaleOnError
+	staleOnError time.Duration
+	// refreshAhead 剩余有效期低于该比例时后台刷新，见WithRefreshAhead
+	refreshAhead float64
+	// tombstone 写入的是fn返回的错误，notFound表示该错误包装了ErrNotFound
+	tombstone bool
+	notFound  bool
+	// compression、compressionMinSize 见WithCompression、WithCompressionMinSize
+	compression        *compressionConfig
+	compressionMinSize int
+	// codec 泛型Get的序列化方式，见WithCodec
+	codec Codec
+	// fnCtx 传给fn的context，fn返回时已取消则不写入缓存，见GetWithContext
+	fnCtx context.Context
+	// fetchTimeout fn的最长执行时间，见WithFetchTimeout
+	fetchTimeout time.Duration
+	// version 缓存值的版本，见WithVersion
+	version string
+	// bypassCache、readOnly 见WithBypassCache、WithReadOnly
+	bypassCache bool
+	readOnly    bool
+	// ifAbsent 只在key不存在时写入，见SetIfAbsent
+	ifAbsent bool
 }
 
 func applyOptions(opts ...Option) *Options {
@@ -28,12 +82,255 @@ func WithTags(tags ...string) Option {
 // WithDynamicTags 动态添加tags，适合计算tag需要做耗时操作的场景，仅在set缓存时进行tag计算
 func WithDynamicTags(fn func() []string) Option {
 	return func(o *Options) {
-		o.Tags = append(o.Tags, fn()...)
+		o.dynamicTags = append(o.dynamicTags, fn)
+	}
+}
+
+// resolveTags 合并静态tag和动态tag，动态tag在这里才真正计算，结果排序并去重，同一组tag总是以相同的顺序写入store。
+// 动态tag函数panic时跳过该函数的tag，返回ErrTagFuncPanic
+func (o *Options) resolveTags() ([]string, error) {
+	tags := slices.Clone(o.Tags)
+	var errs []error
+	for _, fn := range o.dynamicTags {
+		dynamicTags, err := callTagFunc(fn)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tags = append(tags, dynamicTags...)
+	}
+	if o.tagNamespace != "" {
+		tags = namespaceTags(o.tagNamespace, tags)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags), errors.Join(errs...)
+}
+
+// callTagFunc 调用用户提供的tag函数，将panic转换为错误，避免中断写入流程
+func callTagFunc(fn func() []string) (tags []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrTagFuncPanic, r)
+		}
+	}()
+	return fn(), nil
+}
+
+// WithWriteLock 缓存未命中时，在持有名为lockName的分布式写锁期间执行fn并写入缓存，
+// 使用同一个锁的多个key的计算和写入互斥，适合依赖其他缓存计算的聚合数据，避免读取到更新了一半的依赖。
+// 锁带有过期时间，持有者崩溃后自动释放，store实现LockExtender时持有期间会定期续期，
+// 写入前发现锁已经丢失时不写入并返回ErrLockLost。需要store实现Locker，否则返回ErrNotSupported
+func WithWriteLock(lockName string) Option {
+	return func(o *Options) {
+		o.WriteLock = lockName
+	}
+}
+
+// resolve 返回写入时最终生效的配置：未设置有效期时使用默认有效期，计算动态tag。
+// 动态tag计算失败时仍然返回不包含这些tag的配置
+func (o *Options) resolve() (Options, error) {
+	resolved := *o
+	if resolved.Expiration <= 0 {
+		resolved.Expiration = loadDefaultExpiration()
+	}
+	tags, err := o.resolveTags()
+	resolved.Tags = tags
+	resolved.dynamicTags = nil
+	return resolved, err
+}
+
+// storeOptions 将生效的配置转换为store.Option
+func (o *Options) storeOptions() []store.Option {
+	setOptions := []store.Option{store.WithExpiration(o.Expiration)}
+	if len(o.Tags) > 0 {
+		setOptions = append(setOptions, store.WithTags(o.Tags))
+	}
+	return setOptions
+}
+
+// withTagNamespace 给写入的tag加上namespace前缀，用于TypedCache
+func withTagNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.tagNamespace = namespace
 	}
 }
 
+// withTypeName 设置泛型Get的目标类型名称
+func withTypeName(typeName string) Option {
+	return func(o *Options) {
+		o.typeName = typeName
+	}
+}
+
+func namespaceTags(namespace string, tags []string) []string {
+	result := make([]string, len(tags))
+	for i, tag := range tags {
+		result[i] = namespace + ":" + tag
+	}
+	return result
+}
+
+// WithExpiration 设置缓存有效期，0或负数表示使用默认有效期（见SetDefaultExpiration），不写入缓存使用WithNoCache
 func WithExpiration(expiration time.Duration) Option {
 	return func(o *Options) {
 		o.Expiration = expiration
 	}
 }
+
+// WithNoCache 缓存未命中时只调用fn返回结果，不写入缓存，已有的缓存仍然会被读取。
+// 并发请求仍然会通过singleflight合并
+func WithNoCache() Option {
+	return func(o *Options) {
+		o.NoCache = true
+	}
+}
+
+// WithMergeInto GetInto反序列化前不重置dst，缓存值中没有的字段保留dst原有的值，只在需要合并语义时使用
+func WithMergeInto() Option {
+	return func(o *Options) {
+		o.mergeInto = true
+	}
+}
+
+// WithSlidingExpiration 滑动过期，每次命中缓存时将有效期重新延长为window，适合session一类的数据。
+// store实现TTLUpdater时直接修改有效期（例如redis的EXPIRE），否则回退为读取后重新写入
+func WithSlidingExpiration(window time.Duration) Option {
+	return func(o *Options) {
+		o.SlidingExpiration = window
+	}
+}
+
+// ManagerOption 用于在创建CacheManager时进行配置
+type ManagerOption func(m *CacheManager)
+
+// WithAsyncUnlink DeleteByTags时使用UNLINK异步删除key，由redis在后台回收内存，避免大tag集合删除时阻塞服务端。
+// 需要store实现TagUnlinker（例如RedisStore），其他store会回退到同步删除
+func WithAsyncUnlink() ManagerOption {
+	return func(m *CacheManager) {
+		m.asyncUnlink = true
+	}
+}
+
+// WithNoCacheOnTagPanic 动态tag函数panic时不写入缓存，默认会写入不包含这些tag的缓存。
+// 两种情况下panic都会转换为ErrTagFuncPanic上报给Hooks.OnError，请求本身正常返回
+func WithNoCacheOnTagPanic() ManagerOption {
+	return func(m *CacheManager) {
+		m.noCacheOnTagPanic = true
+	}
+}
+
+// WithTypeCheck 泛型Get写入缓存时记录T的类型名称，读取时类型名称与当前的T不一致则视为缓存不存在，重新计算并覆盖，
+// 避免类型在不同版本间发生变化后静默地反序列化为错误的结构。没有记录类型名称的缓存（关闭时或旧版本写入）不做校验
+func WithTypeCheck() ManagerOption {
+	return func(m *CacheManager) {
+		m.typeCheck = true
+	}
+}
+
+// WithStrictSet 缓存未命中后写入store失败时向调用方返回错误。默认只记录错误并返回计算结果，
+// 避免store的大小或类型限制导致读取失败
+func WithStrictSet() ManagerOption {
+	return func(m *CacheManager) {
+		m.strictSet = true
+	}
+}
+
+// WithKeyPrefix 设置CacheManager使用的key前缀，不影响全局的默认前缀，
+// 适用于同一个进程中的多个应用共用一个store，各自使用不同的前缀
+func WithKeyPrefix(prefix string) ManagerOption {
+	return func(m *CacheManager) {
+		m.prefix = prefix
+	}
+}
+
+// WithKeyBuilder 设置拼接store key的函数，默认为prefix:namespace:key，例如redis集群可以使用{namespace}:key让同一个namespace落在同一个slot。
+// prefix已包含纪元，读取、写入和删除使用相同的函数。WithAutoHashKeyAbove哈希后的key以h:<哈希>的形式传入。
+// Export、Entries和ClearNamespace通过fn(prefix, namespace, "")枚举namespace，需要保证它是该namespace下全部key的前缀
+func WithKeyBuilder(fn func(prefix, namespace, key string) string) ManagerOption {
+	return func(m *CacheManager) {
+		m.keyBuilder = fn
+	}
+}
+
+// WithNamespaceHashTag 将namespace放在redis集群的hash tag中，key形如prefix:{namespace}:key，同一个namespace的key落在同一个slot，
+// 可以对它们使用多key命令。与WithKeyBuilder同时使用时，传给fn的namespace已带有花括号。前缀中不能包含花括号，否则hash tag以前缀中的为准。
+// tag集合由gocache保存在gocache_tag_<tag>中，与namespace不在同一个slot，RedisStore逐个key删除不会产生CROSSSLOT错误；
+// 需要tag集合也落在同一个slot时，可以在tag中使用相同的hash tag，例如WithTags("{users}:vip")
+func WithNamespaceHashTag() ManagerOption {
+	return func(m *CacheManager) {
+		m.namespaceHashTag = true
+	}
+}
+
+// WithDefaultExpiration 设置CacheManager的默认有效期，调用时没有设置WithExpiration且namespace没有配置有效期时使用，
+// 不影响全局的默认有效期，没有设置时使用SetDefaultExpiration的值
+func WithDefaultExpiration(expiration time.Duration) ManagerOption {
+	return func(m *CacheManager) {
+		m.expiration = expiration
+	}
+}
+
+// WithAutoHashKeyAbove 完整的key（包括前缀和namespace）长度超过n时，将key部分替换为其sha256，
+// 结果形如prefix:namespace:h:<sha256>，保留前缀和namespace便于排查。读取、写入和删除使用相同的规则，
+// 同一个key总是对应同一个store key
+func WithAutoHashKeyAbove(n int) ManagerOption {
+	return func(m *CacheManager) {
+		m.autoHashKeyAbove = n
+	}
+}
+
+// WithKeyHashFunc 设置WithAutoHashKeyAbove使用的哈希函数，默认为sha256。
+// 内部的key不需要抵御碰撞攻击时可以使用xxhash等非加密哈希降低开销，修改后已有的哈希key全部失效
+func WithKeyHashFunc(newHash func() hash.Hash) ManagerOption {
+	return func(m *CacheManager) {
+		m.keyHashFunc = newHash
+	}
+}
+
+// WithValueSizeMetrics 记录写入store的值大小到cache_value_bytes直方图，直方图有一定开销，默认关闭
+func WithValueSizeMetrics() ManagerOption {
+	return func(m *CacheManager) {
+		m.valueSizeMetrics = true
+	}
+}
+
+// WithMaxValueSize 序列化后的值超过n字节时不写入缓存，Get仍然返回fn的结果，并记录到cache_skipped_too_large_total，
+// 避免异常的大结果占满store挤掉其他缓存。大小按压缩、加密之前计算。没有写入时会删除key已有的缓存，
+// 避免Refresh或后台刷新之后继续读到旧值。Set、SetRaw和SetIfAbsent返回ErrValueTooLarge，SetIfAbsent不会删除已有的缓存
+func WithMaxValueSize(n int) ManagerOption {
+	return func(m *CacheManager) {
+		m.maxValueSize = n
+	}
+}
+
+// valueTooLarge 判断value是否超过WithMaxValueSize的限制，超过时记录指标
+func (i *CacheManager) valueTooLarge(namespace string, value []byte) bool {
+	if i.maxValueSize <= 0 || len(value) <= i.maxValueSize {
+		return false
+	}
+	i.metrics.skippedTooLarge(namespace)
+	return true
+}
+
+// WithInflightInvalidation 跟踪正在计算中的key，计算期间如果收到同一个key的Delete或结果所带tag的DeleteByTags，
+// 计算结果仍会返回给调用方，但不会留在缓存中，避免Delete之后又写入了过时的数据
+func WithInflightInvalidation() ManagerOption {
+	return func(m *CacheManager) {
+		m.trackInflight = true
+	}
+}
+
+// WithEntryAge 在缓存头部记录写入时间，GetWithMeta返回的Age依赖该时间。默认只在已经需要写入头部时
+// （例如开启了压缩、加密、WithTypeCheck等）记录，避免仅为了写入时间改变存储格式，见README中的存储格式说明
+func WithEntryAge() ManagerOption {
+	return func(m *CacheManager) {
+		m.entryAge = true
+	}
+}
+
+// WithClock 替换获取当前时间的函数，主要用于测试
+func WithClock(clock func() time.Time) ManagerOption {
+	return func(m *CacheManager) {
+		m.clock = clock
+	}
+}
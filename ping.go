@@ -0,0 +1,36 @@
+package cacheable
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// defaultPingTimeout Ping的最长耗时，ctx的截止时间更早时以ctx为准
+var defaultPingTimeout = 2 * time.Second
+
+var pingValue = []byte("pong")
+
+// Ping 检查store是否可用，用于就绪探针。store实现Pinger时直接调用，否则写入并读取一个哨兵key（前缀:ping）
+func (i *CacheManager) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultPingTimeout)
+	defer cancel()
+
+	if pinger, ok := i.cache.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	key := i.keyPrefix() + ":ping"
+	if err := i.cache.Set(ctx, key, pingValue, store.WithExpiration(time.Minute)); err != nil {
+		return err
+	}
+	data, err := i.cache.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if stored, err := toBytes(data); err != nil || !bytes.Equal(stored, pingValue) {
+		return errPingMismatch
+	}
+	return nil
+}
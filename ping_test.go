@@ -0,0 +1,39 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("go-cache", func(t *testing.T) {
+		assert.NoError(t, NewCacheManager(newGoCacheStore()).Ping(ctx))
+	})
+
+	t.Run("store读取失败", func(t *testing.T) {
+		storeErr := errors.New("connection refused")
+		cacheManager := NewCacheManager(&failingGetStore{StoreInterface: newGoCacheStore(), err: storeErr})
+		assert.ErrorIs(t, cacheManager.Ping(ctx), storeErr)
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		redisStore, mr := newTestRedisStore(t)
+		cacheManager := NewCacheManager(redisStore)
+		assert.NoError(t, cacheManager.Ping(ctx))
+
+		mr.Close()
+		assert.Error(t, cacheManager.Ping(ctx))
+	})
+
+	t.Run("ctx已取消", func(t *testing.T) {
+		redisStore, _ := newTestRedisStore(t)
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, NewCacheManager(redisStore).Ping(ctx), context.Canceled)
+	})
+}
@@ -0,0 +1,138 @@
+package cacheable
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// primitiveMarker 基础类型使用文本编码时的首字节，json不会以0x00开头，据此区分两种编码，
+// 以json写入的基础类型缓存仍然可以正常读取
+const primitiveMarker = 0x00
+
+// primitiveCodec 见PrimitiveCodec
+type primitiveCodec struct{}
+
+func (primitiveCodec) Marshal(v any) ([]byte, error) {
+	return marshalPrimitive(v)
+}
+
+func (primitiveCodec) Unmarshal(data []byte, v any) error {
+	return unmarshalValue(data, v)
+}
+
+// marshalValue 使用PrimitiveCodec序列化fn返回的值，T为interface时使用json，读取到interface时无法还原文本编码的类型
+func marshalValue[T any](value T) ([]byte, error) {
+	if reflect.TypeFor[T]().Kind() == reflect.Interface {
+		return json.Marshal(value)
+	}
+	return marshalPrimitive(value)
+}
+
+// marshalPrimitive string、bool、数值和[]byte使用文本编码，跳过json
+func marshalPrimitive(value any) ([]byte, error) {
+	var buf []byte
+	switch v := value.(type) {
+	case string:
+		buf = append(append(make([]byte, 0, 1+len(v)), primitiveMarker), v...)
+	case []byte:
+		buf = append(append(make([]byte, 0, 1+len(v)), primitiveMarker), v...)
+	case bool:
+		buf = strconv.AppendBool(primitiveBuf(), v)
+	case int:
+		buf = strconv.AppendInt(primitiveBuf(), int64(v), 10)
+	case int8:
+		buf = strconv.AppendInt(primitiveBuf(), int64(v), 10)
+	case int16:
+		buf = strconv.AppendInt(primitiveBuf(), int64(v), 10)
+	case int32:
+		buf = strconv.AppendInt(primitiveBuf(), int64(v), 10)
+	case int64:
+		buf = strconv.AppendInt(primitiveBuf(), v, 10)
+	case uint:
+		buf = strconv.AppendUint(primitiveBuf(), uint64(v), 10)
+	case uint8:
+		buf = strconv.AppendUint(primitiveBuf(), uint64(v), 10)
+	case uint16:
+		buf = strconv.AppendUint(primitiveBuf(), uint64(v), 10)
+	case uint32:
+		buf = strconv.AppendUint(primitiveBuf(), uint64(v), 10)
+	case uint64:
+		buf = strconv.AppendUint(primitiveBuf(), v, 10)
+	case float32:
+		buf = strconv.AppendFloat(primitiveBuf(), float64(v), 'g', -1, 32)
+	case float64:
+		buf = strconv.AppendFloat(primitiveBuf(), v, 'g', -1, 64)
+	default:
+		return json.Marshal(value)
+	}
+	return buf, nil
+}
+
+// primitiveBuf 返回以primitiveMarker开头、容量足够写入数值的buffer
+func primitiveBuf() []byte {
+	return append(make([]byte, 0, 24), primitiveMarker)
+}
+
+// unmarshalValue 反序列化缓存的值到指针value，根据首字节判断是文本编码还是json
+func unmarshalValue(data []byte, value any) error {
+	if len(data) == 0 || data[0] != primitiveMarker {
+		return json.Unmarshal(data, value)
+	}
+
+	text := data[1:]
+	var err error
+	switch p := any(value).(type) {
+	case *string:
+		*p = string(text)
+	case *[]byte:
+		*p = append([]byte{}, text...)
+	case *bool:
+		*p, err = strconv.ParseBool(string(text))
+	case *int:
+		var n int64
+		n, err = strconv.ParseInt(string(text), 10, strconv.IntSize)
+		*p = int(n)
+	case *int8:
+		var n int64
+		n, err = strconv.ParseInt(string(text), 10, 8)
+		*p = int8(n)
+	case *int16:
+		var n int64
+		n, err = strconv.ParseInt(string(text), 10, 16)
+		*p = int16(n)
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(string(text), 10, 32)
+		*p = int32(n)
+	case *int64:
+		*p, err = strconv.ParseInt(string(text), 10, 64)
+	case *uint:
+		var n uint64
+		n, err = strconv.ParseUint(string(text), 10, strconv.IntSize)
+		*p = uint(n)
+	case *uint8:
+		var n uint64
+		n, err = strconv.ParseUint(string(text), 10, 8)
+		*p = uint8(n)
+	case *uint16:
+		var n uint64
+		n, err = strconv.ParseUint(string(text), 10, 16)
+		*p = uint16(n)
+	case *uint32:
+		var n uint64
+		n, err = strconv.ParseUint(string(text), 10, 32)
+		*p = uint32(n)
+	case *uint64:
+		*p, err = strconv.ParseUint(string(text), 10, 64)
+	case *float32:
+		var f float64
+		f, err = strconv.ParseFloat(string(text), 32)
+		*p = float32(f)
+	case *float64:
+		*p, err = strconv.ParseFloat(string(text), 64)
+	default:
+		return errUnexpectedPrimitive
+	}
+	return err
+}
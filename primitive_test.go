@@ -0,0 +1,146 @@
+package cacheable
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTrip 通过缓存使用PrimitiveCodec写入并读取value，返回命中缓存时读取到的值
+func roundTrip[T any](t *testing.T, key string, value T) T {
+	ctx := context.Background()
+	_, err, _ := Get(ctx, MockCacheManager, namespace, key, func() (T, error) {
+		return value, nil
+	}, WithCodec(PrimitiveCodec))
+	assert.NoError(t, err)
+
+	var zero T
+	got, err, cached := Get(ctx, MockCacheManager, namespace, key, func() (T, error) {
+		return zero, nil
+	}, WithCodec(PrimitiveCodec))
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	return got
+}
+
+func TestPrimitiveRoundTrip(t *testing.T) {
+	assert.Equal(t, "hello \"world\"\n", roundTrip(t, "primitive_string", "hello \"world\"\n"))
+	assert.Equal(t, []byte{0, 1, 2, 255}, roundTrip(t, "primitive_bytes", []byte{0, 1, 2, 255}))
+	assert.Equal(t, true, roundTrip(t, "primitive_bool", true))
+	assert.Equal(t, math.MinInt, roundTrip(t, "primitive_int", math.MinInt))
+	assert.Equal(t, int8(math.MinInt8), roundTrip(t, "primitive_int8", int8(math.MinInt8)))
+	assert.Equal(t, int16(math.MaxInt16), roundTrip(t, "primitive_int16", int16(math.MaxInt16)))
+	assert.Equal(t, int32(math.MinInt32), roundTrip(t, "primitive_int32", int32(math.MinInt32)))
+	assert.Equal(t, int64(math.MaxInt64), roundTrip(t, "primitive_int64", int64(math.MaxInt64)))
+	assert.Equal(t, uint(math.MaxUint), roundTrip(t, "primitive_uint", uint(math.MaxUint)))
+	assert.Equal(t, uint8(math.MaxUint8), roundTrip(t, "primitive_uint8", uint8(math.MaxUint8)))
+	assert.Equal(t, uint16(math.MaxUint16), roundTrip(t, "primitive_uint16", uint16(math.MaxUint16)))
+	assert.Equal(t, uint32(math.MaxUint32), roundTrip(t, "primitive_uint32", uint32(math.MaxUint32)))
+	assert.Equal(t, uint64(math.MaxUint64), roundTrip(t, "primitive_uint64", uint64(math.MaxUint64)))
+	assert.Equal(t, float32(math.SmallestNonzeroFloat32), roundTrip(t, "primitive_float32", float32(math.SmallestNonzeroFloat32)))
+	assert.Equal(t, math.Pi, roundTrip(t, "primitive_float64", math.Pi))
+
+	// interface类型仍然使用json
+	assert.Equal(t, any("value"), roundTrip[any](t, "primitive_any", "value"))
+}
+
+func TestPrimitiveCompatibility(t *testing.T) {
+	// 升级前以json写入的基础类型仍然可以读取
+	var s string
+	assert.NoError(t, unmarshalValue([]byte(`"legacy"`), &s))
+	assert.Equal(t, "legacy", s)
+
+	var n int
+	assert.NoError(t, unmarshalValue([]byte(`42`), &n))
+	assert.Equal(t, 42, n)
+
+	data, err := marshalValue("value")
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{primitiveMarker}, "value"...), data)
+}
+
+func TestPrimitiveCodecOptIn(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+
+	t.Run("默认使用json写入", func(t *testing.T) {
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "primitive_default", "value"))
+		data, err, _ := GetRaw(ctx, cacheManager, namespace, "primitive_default", func() ([]byte, error) {
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, `"value"`, string(data))
+	})
+
+	t.Run("默认编码可以读取PrimitiveCodec写入的值", func(t *testing.T) {
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "primitive_opt_in", 42, WithCodec(PrimitiveCodec)))
+		value, err, cached := Get(ctx, cacheManager, namespace, "primitive_opt_in", func() (int, error) {
+			return 0, nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("直接调用Codec", func(t *testing.T) {
+		data, err := PrimitiveCodec.Marshal(true)
+		assert.NoError(t, err)
+		var v bool
+		assert.NoError(t, PrimitiveCodec.Unmarshal(data, &v))
+		assert.True(t, v)
+	})
+}
+
+func BenchmarkMarshalPrimitive(b *testing.B) {
+	b.Run("string/primitive", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			data, _ := marshalValue("feature-flag-value")
+			var s string
+			_ = unmarshalValue(data, &s)
+		}
+	})
+	b.Run("string/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			data, _ := json.Marshal("feature-flag-value")
+			var s string
+			_ = json.Unmarshal(data, &s)
+		}
+	})
+	b.Run("int/primitive", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			data, _ := marshalValue(123456789)
+			var i int
+			_ = unmarshalValue(data, &i)
+		}
+	})
+	b.Run("int/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			data, _ := json.Marshal(123456789)
+			var i int
+			_ = json.Unmarshal(data, &i)
+		}
+	})
+	b.Run("bool/primitive", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			data, _ := marshalValue(true)
+			var v bool
+			_ = unmarshalValue(data, &v)
+		}
+	})
+	b.Run("bool/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			data, _ := json.Marshal(true)
+			var v bool
+			_ = json.Unmarshal(data, &v)
+		}
+	})
+}
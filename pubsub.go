@@ -0,0 +1,97 @@
+package cacheable
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// PubSub 用于在多个实例之间广播删除消息，RedisPubSub是基于redis的实现
+type PubSub interface {
+	// Publish 发送消息，所有订阅者（包括发送者自己）都会收到
+	Publish(ctx context.Context, message []byte) error
+	// Subscribe 开始接收消息，每条消息调用一次handler，返回的函数用于停止接收
+	Subscribe(handler func(message []byte)) (unsubscribe func())
+}
+
+// invalidationMessage 广播的删除消息，Keys为store中的完整key
+type invalidationMessage struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// WithInvalidationChannel Delete、DeleteByTags等删除成功后通过pubsub广播删除的key或tag，
+// 其他实例收到后删除本地的副本：store为TieredStore时只删除一级缓存，否则删除整个store，
+// 用于多个实例各自使用本地缓存时及时清除其他实例的旧值。从二级缓存回填的本地缓存没有记录tag，
+// DeleteByTags会在删除前读取tag关联的key一起广播，需要二级缓存支持读取tag，否则回填的副本只能等待过期。
+// 广播失败不影响删除结果，通过Hooks.OnError上报。
+// 需要调用Close停止订阅
+func WithInvalidationChannel(pubSub PubSub) ManagerOption {
+	return func(m *CacheManager) {
+		m.pubSub = pubSub
+	}
+}
+
+func (i *CacheManager) subscribeInvalidations() {
+	id, err := newLockToken()
+	if err != nil {
+		panic(err)
+	}
+	i.instanceID = id
+	i.unsubscribe = i.pubSub.Subscribe(func(message []byte) {
+		i.handleInvalidation(context.Background(), message)
+	})
+}
+
+func (i *CacheManager) publishInvalidation(ctx context.Context, msg invalidationMessage) {
+	if i.pubSub == nil {
+		return
+	}
+	msg.Origin = i.instanceID
+	data, err := json.Marshal(msg)
+	if err == nil {
+		err = i.pubSub.Publish(ctx, data)
+	}
+	if err != nil {
+		i.reportError(ctx, "", "", err)
+	}
+}
+
+// handleInvalidation 删除其他实例广播的key和tag在本地的副本，忽略自己发送的消息
+func (i *CacheManager) handleInvalidation(ctx context.Context, data []byte) {
+	var msg invalidationMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		i.reportError(ctx, "", "", err)
+		return
+	}
+	if msg.Origin == i.instanceID {
+		return
+	}
+
+	local := i.cache
+	if tiered, ok := local.(*TieredStore); ok {
+		local = tiered.l1
+	}
+	for _, key := range msg.Keys {
+		if i.trackInflight {
+			i.markInflightDeleted(key)
+		}
+		if err := local.Delete(ctx, key); err != nil {
+			i.reportError(ctx, "", key, err)
+		}
+	}
+	if len(msg.Tags) > 0 {
+		var err error
+		if local != i.cache {
+			i.markInflightTagsDeleted(msg.Tags)
+			err = local.Invalidate(ctx, store.WithInvalidateTags(i.expandTagShards(msg.Tags)))
+		} else {
+			err = i.deleteByTags(ctx, msg.Tags)
+		}
+		if err != nil {
+			i.reportError(ctx, "", "", err)
+		}
+	}
+}
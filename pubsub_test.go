@@ -0,0 +1,114 @@
+package cacheable
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryPubSub 在进程内同步投递消息
+type memoryPubSub struct {
+	mu       sync.Mutex
+	handlers map[int]func(message []byte)
+	next     int
+}
+
+func newMemoryPubSub() *memoryPubSub {
+	return &memoryPubSub{handlers: make(map[int]func(message []byte))}
+}
+
+func (p *memoryPubSub) Publish(_ context.Context, message []byte) error {
+	p.mu.Lock()
+	handlers := make([]func(message []byte), 0, len(p.handlers))
+	for _, handler := range p.handlers {
+		handlers = append(handlers, handler)
+	}
+	p.mu.Unlock()
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (p *memoryPubSub) Subscribe(handler func(message []byte)) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := p.next
+	p.next++
+	p.handlers[id] = handler
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.handlers, id)
+	}
+}
+
+func TestWithInvalidationChannel(t *testing.T) {
+	ctx := context.Background()
+	l2, _ := newTestRedisStore(t)
+	pubSub := newMemoryPubSub()
+	l1a, l1b := newGoCacheStore(), newGoCacheStore()
+	instanceA := NewTieredCacheManager(l1a, l2, WithInvalidationChannel(pubSub))
+	instanceB := NewTieredCacheManager(l1b, l2, WithInvalidationChannel(pubSub))
+	t.Cleanup(func() {
+		_ = instanceA.Close()
+		_ = instanceB.Close()
+	})
+	fill := func(key string, opts ...Option) {
+		for _, cacheManager := range []*CacheManager{instanceA, instanceB} {
+			_, _, _ = Get(ctx, cacheManager, namespace, key, func() (string, error) {
+				return "value", nil
+			}, opts...)
+		}
+		_, err := l1b.Get(ctx, instanceB.buildKey(namespace, key))
+		assert.NoError(t, err)
+	}
+
+	t.Run("删除key时清除其他实例的本地缓存", func(t *testing.T) {
+		fill("pubsub_key")
+		assert.NoError(t, Delete(ctx, instanceA, namespace, "pubsub_key"))
+		_, err := l1b.Get(ctx, instanceB.buildKey(namespace, "pubsub_key"))
+		assert.Error(t, err)
+	})
+
+	t.Run("删除tag时清除其他实例的本地缓存", func(t *testing.T) {
+		fill("pubsub_tagged", WithTags("pubsub_tag"))
+		assert.NoError(t, DeleteByTags(ctx, instanceA, []string{"pubsub_tag"}))
+		_, err := l1b.Get(ctx, instanceB.buildKey(namespace, "pubsub_tagged"))
+		assert.Error(t, err)
+	})
+
+	t.Run("关闭后不再接收", func(t *testing.T) {
+		fill("pubsub_closed")
+		assert.NoError(t, instanceB.Close())
+		assert.NoError(t, Delete(ctx, instanceA, namespace, "pubsub_closed"))
+		_, err := l1b.Get(ctx, instanceB.buildKey(namespace, "pubsub_closed"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestRedisPubSub(t *testing.T) {
+	ctx := context.Background()
+	_, mr := newTestRedisStore(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	pubSub := NewRedisPubSub(client, "cacheable:invalidation")
+
+	received := make(chan string, 1)
+	unsubscribe := pubSub.Subscribe(func(message []byte) {
+		received <- string(message)
+	})
+	defer unsubscribe()
+
+	assert.NoError(t, pubSub.Publish(ctx, []byte("hello")))
+	select {
+	case message := <-received:
+		assert.Equal(t, "hello", message)
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+}
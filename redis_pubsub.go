@@ -0,0 +1,37 @@
+package cacheable
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPubSub 基于redis的PUBLISH和SUBSCRIBE实现PubSub，所有实例需要使用相同的channel
+type RedisPubSub struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+func NewRedisPubSub(client redis.UniversalClient, channel string) *RedisPubSub {
+	return &RedisPubSub{client: client, channel: channel}
+}
+
+func (p *RedisPubSub) Publish(ctx context.Context, message []byte) error {
+	return p.client.Publish(ctx, p.channel, message).Err()
+}
+
+// Subscribe 在后台接收消息，连接断开时go-redis会自动重连，断开期间的消息会丢失
+func (p *RedisPubSub) Subscribe(handler func(message []byte)) func() {
+	sub := p.client.Subscribe(context.Background(), p.channel)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	}()
+	return func() {
+		_ = sub.Close()
+		<-done
+	}
+}
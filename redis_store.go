@@ -0,0 +1,276 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	lib_store "github.com/eko/gocache/lib/v4/store"
+	redis_store "github.com/eko/gocache/store/redis/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore 在gocache的redis store基础上补充了redis原生的能力（UNLINK等），
+// 读写和tag的存储格式与gocache保持一致，可以直接替换redis_store.NewRedis
+type RedisStore struct {
+	*redis_store.RedisStore
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient, options ...lib_store.Option) *RedisStore {
+	return &RedisStore{
+		RedisStore: redis_store.NewRedis(client, options...),
+		client:     client,
+	}
+}
+
+// UnlinkTags 使用SSCAN分批读取tag集合中的key，使用UNLINK异步删除这些key以及tag集合本身，
+// 大的tag集合不会阻塞redis，也不会产生过大的pipeline
+func (s *RedisStore) UnlinkTags(ctx context.Context, tags []string) error {
+	for _, tag := range tags {
+		tagKey := fmt.Sprintf(redis_store.RedisTagPattern, tag)
+		err := s.scanTag(ctx, tagKey, func(cacheKeys []string) error {
+			//集群模式下多个key可能不在同一个slot，这里使用pipeline逐个UNLINK，避免CROSSSLOT错误
+			pipe := s.client.Pipeline()
+			for _, cacheKey := range cacheKeys {
+				pipe.Unlink(ctx, cacheKey)
+			}
+			_, err := pipe.Exec(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.client.Unlink(ctx, tagKey).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanTag 使用SSCAN分批读取tag集合，每批最多scanCount个key
+func (s *RedisStore) scanTag(ctx context.Context, tagKey string, fn func(keys []string) error) error {
+	batch := make([]string, 0, scanCount)
+	iter := s.client.SScan(ctx, tagKey, 0, "", scanCount).Iterator()
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanCount {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return fn(batch)
+}
+
+// DeleteKeys 使用pipeline删除多个key，部分key删除失败时返回合并后的错误
+func (s *RedisStore) DeleteKeys(ctx context.Context, keys []string) error {
+	//与UnlinkTags相同，逐个DEL避免CROSSSLOT错误
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for n, key := range keys {
+		cmds[n] = pipe.Del(ctx, key)
+	}
+	//Exec只返回第一个错误，逐个检查每个命令的结果
+	_, _ = pipe.Exec(ctx)
+	var errs []error
+	for n, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			errs = append(errs, &KeyDeleteError{Key: keys[n], Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetMany 使用pipeline读取多个key
+func (s *RedisStore) GetMany(ctx context.Context, keys []string) ([]any, error) {
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for n, key := range keys {
+		cmds[n] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	values := make([]any, len(keys))
+	for n, cmd := range cmds {
+		if value, err := cmd.Result(); err == nil {
+			values[n] = value
+		}
+	}
+	return values, nil
+}
+
+// SetMany 使用pipeline写入多个key
+func (s *RedisStore) SetMany(ctx context.Context, keys []string, values [][]byte, expiration time.Duration) error {
+	pipe := s.client.Pipeline()
+	for n, key := range keys {
+		pipe.Set(ctx, key, values[n], expiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SetIfAbsent 使用SET NX写入，tag的存储格式与gocache相同。
+// 集群模式下key和tag集合可能不在同一个slot，无法在一个事务中写入，tag写入失败时删除刚写入的key并返回错误
+func (s *RedisStore) SetIfAbsent(ctx context.Context, key any, value any, options ...lib_store.Option) (bool, error) {
+	opts := lib_store.ApplyOptions(options...)
+	ok, err := s.client.SetNX(ctx, key.(string), value, opts.Expiration).Result()
+	if err != nil || !ok || len(opts.Tags) == 0 {
+		return ok, err
+	}
+
+	pipe := s.client.Pipeline()
+	for _, tag := range opts.Tags {
+		tagKey := fmt.Sprintf(redis_store.RedisTagPattern, tag)
+		pipe.SAdd(ctx, tagKey, key.(string))
+		pipe.Expire(ctx, tagKey, 720*time.Hour)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		//不带tag的值无法被DeleteByTags删除，回滚写入
+		if delErr := s.client.Del(context.WithoutCancel(ctx), key.(string)).Err(); delErr != nil {
+			return false, errors.Join(err, delErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Ping 使用PING检查连接
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// TagKeys 使用SSCAN返回tag集合中的全部key，SSCAN可能返回重复的key，这里去重
+func (s *RedisStore) TagKeys(ctx context.Context, tag string) ([]string, error) {
+	var keys []string
+	seen := make(map[string]struct{})
+	err := s.scanTag(ctx, fmt.Sprintf(redis_store.RedisTagPattern, tag), func(batch []string) error {
+		for _, key := range batch {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Expire 使用EXPIRE修改key的有效期
+func (s *RedisStore) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	ok, err := s.client.Expire(ctx, key, expiration).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return lib_store.NotFoundWithCause(errors.New("value not found in redis store"))
+	}
+	return nil
+}
+
+// TTL 使用PTTL读取剩余有效期
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	//-2表示key不存在，-1表示没有过期时间，go-redis对这两个值不做单位换算
+	switch ttl {
+	case -2:
+		return 0, lib_store.NotFoundWithCause(errors.New("value not found in redis store"))
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// scanCount 每次SCAN的数量
+const scanCount = 1000
+
+// ScanKeys 使用SCAN枚举以prefix开头的key，集群模式下扫描全部master节点
+func (s *RedisStore) ScanKeys(ctx context.Context, prefix string) ([]string, error) {
+	pattern := escapeGlob(prefix) + "*"
+	cluster, ok := s.client.(*redis.ClusterClient)
+	if !ok {
+		return scanKeys(ctx, s.client, pattern)
+	}
+
+	var mu sync.Mutex
+	var keys []string
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		nodeKeys, err := scanKeys(ctx, client, pattern)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, nodeKeys...)
+		return nil
+	})
+	return keys, err
+}
+
+func scanKeys(ctx context.Context, client redis.Cmdable, pattern string) ([]string, error) {
+	var keys []string
+	iter := client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// escapeGlob 转义redis匹配模式中的特殊字符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// unlockScript 只有锁的值等于token时才删除，避免释放其他持有者的锁
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extendLockScript 只有锁的值等于token时才重置过期时间
+var extendLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// TryLock 使用SET NX PX加锁
+func (s *RedisStore) TryLock(ctx context.Context, name string, token string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, name, token, ttl).Result()
+}
+
+func (s *RedisStore) Unlock(ctx context.Context, name string, token string) error {
+	return unlockScript.Run(ctx, s.client, []string{name}, token).Err()
+}
+
+// ExtendLock 锁仍属于token时使用PEXPIRE重置过期时间
+func (s *RedisStore) ExtendLock(ctx context.Context, name string, token string, ttl time.Duration) (bool, error) {
+	n, err := extendLockScript.Run(ctx, s.client, []string{name}, token, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
@@ -0,0 +1,182 @@
+package cacheable
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	lib_store "github.com/eko/gocache/lib/v4/store"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisStore(client), mr
+}
+
+func TestRedisStoreAsyncUnlink(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("使用UNLINK根据标签删除缓存", func(t *testing.T) {
+		redisStore, mr := newTestRedisStore(t)
+		cacheManager := NewCacheManager(redisStore, WithAsyncUnlink())
+
+		_, _, _ = Get(ctx, cacheManager, namespace, "key1", func() (string, error) {
+			return "value1", nil
+		}, WithTags("tag1"))
+		_, _, _ = Get(ctx, cacheManager, namespace, "key2", func() (string, error) {
+			return "value2", nil
+		}, WithTags("tag1"))
+		assert.True(t, mr.Exists("gocache_tag_tag1"))
+
+		err := DeleteByTags(ctx, cacheManager, []string{"tag1"})
+		assert.NoError(t, err)
+
+		assert.False(t, mr.Exists(loadDefaultKeyPrefix()+":"+namespace+":key1"))
+		assert.False(t, mr.Exists(loadDefaultKeyPrefix()+":"+namespace+":key2"))
+		assert.False(t, mr.Exists("gocache_tag_tag1"))
+	})
+
+	t.Run("大的tag集合分批删除", func(t *testing.T) {
+		redisStore, mr := newTestRedisStore(t)
+		total := scanCount*2 + 1
+		for n := 0; n < total; n++ {
+			key := fmt.Sprintf("batch_key_%d", n)
+			assert.NoError(t, mr.Set(key, "value"))
+			_, err := mr.SAdd("gocache_tag_batch", key)
+			assert.NoError(t, err)
+		}
+
+		keys, err := redisStore.TagKeys(ctx, "batch")
+		assert.NoError(t, err)
+		assert.Len(t, keys, total)
+
+		assert.NoError(t, redisStore.UnlinkTags(ctx, []string{"batch"}))
+		assert.Empty(t, mr.Keys())
+	})
+
+	t.Run("store不支持时回退到同步删除", func(t *testing.T) {
+		cacheManager := NewCacheManager(MockCacheManager.cache, WithAsyncUnlink())
+
+		_, _, _ = Get(ctx, cacheManager, namespace, "unlink_fallback", func() (string, error) {
+			return "value", nil
+		}, WithTags("unlink_fallback_tag"))
+
+		err := DeleteByTags(ctx, cacheManager, []string{"unlink_fallback_tag"})
+		assert.NoError(t, err)
+
+		_, _, cached := Get(ctx, cacheManager, namespace, "unlink_fallback", func() (string, error) {
+			return "new value", nil
+		})
+		assert.False(t, cached)
+	})
+}
+
+func TestRedisStoreTagKeys(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	cacheManager := NewCacheManager(redisStore)
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "key1", func() (string, error) {
+		return "value1", nil
+	}, WithTags("tag1"))
+
+	_, keys, err := PreviewDeleteByTags(ctx, cacheManager, []string{"tag1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{loadDefaultKeyPrefix() + ":" + namespace + ":key1"}, keys)
+}
+
+func TestRedisStoreExpire(t *testing.T) {
+	ctx := context.Background()
+	redisStore, mr := newTestRedisStore(t)
+	cacheManager := NewCacheManager(redisStore)
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "sliding", func() (string, error) {
+		return "value", nil
+	}, WithExpiration(time.Minute))
+	mr.FastForward(50 * time.Second)
+
+	_, _, cached := Get(ctx, cacheManager, namespace, "sliding", func() (string, error) {
+		return "new value", nil
+	}, WithSlidingExpiration(time.Minute))
+	assert.True(t, cached)
+	assert.Equal(t, time.Minute, mr.TTL(loadDefaultKeyPrefix()+":"+namespace+":sliding"))
+
+	assert.ErrorIs(t, redisStore.Expire(ctx, "missing", time.Minute), lib_store.NotFound{})
+}
+
+func TestRedisStoreLock(t *testing.T) {
+	ctx := context.Background()
+	redisStore, mr := newTestRedisStore(t)
+
+	locked, err := redisStore.TryLock(ctx, "lock", "token1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	locked, err = redisStore.TryLock(ctx, "lock", "token2", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, locked)
+
+	// 其他持有者不能释放锁
+	assert.NoError(t, redisStore.Unlock(ctx, "lock", "token2"))
+	assert.True(t, mr.Exists("lock"))
+
+	assert.NoError(t, redisStore.Unlock(ctx, "lock", "token1"))
+	assert.False(t, mr.Exists("lock"))
+
+	// 锁过期后可以重新获取
+	_, _ = redisStore.TryLock(ctx, "lock", "token1", time.Minute)
+	mr.FastForward(time.Minute)
+	locked, err = redisStore.TryLock(ctx, "lock", "token2", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+}
+
+func TestEscapeGlob(t *testing.T) {
+	assert.Equal(t, `cacheable:a\*b\?\[c\]\\:`, escapeGlob(`cacheable:a*b?[c]\:`))
+}
+
+func TestNamespaceHashTag(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { _ = client.Close() })
+	cacheManager := NewCacheManager(NewRedisStore(client), WithKeyPrefix("app"), WithNamespaceHashTag())
+
+	key1 := cacheManager.buildKey("users", "alice")
+	key2 := cacheManager.buildKey("users", "bob")
+	assert.Equal(t, "app:{users}:alice", key1)
+
+	// 同一个namespace的key落在同一个slot
+	slot1, err := client.ClusterKeySlot(ctx, key1).Result()
+	assert.NoError(t, err)
+	slot2, err := client.ClusterKeySlot(ctx, key2).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, slot1, slot2)
+
+	for _, key := range []string{"alice", "bob"} {
+		assert.NoError(t, Set(ctx, cacheManager, "users", key, key, WithTags("{users}:all")))
+	}
+	values, err := Export[string](ctx, cacheManager, "users")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"alice": "alice", "bob": "bob"}, values)
+
+	// 使用相同hash tag的tag集合可以正常失效
+	assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"{users}:all"}))
+	for _, key := range []string{"alice", "bob"} {
+		exists, err := Exists(ctx, cacheManager, "users", key)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	}
+
+	// 与WithKeyBuilder组合时namespace已带有花括号
+	built := NewCacheManager(NewRedisStore(client), WithNamespaceHashTag(), WithKeyBuilder(func(prefix, namespace, key string) string {
+		return namespace + "/" + key
+	}))
+	assert.Equal(t, "{users}/alice", built.buildKey("users", "alice"))
+}
@@ -0,0 +1,37 @@
+package cacheable
+
+import "context"
+
+// Refresh 总是调用fn并用结果覆盖缓存，用于已知上游数据变化时主动更新缓存，避免删除后下次读取时大量请求同时穿透。
+// 同一个key的并发Refresh通过singleflight合并，但不会与未命中时的计算合并，避免拿到刷新开始前已经在计算的旧数据。
+// fn返回错误时不修改已有的缓存
+func (i *CacheManager) Refresh(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) ([]byte, error) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return nil, err
+	}
+	storeKey := i.tenantKey(ctx, namespace, key)
+	value, err, _ := i.loadFlight(ctx, "refresh:"+i.singleflightKey(namespace, storeKey), namespace, storeKey, fn, opts...)
+	return value, err
+}
+
+// Refresh 泛型版本的CacheManager.Refresh，返回新的值
+func Refresh[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, fn func() (T, error), opts ...Option) (value T, err error) {
+	codec := cacheManager.callCodec(namespace, opts)
+	if cacheManager.typeCheck {
+		opts = append(opts[:len(opts):len(opts)], withTypeName(typeName[T]()))
+	}
+	data, err := cacheManager.Refresh(ctx, namespace, key, func() ([]byte, error) {
+		v, e := fn()
+		if e != nil {
+			return nil, e
+		}
+		return encodeWith(codec, v)
+	}, opts...)
+	if err != nil {
+		return value, err
+	}
+	if err := decodeWith(codec, data, &value); err != nil {
+		return value, &DecodeError{Namespace: namespace, Key: key, Type: typeName[T](), Err: err}
+	}
+	return value, nil
+}
@@ -0,0 +1,29 @@
+package cacheable
+
+// WithRefreshAhead 缓存剩余有效期低于有效期的threshold比例时（例如0.2表示最后20%），在后台调用fn提前刷新，
+// 本次仍然返回当前的值（cached为true）。与WithStaleWhileRevalidate共用singleflight，同一个key同时只有一个刷新。
+// 写入时需要记录过期时间，只对开启该选项后写入的缓存生效，threshold不在(0, 1)之间或没有有效期时不生效。
+// WithSlidingExpiration续期不会更新记录的过期时间
+func WithRefreshAhead(threshold float64) Option {
+	return func(o *Options) {
+		o.refreshAhead = threshold
+	}
+}
+
+// expiresAt 计算写入时记录的过期时间，没有开启WithRefreshAhead时返回0
+func (i *CacheManager) expiresAt(effective Options) int64 {
+	if effective.refreshAhead <= 0 || effective.refreshAhead >= 1 || effective.Expiration <= 0 || effective.tombstone {
+		return 0
+	}
+	return i.now().Add(effective.Expiration).UnixNano()
+}
+
+// shouldRefreshAhead 判断缓存的剩余有效期是否已经低于WithRefreshAhead的比例
+func (i *CacheManager) shouldRefreshAhead(options *Options, e *entry) bool {
+	if options.refreshAhead <= 0 || options.refreshAhead >= 1 || e.expiresAt == 0 || e.createdAt == 0 {
+		return false
+	}
+	total := e.expiresAt - e.createdAt
+	remaining := e.expiresAt - i.now().UnixNano()
+	return total > 0 && float64(remaining) < float64(total)*options.refreshAhead
+}
@@ -0,0 +1,82 @@
+package cacheable
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshAhead(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	now := time.Now()
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+	cacheManager := newTestGoCacheManager(t, WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}))
+	opts := []Option{WithExpiration(10 * time.Minute), WithRefreshAhead(0.2)}
+	var version atomic.Int32
+	release := make(chan struct{})
+	fn := func() (int32, error) {
+		v := version.Add(1)
+		if v > 1 {
+			<-release
+		}
+		return v, nil
+	}
+
+	value, _, meta := GetWithMeta(ctx, cacheManager, namespace, "ahead_key", fn, opts...)
+	assert.Equal(t, int32(1), value)
+	assert.False(t, meta.Cached)
+
+	t.Run("剩余有效期充足时不刷新", func(t *testing.T) {
+		advance(7 * time.Minute)
+		value, _, meta := GetWithMeta(ctx, cacheManager, namespace, "ahead_key", fn, opts...)
+		assert.Equal(t, int32(1), value)
+		assert.True(t, meta.Cached)
+		assert.Equal(t, int32(1), version.Load())
+	})
+
+	t.Run("进入最后20%后只触发一次刷新", func(t *testing.T) {
+		advance(2 * time.Minute)
+		for n := 0; n < 5; n++ {
+			value, err, meta := GetWithMeta(ctx, cacheManager, namespace, "ahead_key", fn, opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), value)
+			assert.True(t, meta.Cached)
+			assert.False(t, meta.Stale)
+		}
+		assert.Eventually(t, func() bool {
+			return version.Load() == 2
+		}, time.Second, 5*time.Millisecond)
+		close(release)
+
+		assert.Eventually(t, func() bool {
+			value, _, _ := GetWithMeta(ctx, cacheManager, namespace, "ahead_key", fn, opts...)
+			return value == 2
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(2), version.Load())
+	})
+
+	t.Run("未记录过期时间的缓存不刷新", func(t *testing.T) {
+		_, _, _ = Get(ctx, cacheManager, namespace, "ahead_legacy", fn, WithExpiration(10*time.Minute))
+		advance(9 * time.Minute)
+		before := version.Load()
+		value, _, meta := GetWithMeta(ctx, cacheManager, namespace, "ahead_legacy", fn, opts...)
+		assert.True(t, meta.Cached)
+		assert.Equal(t, before, value)
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, before, version.Load())
+	})
+}
@@ -0,0 +1,98 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t)
+
+	t.Run("覆盖已有的缓存", func(t *testing.T) {
+		_, _, _ = Get(ctx, cacheManager, namespace, "refresh_key", func() (string, error) {
+			return "old", nil
+		})
+
+		value, err := Refresh(ctx, cacheManager, namespace, "refresh_key", func() (string, error) {
+			return "new", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "new", value)
+
+		value, _, cached := Get(ctx, cacheManager, namespace, "refresh_key", func() (string, error) {
+			return "", nil
+		})
+		assert.True(t, cached)
+		assert.Equal(t, "new", value)
+	})
+
+	t.Run("失败时保留已有的缓存", func(t *testing.T) {
+		fnErr := errors.New("db error")
+		_, err := Refresh(ctx, cacheManager, namespace, "refresh_key", func() (string, error) {
+			return "", fnErr
+		})
+		assert.ErrorIs(t, err, fnErr)
+
+		value, _, cached := Get(ctx, cacheManager, namespace, "refresh_key", func() (string, error) {
+			return "", nil
+		})
+		assert.True(t, cached)
+		assert.Equal(t, "new", value)
+	})
+
+	t.Run("并发刷新合并", func(t *testing.T) {
+		var calls atomic.Int32
+		var wg sync.WaitGroup
+		for n := 0; n < 10; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := Refresh(ctx, cacheManager, namespace, "refresh_shared", func() (int, error) {
+					calls.Add(1)
+					time.Sleep(20 * time.Millisecond)
+					return 1, nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, 1, value)
+			}()
+		}
+		wg.Wait()
+		assert.Less(t, calls.Load(), int32(10))
+	})
+
+	t.Run("不与未命中时的计算合并", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _, _ = Get(ctx, cacheManager, namespace, "refresh_inflight", func() (string, error) {
+				close(started)
+				<-release
+				return "old", nil
+			})
+		}()
+		<-started
+
+		value, err := Refresh(ctx, cacheManager, namespace, "refresh_inflight", func() (string, error) {
+			return "new", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "new", value)
+		close(release)
+		<-done
+	})
+
+	t.Run("namespace为空", func(t *testing.T) {
+		_, err := cacheManager.Refresh(ctx, "", "key", func() ([]byte, error) {
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, ErrEmptyNamespace)
+	})
+}
@@ -0,0 +1,80 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// WithRetry store的Get和Set返回错误（store.NotFound除外）时重试，attempts为包括第一次在内的最大尝试次数。
+// 第n次重试前等待backoff*2^(n-1)加上同样大小以内的随机时间，ctx取消或剩余时间不足以等待时不再重试，返回最后一次的错误。
+// SetIfAbsent不会重试，第一次写入可能已经成功，重试会得到错误的结果
+func WithRetry(attempts int, backoff time.Duration) ManagerOption {
+	return func(m *CacheManager) {
+		m.retryAttempts = attempts
+		m.retryBackoff = backoff
+	}
+}
+
+// storeGet 读取store，按WithRetry重试，经过WithCircuitBreaker的熔断器
+func (i *CacheManager) storeGet(ctx context.Context, key string) (data any, err error) {
+	if i.retryAttempts <= 1 && i.breaker == nil {
+		return i.cache.Get(ctx, key)
+	}
+	err = i.guardStore(ctx, func() error {
+		return i.retry(ctx, func() error {
+			data, err = i.cache.Get(ctx, key)
+			return err
+		})
+	})
+	return data, err
+}
+
+// storeSet 写入store，按WithRetry重试，经过WithCircuitBreaker的熔断器
+func (i *CacheManager) storeSet(ctx context.Context, key string, value []byte, options ...store.Option) error {
+	if i.retryAttempts <= 1 && i.breaker == nil {
+		return i.cache.Set(ctx, key, value, options...)
+	}
+	return i.guardStore(ctx, func() error {
+		return i.retry(ctx, func() error {
+			return i.cache.Set(ctx, key, value, options...)
+		})
+	})
+}
+
+// storeSetIfAbsent 只在key不存在时写入store，经过WithCircuitBreaker的熔断器。
+// 不按WithRetry重试，超时的写入可能已经成功，重试会误判key已存在
+func (i *CacheManager) storeSetIfAbsent(ctx context.Context, key string, value []byte, options ...store.Option) (absent bool, err error) {
+	err = i.guardStore(ctx, func() error {
+		absent, err = i.cache.(AbsentSetter).SetIfAbsent(ctx, key, value, options...)
+		return err
+	})
+	return absent, err
+}
+
+// retry 按WithRetry的配置执行op
+func (i *CacheManager) retry(ctx context.Context, op func() error) error {
+	err := op()
+	for attempt := 1; attempt < i.retryAttempts; attempt++ {
+		if err == nil || errors.Is(err, store.NotFound{}) || ctx.Err() != nil {
+			return err
+		}
+		delay := i.retryBackoff << (attempt - 1)
+		delay += jitter(delay)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = op()
+	}
+	return err
+}
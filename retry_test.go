@@ -0,0 +1,89 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyStore 前failures次Get和Set返回错误
+type flakyStore struct {
+	store.StoreInterface
+	failures int32
+	gets     atomic.Int32
+	sets     atomic.Int32
+}
+
+var errFlaky = errors.New("connection reset")
+
+func (s *flakyStore) Get(ctx context.Context, key any) (any, error) {
+	if s.gets.Add(1) <= s.failures {
+		return nil, errFlaky
+	}
+	return s.StoreInterface.Get(ctx, key)
+}
+
+func (s *flakyStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	if s.sets.Add(1) <= s.failures {
+		return errFlaky
+	}
+	return s.StoreInterface.Set(ctx, key, value, options...)
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	fn := func() (string, error) {
+		return "value", nil
+	}
+
+	t.Run("重试后成功", func(t *testing.T) {
+		flaky := &flakyStore{StoreInterface: newGoCacheStore(), failures: 2}
+		cacheManager := NewCacheManager(flaky, WithRetry(3, time.Millisecond), WithStrictSet())
+		value, err, cached := Get(ctx, cacheManager, namespace, "retry_key", fn)
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, int32(3), flaky.gets.Load())
+		assert.Equal(t, int32(3), flaky.sets.Load())
+	})
+
+	t.Run("超过次数返回错误", func(t *testing.T) {
+		flaky := &flakyStore{StoreInterface: newGoCacheStore(), failures: 5}
+		cacheManager := NewCacheManager(flaky, WithRetry(3, time.Millisecond))
+		_, err, _ := Get(ctx, cacheManager, namespace, "retry_key", fn)
+		assert.ErrorIs(t, err, errFlaky)
+		assert.Equal(t, int32(3), flaky.gets.Load())
+	})
+
+	t.Run("缓存不存在不重试", func(t *testing.T) {
+		flaky := &flakyStore{StoreInterface: newGoCacheStore()}
+		cacheManager := NewCacheManager(flaky, WithRetry(3, time.Millisecond))
+		_, err, _ := Get(ctx, cacheManager, namespace, "retry_key", fn)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), flaky.gets.Load())
+	})
+
+	t.Run("剩余时间不足时不再重试", func(t *testing.T) {
+		flaky := &flakyStore{StoreInterface: newGoCacheStore(), failures: 5}
+		cacheManager := NewCacheManager(flaky, WithRetry(5, time.Second))
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err, _ := Get(ctx, cacheManager, namespace, "retry_key", fn)
+		assert.ErrorIs(t, err, errFlaky)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+		assert.Equal(t, int32(1), flaky.gets.Load())
+	})
+
+	t.Run("未开启时不重试", func(t *testing.T) {
+		flaky := &flakyStore{StoreInterface: newGoCacheStore(), failures: 1}
+		_, err, _ := Get(ctx, NewCacheManager(flaky), namespace, "retry_key", fn)
+		assert.ErrorIs(t, err, errFlaky)
+		assert.Equal(t, int32(1), flaky.gets.Load())
+	})
+}
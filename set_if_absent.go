@@ -0,0 +1,41 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+)
+
+// SetRawIfAbsent 与SetRaw相同，但只在key不存在时写入，返回是否写入成功，可用于先写入者获胜的预热和简单的互斥。
+// 需要store实现AbsentSetter保证原子性，否则返回ErrNotSupported，不会退化为非原子的先读后写
+func (i *CacheManager) SetRawIfAbsent(ctx context.Context, namespace string, key string, data []byte, opts ...Option) (bool, error) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return false, err
+	}
+	if _, ok := i.cache.(AbsentSetter); !ok {
+		return false, ErrNotSupported
+	}
+	if i.valueTooLarge(namespace, data) {
+		return false, ErrValueTooLarge
+	}
+	options := applyOptions(opts...)
+	options.ifAbsent = true
+	err := i.set(ctx, namespace, i.tenantKey(ctx, namespace, key), data, options, nil, true)
+	if errors.Is(err, errKeyExists) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetIfAbsent 序列化value后调用SetRawIfAbsent，key已存在时不覆盖并返回false
+func SetIfAbsent[T any](ctx context.Context, cacheManager *CacheManager, namespace string, key string, value T, opts ...Option) (bool, error) {
+	data, err := encodeWith(cacheManager.callCodec(namespace, opts), value)
+	if err != nil {
+		return false, err
+	}
+	if cacheManager.typeCheck {
+		opts = append(opts[:len(opts):len(opts)], withTypeName(typeName[T]()))
+	}
+	return cacheManager.SetRawIfAbsent(ctx, namespace, key, data, opts...)
+}
@@ -0,0 +1,99 @@
+package cacheable
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redis_store "github.com/eko/gocache/store/redis/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"redis":    NewCacheManager(redisStore),
+		"go-cache": newTestGoCacheManager(t),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			written, err := SetIfAbsent(ctx, cacheManager, namespace, "absent", "first", WithExpiration(time.Minute), WithTags("absent_tag"))
+			assert.NoError(t, err)
+			assert.True(t, written)
+
+			written, err = SetIfAbsent(ctx, cacheManager, namespace, "absent", "second")
+			assert.NoError(t, err)
+			assert.False(t, written)
+
+			value, err, cached := Get(ctx, cacheManager, namespace, "absent", func() (string, error) {
+				return "fn", nil
+			})
+			assert.NoError(t, err)
+			assert.True(t, cached)
+			assert.Equal(t, "first", value)
+
+			//写入时的tag同样生效
+			assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"absent_tag"}))
+			written, err = SetIfAbsent(ctx, cacheManager, namespace, "absent", "third")
+			assert.NoError(t, err)
+			assert.True(t, written)
+		})
+	}
+
+	t.Run("并发写入只有一个成功", func(t *testing.T) {
+		cacheManager := managers["redis"]
+		var succeeded atomic.Int32
+		var wg sync.WaitGroup
+		for n := 0; n < 20; n++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				written, err := SetIfAbsent(ctx, cacheManager, namespace, "absent_race", n)
+				assert.NoError(t, err)
+				if written {
+					succeeded.Add(1)
+				}
+			}(n)
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), succeeded.Load())
+	})
+
+	t.Run("tag写入失败时回滚并返回错误", func(t *testing.T) {
+		redisStore, mr := newTestRedisStore(t)
+		cacheManager := NewCacheManager(redisStore)
+		//tag集合的key类型错误，SADD失败
+		assert.NoError(t, mr.Set(fmt.Sprintf(redis_store.RedisTagPattern, "absent_broken"), "not a set"))
+
+		written, err := SetIfAbsent(ctx, cacheManager, namespace, "absent_broken", "value", WithTags("absent_broken"))
+		assert.Error(t, err)
+		assert.False(t, written)
+		assert.False(t, mr.Exists(cacheManager.buildKey(namespace, "absent_broken")))
+	})
+
+	t.Run("经过熔断器", func(t *testing.T) {
+		redisStore, mr := newTestRedisStore(t)
+		cacheManager := NewCacheManager(redisStore, WithCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, OpenTimeout: time.Minute}))
+		mr.SetError("down")
+		_, err := SetIfAbsent(ctx, cacheManager, namespace, "absent_breaker", "value")
+		assert.Error(t, err)
+		assert.Equal(t, CircuitOpen, cacheManager.CircuitState())
+
+		mr.SetError("")
+		written, err := SetIfAbsent(ctx, cacheManager, namespace, "absent_breaker", "value")
+		assert.ErrorIs(t, err, errCircuitOpen)
+		assert.False(t, written)
+		assert.False(t, mr.Exists(cacheManager.buildKey(namespace, "absent_breaker")))
+	})
+
+	t.Run("store不支持", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore())
+		_, err := SetIfAbsent(ctx, cacheManager, namespace, "absent", "value")
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
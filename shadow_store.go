@@ -0,0 +1,141 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+const (
+	shadowPrimary   = "primary"
+	shadowSecondary = "secondary"
+)
+
+// defaultShadowConcurrency 同时进行中的镜像请求上限
+const defaultShadowConcurrency = 64
+
+// ShadowStore 用于对比两个store的表现，例如从go-cache迁移到redis前评估命中率。
+// 所有读写都以primary为准，同时异步镜像到secondary，secondary的结果和错误不会影响返回值，
+// 两个后端的请求数、命中数、错误数和耗时分别记录在shadow_store_*指标中，通过backend标签区分
+type ShadowStore struct {
+	primary   store.StoreInterface
+	secondary store.StoreInterface
+	sem       chan struct{}
+}
+
+func NewShadowStore(primary store.StoreInterface, secondary store.StoreInterface) *ShadowStore {
+	initMetrics()
+	return &ShadowStore{
+		primary:   primary,
+		secondary: secondary,
+		sem:       make(chan struct{}, defaultShadowConcurrency),
+	}
+}
+
+func (s *ShadowStore) Get(ctx context.Context, key any) (any, error) {
+	s.mirror(ctx, "get", func(ctx context.Context) error {
+		_, err := s.secondary.Get(ctx, key)
+		return err
+	})
+
+	var value any
+	err := observeShadow(shadowPrimary, "get", func() (err error) {
+		value, err = s.primary.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (s *ShadowStore) GetWithTTL(ctx context.Context, key any) (any, time.Duration, error) {
+	s.mirror(ctx, "get", func(ctx context.Context) error {
+		_, _, err := s.secondary.GetWithTTL(ctx, key)
+		return err
+	})
+
+	var value any
+	var ttl time.Duration
+	err := observeShadow(shadowPrimary, "get", func() (err error) {
+		value, ttl, err = s.primary.GetWithTTL(ctx, key)
+		return err
+	})
+	return value, ttl, err
+}
+
+func (s *ShadowStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	s.mirror(ctx, "set", func(ctx context.Context) error {
+		return s.secondary.Set(ctx, key, value, options...)
+	})
+	return observeShadow(shadowPrimary, "set", func() error {
+		return s.primary.Set(ctx, key, value, options...)
+	})
+}
+
+func (s *ShadowStore) Delete(ctx context.Context, key any) error {
+	s.mirror(ctx, "delete", func(ctx context.Context) error {
+		return s.secondary.Delete(ctx, key)
+	})
+	return observeShadow(shadowPrimary, "delete", func() error {
+		return s.primary.Delete(ctx, key)
+	})
+}
+
+func (s *ShadowStore) Invalidate(ctx context.Context, options ...store.InvalidateOption) error {
+	s.mirror(ctx, "invalidate", func(ctx context.Context) error {
+		return s.secondary.Invalidate(ctx, options...)
+	})
+	return observeShadow(shadowPrimary, "invalidate", func() error {
+		return s.primary.Invalidate(ctx, options...)
+	})
+}
+
+func (s *ShadowStore) Clear(ctx context.Context) error {
+	s.mirror(ctx, "clear", func(ctx context.Context) error {
+		return s.secondary.Clear(ctx)
+	})
+	return observeShadow(shadowPrimary, "clear", func() error {
+		return s.primary.Clear(ctx)
+	})
+}
+
+func (s *ShadowStore) GetType() string {
+	return s.primary.GetType()
+}
+
+// mirror 异步在secondary上执行操作，并发数达到上限时直接丢弃，不会阻塞primary
+func (s *ShadowStore) mirror(ctx context.Context, operation string, fn func(ctx context.Context) error) {
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		ShadowStoreDroppedTotal.Inc()
+		return
+	}
+
+	//调用方返回后ctx可能被取消，镜像请求不受其影响
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() { <-s.sem }()
+		_ = observeShadow(shadowSecondary, operation, func() error {
+			return fn(ctx)
+		})
+	}()
+}
+
+func observeShadow(backend string, operation string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	ShadowStoreDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+	ShadowStoreRequestTotal.WithLabelValues(backend, operation).Inc()
+
+	switch {
+	case err == nil:
+		if operation == "get" {
+			ShadowStoreHitTotal.WithLabelValues(backend).Inc()
+		}
+	case errors.Is(err, store.NotFound{}):
+	default:
+		ShadowStoreErrorTotal.WithLabelValues(backend, operation).Inc()
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/eko/gocache/store/go_cache/v4"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingStore 所有操作阻塞到release关闭后返回错误
+type blockingStore struct {
+	store.StoreInterface
+	release chan struct{}
+}
+
+func (s *blockingStore) Get(context.Context, any) (any, error) {
+	<-s.release
+	return nil, errors.New("secondary down")
+}
+
+func (s *blockingStore) Set(context.Context, any, any, ...store.Option) error {
+	<-s.release
+	return errors.New("secondary down")
+}
+
+func newGoCacheStore() store.StoreInterface {
+	return go_cache.NewGoCache(gocache.New(5*time.Minute, 10*time.Minute))
+}
+
+func TestShadowStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("写入镜像到secondary", func(t *testing.T) {
+		secondary := newGoCacheStore()
+		cacheManager := NewCacheManager(NewShadowStore(newGoCacheStore(), secondary))
+
+		_, _, _ = Get(ctx, cacheManager, namespace, "shadow", func() (string, error) {
+			return "value", nil
+		})
+
+		assert.Eventually(t, func() bool {
+			_, err := secondary.Get(ctx, cacheManager.buildKey(namespace, "shadow"))
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("secondary故障不影响primary", func(t *testing.T) {
+		secondary := &blockingStore{release: make(chan struct{})}
+		cacheManager := NewCacheManager(NewShadowStore(newGoCacheStore(), secondary))
+		errorsBefore := testutil.ToFloat64(ShadowStoreErrorTotal.WithLabelValues(shadowSecondary, "set"))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err, _ := Get(ctx, cacheManager, namespace, "shadow_down", func() (string, error) {
+				return "value", nil
+			})
+			assert.NoError(t, err)
+			value, err, cached := Get(ctx, cacheManager, namespace, "shadow_down", func() (string, error) {
+				return "new value", nil
+			})
+			assert.NoError(t, err)
+			assert.True(t, cached)
+			assert.Equal(t, "value", value)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("primary被secondary阻塞")
+		}
+
+		close(secondary.release)
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(ShadowStoreErrorTotal.WithLabelValues(shadowSecondary, "set")) == errorsBefore+1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,21 @@
+package cacheable
+
+// WithSingleflightKeyFunc 自定义singleflight合并的粒度，fn根据namespace和store中的key（已包含前缀和namespace）
+// 返回合并使用的key，默认直接使用store中的key，即只合并同一个key的并发请求。
+//
+// 返回相同合并key的并发请求只会执行其中一个请求的fn，其余请求直接得到这个fn的结果，并且只有执行fn的请求的key会写入缓存。
+// 因此只有在映射到同一个合并key的各个key的fn返回完全相同的值时才能放宽粒度，例如fn批量加载整个namespace的数据，
+// 否则请求会拿到其他key的值。WithMaxInflight也按合并key计数
+func WithSingleflightKeyFunc(fn func(namespace, key string) string) ManagerOption {
+	return func(m *CacheManager) {
+		m.sgKeyFunc = fn
+	}
+}
+
+// singleflightKey 返回key在singleflight中使用的key
+func (i *CacheManager) singleflightKey(namespace, key string) string {
+	if i.sgKeyFunc == nil {
+		return key
+	}
+	return i.sgKeyFunc(namespace, key)
+}
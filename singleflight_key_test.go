@@ -0,0 +1,69 @@
+package cacheable
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSingleflightKeyFunc(t *testing.T) {
+	ctx := context.Background()
+
+	// getConcurrently 同时发起多个请求，返回执行fn的次数
+	getConcurrently := func(cacheManager *CacheManager, namespaces []string, keys []string) int32 {
+		var wg sync.WaitGroup
+		var executed atomic.Int32
+		start := make(chan struct{})
+		for _, ns := range namespaces {
+			for _, key := range keys {
+				wg.Add(1)
+				go func(ns, key string) {
+					defer wg.Done()
+					<-start
+					value, err, _ := Get(ctx, cacheManager, ns, key, func() (string, error) {
+						executed.Add(1)
+						time.Sleep(50 * time.Millisecond)
+						return "value", nil
+					})
+					assert.NoError(t, err)
+					assert.Equal(t, "value", value)
+				}(ns, key)
+			}
+		}
+		close(start)
+		wg.Wait()
+		return executed.Load()
+	}
+
+	t.Run("默认不同namespace的相同key不合并", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		executed := getConcurrently(cacheManager, []string{"sg_ns1", "sg_ns2"}, []string{"key", "key"})
+		assert.Equal(t, int32(2), executed)
+	})
+
+	t.Run("按namespace合并", func(t *testing.T) {
+		var calls atomic.Int32
+		cacheManager := newTestGoCacheManager(t, WithSingleflightKeyFunc(func(namespace, key string) string {
+			calls.Add(1)
+			return namespace
+		}))
+		executed := getConcurrently(cacheManager, []string{"sg_bulk"}, []string{"a", "b", "c"})
+		assert.Equal(t, int32(1), executed)
+		assert.Positive(t, calls.Load())
+
+		// 只有执行fn的key写入了缓存
+		cached := 0
+		for _, key := range []string{"a", "b", "c"} {
+			exists, err := cacheManager.Exists(ctx, "sg_bulk", key)
+			assert.NoError(t, err)
+			if exists {
+				cached++
+			}
+		}
+		assert.Equal(t, 1, cached)
+	})
+}
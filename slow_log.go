@@ -0,0 +1,50 @@
+package cacheable
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// 慢操作日志中的operation
+const (
+	slowOpGet    = "get"
+	slowOpSet    = "set"
+	slowOpDelete = "delete"
+	slowOpFn     = "fn"
+)
+
+// WithLogger 设置CacheManager的日志，默认不输出日志。Debug级别会输出每次读写和删除的Event，见WithObserver
+func WithLogger(logger *slog.Logger) ManagerOption {
+	return func(m *CacheManager) {
+		m.logger = logger
+	}
+}
+
+// WithSlowThreshold store操作或fn的耗时超过threshold时输出一条Warn日志，包括namespace、key的哈希、操作和耗时，
+// 用于定位长尾延迟。需要通过WithLogger设置日志，否则不生效
+func WithSlowThreshold(threshold time.Duration) ManagerOption {
+	return func(m *CacheManager) {
+		m.slowThreshold = threshold
+	}
+}
+
+func noopTimer() {}
+
+// slowTimer 开始计时，返回的函数在操作结束时调用，耗时超过阈值时输出日志。未开启时不计时，key为store中的key
+func (i *CacheManager) slowTimer(ctx context.Context, namespace string, key string, operation string) func() {
+	if i.logger == nil || i.slowThreshold <= 0 {
+		return noopTimer
+	}
+	start := time.Now()
+	return func() {
+		if elapsed := time.Since(start); elapsed >= i.slowThreshold {
+			i.logger.WarnContext(ctx, "cacheable: slow operation",
+				slog.String("namespace", namespace),
+				slog.String("key_hash", keyHash(key)),
+				slog.String("operation", operation),
+				slog.Duration("duration", elapsed),
+			)
+		}
+	}
+}
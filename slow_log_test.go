@@ -0,0 +1,43 @@
+package cacheable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowThreshold(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("记录慢操作", func(t *testing.T) {
+		var buf bytes.Buffer
+		cacheManager := newTestGoCacheManager(t, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))), WithSlowThreshold(10*time.Millisecond))
+
+		_, _, _ = Get(ctx, cacheManager, namespace, "slow", func() (string, error) {
+			time.Sleep(20 * time.Millisecond)
+			return "value", nil
+		})
+
+		var record map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, namespace, record["namespace"])
+		assert.Equal(t, "fn", record["operation"])
+		assert.Equal(t, keyHash(cacheManager.buildKey(namespace, "slow")), record["key_hash"])
+		assert.GreaterOrEqual(t, record["duration"], float64(20*time.Millisecond))
+	})
+
+	t.Run("未超过阈值不记录", func(t *testing.T) {
+		var buf bytes.Buffer
+		cacheManager := newTestGoCacheManager(t, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))), WithSlowThreshold(time.Second))
+		_, _, _ = Get(ctx, cacheManager, namespace, "fast", func() (string, error) {
+			return "value", nil
+		})
+		assert.Empty(t, buf.String())
+	})
+}
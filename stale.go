@@ -0,0 +1,69 @@
+package cacheable
+
+import (
+	"context"
+	"time"
+)
+
+// WithStaleWhileRevalidate 缓存超过WithExpiration的有效期后，在staleFor时间内仍然返回旧值（cached为true，Meta.Stale为true），
+// 同时在后台调用fn刷新缓存，同一个key同时只有一个刷新，与缓存未命中时的计算共用singleflight。
+// store中的实际有效期为有效期加上staleFor。后台刷新的错误通过Hooks.OnError上报
+func WithStaleWhileRevalidate(staleFor time.Duration) Option {
+	return func(o *Options) {
+		o.staleFor = staleFor
+	}
+}
+
+// WithServeStaleOnError 缓存超过WithExpiration的有效期后仍在store中保留maxStaleAge时间，读取时按未命中重新调用fn，
+// fn失败时返回保留的旧值（cached为true，Meta.Stale为true）而不是错误，fn的错误通过Hooks.OnError上报。
+// 与WithStaleWhileRevalidate同时使用时以WithStaleWhileRevalidate为准
+func WithServeStaleOnError(maxStaleAge time.Duration) Option {
+	return func(o *Options) {
+		o.staleOnError = maxStaleAge
+	}
+}
+
+// freshUntil 计算缓存需要刷新的时间，没有开启WithStaleWhileRevalidate和WithServeStaleOnError时返回0
+func (i *CacheManager) freshUntil(effective Options) int64 {
+	if (effective.staleFor <= 0 && effective.staleOnError <= 0) || effective.tombstone {
+		return 0
+	}
+	return i.now().Add(effective.Expiration).UnixNano()
+}
+
+// revalidate 在后台调用fn刷新缓存，不等待结果，key为store中的key
+func (i *CacheManager) revalidate(ctx context.Context, namespace string, key string, fn func() ([]byte, error), opts ...Option) {
+	if applyOptions(opts...).readOnly {
+		return
+	}
+	loadCtx := context.WithoutCancel(ctx)
+	ch := i.sg.DoChan(i.singleflightKey(namespace, key), func() (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicError(r)
+			}
+		}()
+		return i.load(loadCtx, namespace, key, fn, opts...)
+	})
+	go func() {
+		if result := <-ch; result.Err != nil {
+			i.reportError(loadCtx, namespace, key, result.Err)
+		}
+	}()
+}
+
+// loadOrServeStale 缓存已过期时重新计算，fn失败时返回过期的旧值，key为store中的key
+func (i *CacheManager) loadOrServeStale(ctx context.Context, namespace string, key string, fn func() ([]byte, error), stale *entry, opts ...Option) (value []byte, err error, meta Meta) {
+	value, err, meta = i.loadShared(ctx, namespace, key, fn, opts...)
+	if err == nil || ctx.Err() != nil {
+		return value, err, meta
+	}
+
+	i.reportError(ctx, namespace, key, err)
+	meta.Cached = true
+	meta.Stale = true
+	if stale.createdAt != 0 {
+		meta.Age = i.now().Sub(time.Unix(0, stale.createdAt))
+	}
+	return stale.payload, nil, meta
+}
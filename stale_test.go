@@ -0,0 +1,164 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("过期后返回旧值并后台刷新", func(t *testing.T) {
+		var mu sync.Mutex
+		now := time.Now()
+		cacheManager := newTestGoCacheManager(t, WithClock(func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		}))
+		opts := []Option{WithExpiration(time.Minute), WithStaleWhileRevalidate(time.Hour)}
+		var version atomic.Int32
+		refreshed := make(chan struct{}, 1)
+		fn := func() (int32, error) {
+			v := version.Add(1)
+			if v > 1 {
+				refreshed <- struct{}{}
+			}
+			return v, nil
+		}
+
+		value, _, meta := GetWithMeta(ctx, cacheManager, namespace, "stale_key", fn, opts...)
+		assert.Equal(t, int32(1), value)
+		assert.False(t, meta.Cached)
+
+		value, _, meta = GetWithMeta(ctx, cacheManager, namespace, "stale_key", fn, opts...)
+		assert.Equal(t, int32(1), value)
+		assert.True(t, meta.Cached)
+		assert.False(t, meta.Stale)
+
+		mu.Lock()
+		now = now.Add(2 * time.Minute)
+		mu.Unlock()
+		value, err, meta := GetWithMeta(ctx, cacheManager, namespace, "stale_key", fn, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), value)
+		assert.True(t, meta.Cached)
+		assert.True(t, meta.Stale)
+
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatal("refresh not started")
+		}
+		assert.Eventually(t, func() bool {
+			value, _, meta := GetWithMeta(ctx, cacheManager, namespace, "stale_key", fn, opts...)
+			return value == 2 && !meta.Stale
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("刷新失败时上报错误", func(t *testing.T) {
+		var mu sync.Mutex
+		now := time.Now()
+		reported := make(chan error, 1)
+		cacheManager := newTestGoCacheManager(t, WithClock(func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		}), WithHooks(Hooks{
+			OnError: func(ctx context.Context, namespace string, key string, err error) {
+				reported <- err
+			},
+		}))
+		opts := []Option{WithExpiration(time.Minute), WithStaleWhileRevalidate(time.Hour)}
+		refreshErr := errors.New("db error")
+		_, _, _ = Get(ctx, cacheManager, namespace, "stale_error", func() (string, error) {
+			return "old", nil
+		}, opts...)
+
+		mu.Lock()
+		now = now.Add(2 * time.Minute)
+		mu.Unlock()
+		value, err, cached := Get(ctx, cacheManager, namespace, "stale_error", func() (string, error) {
+			return "", refreshErr
+		}, opts...)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "old", value)
+
+		select {
+		case err := <-reported:
+			assert.ErrorIs(t, err, refreshErr)
+		case <-time.After(time.Second):
+			t.Fatal("refresh error not reported")
+		}
+	})
+}
+
+func TestServeStaleOnError(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	now := time.Now()
+	var reported []error
+	cacheManager := newTestGoCacheManager(t, WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}), WithHooks(Hooks{
+		OnError: func(ctx context.Context, namespace string, key string, err error) {
+			reported = append(reported, err)
+		},
+	}))
+	opts := []Option{WithExpiration(time.Minute), WithServeStaleOnError(time.Hour)}
+	fnErr := errors.New("db error")
+
+	_, _, _ = Get(ctx, cacheManager, namespace, "stale_on_error", func() (string, error) {
+		return "old", nil
+	}, opts...)
+	storeKey := cacheManager.buildKey(namespace, "stale_on_error")
+	ttl, err := cacheManager.TTL(ctx, namespace, "stale_on_error")
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, time.Hour)
+
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	mu.Unlock()
+
+	t.Run("fn失败时返回旧值", func(t *testing.T) {
+		value, err, meta := GetWithMeta(ctx, cacheManager, namespace, "stale_on_error", func() (string, error) {
+			return "", fnErr
+		}, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, "old", value)
+		assert.True(t, meta.Cached)
+		assert.True(t, meta.Stale)
+		assert.Equal(t, 2*time.Minute, meta.Age)
+		assert.Len(t, reported, 1)
+		assert.ErrorIs(t, reported[0], fnErr)
+
+		_, err = cacheManager.cache.Get(ctx, storeKey)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fn成功时返回新值", func(t *testing.T) {
+		value, err, meta := GetWithMeta(ctx, cacheManager, namespace, "stale_on_error", func() (string, error) {
+			return "new", nil
+		}, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", value)
+		assert.False(t, meta.Cached)
+		assert.False(t, meta.Stale)
+
+		value, _, meta = GetWithMeta(ctx, cacheManager, namespace, "stale_on_error", func() (string, error) {
+			return "", fnErr
+		}, opts...)
+		assert.Equal(t, "new", value)
+		assert.True(t, meta.Cached)
+		assert.False(t, meta.Stale)
+	})
+}
@@ -0,0 +1,70 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// tagIndexNamespace tag索引使用的namespace，与调用方的key分开
+const tagIndexNamespace = "__tagindex__"
+
+// WithTagIndex 由CacheManager在store中维护tag到key的索引，不再依赖store自身的tag能力，
+// 适用于不支持tag的store。每个tag和key对应一个单独的索引key，与缓存同时过期，
+// 多个进程同时写入不会互相覆盖。读取索引需要store实现KeyScanner，否则创建CacheManager时panic
+func WithTagIndex() ManagerOption {
+	return func(m *CacheManager) {
+		m.tagIndex = true
+	}
+}
+
+// tagIndexPrefix 返回tag全部索引key共同的前缀，tag使用哈希，避免tag中的冒号与key混淆
+func (i *CacheManager) tagIndexPrefix(tag string) string {
+	return i.joinKey(tagIndexNamespace, i.hashKey(tag)+":")
+}
+
+// indexedTagKeys 读取tag索引中的key
+func (i *CacheManager) indexedTagKeys(ctx context.Context, tag string) ([]string, error) {
+	prefix := i.tagIndexPrefix(tag)
+	indexKeys, err := i.cache.(KeyScanner).ScanKeys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(indexKeys))
+	for _, indexKey := range indexKeys {
+		keys = append(keys, strings.TrimPrefix(indexKey, prefix))
+	}
+	return keys, nil
+}
+
+// indexTags 将key加入tags的索引，索引与缓存使用相同的有效期，过期的key不会留在索引中
+func (i *CacheManager) indexTags(ctx context.Context, key string, tags []string, expiration time.Duration) error {
+	for _, tag := range tags {
+		if err := i.cache.Set(ctx, i.tagIndexPrefix(tag)+key, []byte{}, store.WithExpiration(expiration)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteIndexedTags 删除tag索引中的key以及对应的索引
+func (i *CacheManager) deleteIndexedTags(ctx context.Context, tags []string) error {
+	for _, tag := range tags {
+		keys, err := i.indexedTagKeys(ctx, tag)
+		if err != nil {
+			return err
+		}
+		prefix := i.tagIndexPrefix(tag)
+		for _, key := range keys {
+			for _, k := range []string{key, prefix + key} {
+				if err := i.cache.Delete(ctx, k); err != nil && !errors.Is(err, store.NotFound{}) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+// tagslessStore 不支持tag的store，写入时忽略tag
+type tagslessStore struct {
+	store.StoreInterface
+}
+
+func (s *tagslessStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	return s.StoreInterface.Set(ctx, key, value, store.WithExpiration(store.ApplyOptions(options...).Expiration))
+}
+
+func (s *tagslessStore) SupportsTags() bool {
+	return false
+}
+
+// tagslessScanStore 不支持tag但可以枚举key的store
+type tagslessScanStore struct {
+	tagslessStore
+	scanner KeyScanner
+}
+
+func newTagslessScanStore() *tagslessScanStore {
+	s := NewGoCacheStore(gocache.New(5*time.Minute, 10*time.Minute))
+	return &tagslessScanStore{tagslessStore: tagslessStore{s}, scanner: s}
+}
+
+func (s *tagslessScanStore) ScanKeys(ctx context.Context, prefix string) ([]string, error) {
+	return s.scanner.ScanKeys(ctx, prefix)
+}
+
+var errIndexFailed = errors.New("index failed")
+
+// failingIndexStore 写入tag索引时失败
+type failingIndexStore struct {
+	*tagslessScanStore
+}
+
+func (s *failingIndexStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	if strings.Contains(key.(string), tagIndexNamespace) {
+		return errIndexFailed
+	}
+	return s.tagslessScanStore.Set(ctx, key, value, options...)
+}
+
+func TestTagIndex(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store不支持tag时返回错误", func(t *testing.T) {
+		cacheManager := NewCacheManager(&tagslessStore{newGoCacheStore()})
+		err := DeleteByTags(ctx, cacheManager, []string{"tag1"})
+		assert.ErrorIs(t, err, ErrTagsUnsupported)
+	})
+
+	t.Run("使用tag索引删除缓存", func(t *testing.T) {
+		cacheManager := NewCacheManager(newTagslessScanStore(), WithTagIndex())
+		_, _, _ = Get(ctx, cacheManager, namespace, "key1", func() (string, error) {
+			return "value1", nil
+		}, WithTags("tag1"))
+		_, _, _ = Get(ctx, cacheManager, namespace, "key2", func() (string, error) {
+			return "value2", nil
+		}, WithTags("tag1", "tag2"))
+		_, _, _ = Get(ctx, cacheManager, namespace, "key3", func() (string, error) {
+			return "value3", nil
+		}, WithTags("tag3"))
+
+		_, keys, err := PreviewDeleteByTags(ctx, cacheManager, []string{"tag1"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{cacheManager.buildKey(namespace, "key1"), cacheManager.buildKey(namespace, "key2")}, keys)
+
+		assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"tag1"}))
+		for key, expected := range map[string]bool{"key1": false, "key2": false, "key3": true} {
+			_, _, cached := Get(ctx, cacheManager, namespace, key, func() (string, error) {
+				return "new value", nil
+			})
+			assert.Equal(t, expected, cached, key)
+		}
+
+		_, keys, err = PreviewDeleteByTags(ctx, cacheManager, []string{"tag1"})
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("多个实例写入同一个tag", func(t *testing.T) {
+		s := newTagslessScanStore()
+		instanceA := NewCacheManager(s, WithTagIndex())
+		instanceB := NewCacheManager(s, WithTagIndex())
+		assert.NoError(t, Set(ctx, instanceA, namespace, "key_a", "a", WithTags("shared")))
+		assert.NoError(t, Set(ctx, instanceB, namespace, "key_b", "b", WithTags("shared")))
+
+		assert.NoError(t, DeleteByTags(ctx, instanceA, []string{"shared"}))
+		for _, key := range []string{"key_a", "key_b"} {
+			exists, err := Exists(ctx, instanceB, namespace, key)
+			assert.NoError(t, err)
+			assert.False(t, exists, key)
+		}
+	})
+
+	t.Run("索引与缓存同时过期", func(t *testing.T) {
+		s := newTagslessScanStore()
+		cacheManager := NewCacheManager(s, WithTagIndex())
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "expiring", "value", WithTags("expiring_tag"), WithExpiration(50*time.Millisecond)))
+		_, keys, err := PreviewDeleteByTags(ctx, cacheManager, []string{"expiring_tag"})
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+
+		time.Sleep(100 * time.Millisecond)
+		_, keys, err = PreviewDeleteByTags(ctx, cacheManager, []string{"expiring_tag"})
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("索引的key不会与namespace冲突", func(t *testing.T) {
+		cacheManager := NewCacheManager(newTagslessScanStore(), WithTagIndex())
+		assert.ErrorIs(t, Set(ctx, cacheManager, tagIndexNamespace, "key", 1), ErrReservedNamespace)
+		assert.False(t, strings.HasPrefix(cacheManager.tagIndexPrefix("tag1"), cacheManager.namespacePrefix("tagindex")))
+	})
+
+	t.Run("索引写入失败", func(t *testing.T) {
+		var setErrors atomic.Int32
+		hooks := WithHooks(Hooks{OnSetError: func(context.Context, string, string, error) { setErrors.Add(1) }})
+		s := &failingIndexStore{tagslessScanStore: newTagslessScanStore()}
+
+		cacheManager := NewCacheManager(s, WithTagIndex(), hooks)
+		value, err, _ := Get(ctx, cacheManager, namespace, "index_failed", func() (string, error) {
+			return "value", nil
+		}, WithTags("tag1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, int32(1), setErrors.Load())
+		// 没有索引的值不会留在缓存中
+		exists, err := Exists(ctx, cacheManager, namespace, "index_failed")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+
+		strict := NewCacheManager(s, WithTagIndex(), WithStrictSet())
+		assert.ErrorIs(t, Set(ctx, strict, namespace, "index_failed", "value", WithTags("tag1")), errIndexFailed)
+	})
+
+	t.Run("store不能枚举key时panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewCacheManager(&tagslessStore{newGoCacheStore()}, WithTagIndex())
+		})
+	})
+}
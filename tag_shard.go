@@ -0,0 +1,60 @@
+package cacheable
+
+import (
+	"hash/fnv"
+	"strconv"
+)
+
+// WithTagShards 将每个tag按key的哈希拆分为k个子tag（tag#0 ~ tag#k-1），写入时只加入其中一个，
+// DeleteByTags时删除全部子tag，避免热门tag关联大量key导致单个tag集合过大。
+// 修改k会导致之前写入的子tag无法被删除，需要在上线前确定
+func WithTagShards(k int) ManagerOption {
+	return func(m *CacheManager) {
+		m.tagShards = k
+	}
+}
+
+// WithTagShardFunc 自定义tag分片使用的哈希函数，默认为fnv-1a
+func WithTagShardFunc(fn func(key string) uint32) ManagerOption {
+	return func(m *CacheManager) {
+		m.tagShardFunc = fn
+	}
+}
+
+func fnvHash(key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// shardTags 返回key写入时使用的子tag
+func (i *CacheManager) shardTags(key string, tags []string) []string {
+	if i.tagShards <= 1 || len(tags) == 0 {
+		return tags
+	}
+	hash := i.tagShardFunc
+	if hash == nil {
+		hash = fnvHash
+	}
+	suffix := "#" + strconv.Itoa(int(hash(key)%uint32(i.tagShards)))
+
+	sharded := make([]string, len(tags))
+	for n, tag := range tags {
+		sharded[n] = tag + suffix
+	}
+	return sharded
+}
+
+// expandTagShards 返回tag的全部子tag
+func (i *CacheManager) expandTagShards(tags []string) []string {
+	if i.tagShards <= 1 {
+		return tags
+	}
+	expanded := make([]string, 0, len(tags)*i.tagShards)
+	for _, tag := range tags {
+		for n := 0; n < i.tagShards; n++ {
+			expanded = append(expanded, tag+"#"+strconv.Itoa(n))
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,39 @@
+package cacheable
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagShards(t *testing.T) {
+	ctx := context.Background()
+	cacheManager := newTestGoCacheManager(t, WithTagShards(4))
+
+	var keys []string
+	usedTags := make(map[string]struct{})
+	for n := 0; n < 20; n++ {
+		key := fmt.Sprintf("shard_%d", n)
+		keys = append(keys, key)
+		_, _, _ = Get(ctx, cacheManager, namespace, key, func() (int, error) {
+			return n, nil
+		}, WithTags("global"))
+		usedTags[cacheManager.shardTags(cacheManager.buildKey(namespace, key), []string{"global"})[0]] = struct{}{}
+	}
+	// 20个key应分布在多个子tag中
+	assert.Greater(t, len(usedTags), 1)
+
+	count, _, err := PreviewDeleteByTags(ctx, cacheManager, []string{"global"})
+	assert.NoError(t, err)
+	assert.Equal(t, len(keys), count)
+
+	assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"global"}))
+	for _, key := range keys {
+		_, _, cached := Get(ctx, cacheManager, namespace, key, func() (int, error) {
+			return 0, nil
+		})
+		assert.False(t, cached, key)
+	}
+}
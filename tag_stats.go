@@ -0,0 +1,77 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// tagStatsSampleSize 统计剩余有效期时最多读取的key数量
+const tagStatsSampleSize = 100
+
+// TagStat tag关联的key数量和剩余有效期的统计
+type TagStat struct {
+	// Keys tag关联的key数量，可能包含已过期但还未从tag集合中移除的key
+	Keys int
+	// Sampled 读取了剩余有效期的key数量，最多为tagStatsSampleSize，不包括已过期的key。
+	// store没有实现TTLReader时为0，此时下面的字段都为0
+	Sampled int
+	// NoExpiration 抽样中没有过期时间的key数量，不计入下面的有效期统计
+	NoExpiration int
+	MinTTL       time.Duration
+	MaxTTL       time.Duration
+	AvgTTL       time.Duration
+}
+
+// TagStats 统计tag关联的key数量，store实现TTLReader时抽样统计这些key的剩余有效期，
+// 用于发现无限增长或没有过期时间的tag。key的来源与PreviewDeleteByTags一致，无法枚举时返回ErrNotSupported
+func (i *CacheManager) TagStats(ctx context.Context, tag string) (TagStat, error) {
+	var stat TagStat
+	keys, err := i.tagKeys(ctx, []string{tag})
+	if err != nil {
+		return stat, err
+	}
+	stat.Keys = len(keys)
+
+	reader, ok := i.cache.(TTLReader)
+	if !ok {
+		return stat, nil
+	}
+	if len(keys) > tagStatsSampleSize {
+		keys = keys[:tagStatsSampleSize]
+	}
+
+	var total time.Duration
+	for _, key := range keys {
+		ttl, err := reader.TTL(ctx, key)
+		if errors.Is(err, store.NotFound{}) {
+			continue
+		} else if err != nil {
+			return stat, err
+		}
+
+		stat.Sampled++
+		if ttl == 0 {
+			stat.NoExpiration++
+			continue
+		}
+		if stat.MinTTL == 0 || ttl < stat.MinTTL {
+			stat.MinTTL = ttl
+		}
+		if ttl > stat.MaxTTL {
+			stat.MaxTTL = ttl
+		}
+		total += ttl
+	}
+	if expiring := stat.Sampled - stat.NoExpiration; expiring > 0 {
+		stat.AvgTTL = total / time.Duration(expiring)
+	}
+	return stat, nil
+}
+
+// TagStats 统计tag关联的key数量和剩余有效期
+func TagStats(ctx context.Context, cacheManager *CacheManager, tag string) (TagStat, error) {
+	return cacheManager.TagStats(ctx, tag)
+}
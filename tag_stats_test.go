@@ -0,0 +1,64 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagStats(t *testing.T) {
+	ctx := context.Background()
+
+	fill := func(cacheManager *CacheManager) {
+		for key, expiration := range map[string]time.Duration{"key1": time.Minute, "key2": time.Hour} {
+			_, _, _ = Get(ctx, cacheManager, namespace, key, func() (string, error) {
+				return "value", nil
+			}, WithTags("stats"), WithExpiration(expiration))
+		}
+	}
+
+	t.Run("go-cache", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		fill(cacheManager)
+
+		stat, err := TagStats(ctx, cacheManager, "stats")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stat.Keys)
+		assert.Equal(t, 2, stat.Sampled)
+		assert.InDelta(t, time.Minute, stat.MinTTL, float64(time.Second))
+		assert.InDelta(t, time.Hour, stat.MaxTTL, float64(time.Second))
+		assert.InDelta(t, (time.Minute+time.Hour)/2, stat.AvgTTL, float64(time.Second))
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		redisStore, mr := newTestRedisStore(t)
+		cacheManager := NewCacheManager(redisStore)
+		fill(cacheManager)
+		// 没有过期时间的key
+		assert.NoError(t, redisStore.client.Persist(ctx, cacheManager.buildKey(namespace, "key2")).Err())
+
+		stat, err := TagStats(ctx, cacheManager, "stats")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stat.Keys)
+		assert.Equal(t, 2, stat.Sampled)
+		assert.Equal(t, 1, stat.NoExpiration)
+		assert.Equal(t, time.Minute, stat.MinTTL)
+		assert.Equal(t, time.Minute, stat.MaxTTL)
+		assert.Equal(t, time.Minute, stat.AvgTTL)
+
+		// tag集合中已过期的key不计入抽样
+		mr.Del(cacheManager.buildKey(namespace, "key1"))
+		stat, err = TagStats(ctx, cacheManager, "stats")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stat.Keys)
+		assert.Equal(t, 1, stat.Sampled)
+	})
+
+	t.Run("无法枚举tag时返回错误", func(t *testing.T) {
+		cacheManager := NewCacheManager(&tagslessStore{newGoCacheStore()})
+		_, err := TagStats(ctx, cacheManager, "stats")
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
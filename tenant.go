@@ -0,0 +1,77 @@
+package cacheable
+
+import (
+	"context"
+	"strings"
+)
+
+// tenantSegmentPrefix key和tag中租户的前缀，不带租户的namespace不能以它开头，避免与租户的key冲突
+const tenantSegmentPrefix = "t="
+
+// WithTenantFromContext 从ctx中获取租户，key中在namespace之前加入租户（prefix:t=tenant:namespace:key），
+// tag同样按租户隔离（t=tenant:tag），Delete、DeleteByTags、DeleteByPrefix、ClearNamespace等只作用于ctx中的租户。
+// fn返回空字符串时使用WithDefaultTenant设置的租户，没有设置时返回ErrMissingTenant，租户中包含:时返回ErrInvalidTenant。
+// 使用WithKeyBuilder时传给它的namespace为t=tenant:namespace
+func WithTenantFromContext(fn func(ctx context.Context) string) ManagerOption {
+	return func(m *CacheManager) {
+		m.tenantFunc = fn
+	}
+}
+
+// WithDefaultTenant 设置ctx中没有租户时使用的租户，见WithTenantFromContext
+func WithDefaultTenant(tenant string) ManagerOption {
+	return func(m *CacheManager) {
+		m.defaultTenant = tenant
+	}
+}
+
+// tenant 返回ctx中的租户，没有设置WithTenantFromContext时返回空字符串
+func (i *CacheManager) tenant(ctx context.Context) (string, error) {
+	if i.tenantFunc == nil {
+		return "", nil
+	}
+	tenant := i.tenantFunc(ctx)
+	if tenant == "" {
+		tenant = i.defaultTenant
+	}
+	if tenant == "" {
+		return "", ErrMissingTenant
+	}
+	if strings.Contains(tenant, ":") {
+		return "", ErrInvalidTenant
+	}
+	return tenant, nil
+}
+
+// checkNamespace 校验namespace不为空，并且可以从ctx中获取租户
+func (i *CacheManager) checkNamespace(ctx context.Context, namespace string) error {
+	if namespace == "" {
+		return ErrEmptyNamespace
+	}
+	if isReservedNamespace(namespace) {
+		return ErrReservedNamespace
+	}
+	_, err := i.tenant(ctx)
+	return err
+}
+
+// tenantNamespace 返回拼接key时使用的namespace，有租户时为t=tenant:namespace
+func (i *CacheManager) tenantNamespace(ctx context.Context, namespace string) string {
+	if tenant, _ := i.tenant(ctx); tenant != "" {
+		return tenantSegmentPrefix + tenant + ":" + namespace
+	}
+	return namespace
+}
+
+// tenantKey 和buildKey相同，key中加入ctx中的租户
+func (i *CacheManager) tenantKey(ctx context.Context, namespace string, key string) string {
+	return i.buildKey(i.tenantNamespace(ctx, namespace), key)
+}
+
+// tenantTags 返回按ctx中的租户隔离的tag
+func (i *CacheManager) tenantTags(ctx context.Context, tags []string) []string {
+	if tenant, _ := i.tenant(ctx); tenant != "" && len(tags) > 0 {
+		return namespaceTags(tenantSegmentPrefix+tenant, tags)
+	}
+	return tags
+}
@@ -0,0 +1,121 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+func withTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func tenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+func TestWithTenantFromContext(t *testing.T) {
+	ctx := context.Background()
+	ctxA, ctxB := withTenant(ctx, "a"), withTenant(ctx, "b")
+	ns := "tenant_ns"
+
+	get := func(ctx context.Context, cacheManager *CacheManager, key string, value string) (string, bool) {
+		v, err, cached := Get(ctx, cacheManager, ns, key, func() (string, error) {
+			return value, nil
+		}, WithTags("tenant_tag"))
+		assert.NoError(t, err)
+		return v, cached
+	}
+
+	t.Run("key按租户隔离", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithTenantFromContext(tenantFromContext))
+		get(ctxA, cacheManager, "key", "value a")
+		value, cached := get(ctxB, cacheManager, "key", "value b")
+		assert.False(t, cached)
+		assert.Equal(t, "value b", value)
+
+		value, cached = get(ctxA, cacheManager, "key", "")
+		assert.True(t, cached)
+		assert.Equal(t, "value a", value)
+		assert.Equal(t, loadDefaultKeyPrefix()+":t=a:"+ns+":key", cacheManager.tenantKey(ctxA, ns, "key"))
+	})
+
+	t.Run("没有租户时返回错误", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithTenantFromContext(tenantFromContext))
+		_, err, _ := Get(ctx, cacheManager, ns, "key", func() (string, error) {
+			return "value", nil
+		})
+		assert.ErrorIs(t, err, ErrMissingTenant)
+		assert.ErrorIs(t, Set(ctx, cacheManager, ns, "key", "value"), ErrMissingTenant)
+		assert.ErrorIs(t, Delete(ctx, cacheManager, ns, "key"), ErrMissingTenant)
+		assert.ErrorIs(t, DeleteByTags(ctx, cacheManager, []string{"tenant_tag"}), ErrMissingTenant)
+	})
+
+	t.Run("没有租户时使用默认租户", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithTenantFromContext(tenantFromContext), WithDefaultTenant("shared"))
+		get(ctx, cacheManager, "key", "value")
+		value, cached := get(withTenant(ctx, "shared"), cacheManager, "key", "")
+		assert.True(t, cached)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("删除只作用于当前租户", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithTenantFromContext(tenantFromContext))
+		for _, key := range []string{"delete", "by_tag", "prefix:1"} {
+			get(ctxA, cacheManager, key, "value a")
+			get(ctxB, cacheManager, key, "value b")
+		}
+
+		assert.NoError(t, Delete(ctxA, cacheManager, ns, "delete"))
+		assert.NoError(t, DeleteByTags(ctxA, cacheManager, []string{"tenant_tag"}))
+		assert.NoError(t, DeleteByPrefix(ctxA, cacheManager, ns, "prefix:"))
+
+		for _, key := range []string{"delete", "by_tag", "prefix:1"} {
+			exists, err := Exists(ctxA, cacheManager, ns, key)
+			assert.NoError(t, err)
+			assert.False(t, exists, key)
+			exists, err = Exists(ctxB, cacheManager, ns, key)
+			assert.NoError(t, err)
+			assert.True(t, exists, key)
+		}
+	})
+
+	t.Run("ClearNamespace只作用于当前租户", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithTenantFromContext(tenantFromContext))
+		get(ctxA, cacheManager, "clear", "value a")
+		get(ctxB, cacheManager, "clear", "value b")
+
+		assert.NoError(t, ClearNamespace(ctxB, cacheManager, ns))
+
+		exists, err := Exists(ctxA, cacheManager, ns, "clear")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = Exists(ctxB, cacheManager, ns, "clear")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("租户不能包含分隔符", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t, WithTenantFromContext(tenantFromContext))
+		assert.ErrorIs(t, Set(withTenant(ctx, "a:b"), cacheManager, ns, "key", "value"), ErrInvalidTenant)
+	})
+
+	t.Run("不会与不带租户的namespace冲突", func(t *testing.T) {
+		s := NewGoCacheStore(gocache.New(5*time.Minute, 10*time.Minute))
+		cacheManager := NewCacheManager(s, WithTenantFromContext(tenantFromContext))
+		untenanted := NewCacheManager(s)
+		get(ctxA, cacheManager, "untenanted", "value a")
+
+		//不带租户时，与租户a的namespace相同的前缀是保留的
+		assert.ErrorIs(t, ClearNamespace(ctx, untenanted, "t=a"), ErrReservedNamespace)
+		assert.NoError(t, ClearNamespace(ctx, untenanted, "a"))
+		_, cached := get(ctxA, cacheManager, "untenanted", "")
+		assert.True(t, cached)
+	})
+}
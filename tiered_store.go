@@ -0,0 +1,127 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+)
+
+// defaultL1Expiration 一级缓存默认的最长有效期
+const defaultL1Expiration = time.Minute
+
+// TieredStore 由本地的一级缓存（例如go-cache）和远程的二级缓存（例如redis）组成。
+// 读取时依次查找l1和l2，l2命中时回填l1；写入、删除和tag失效同时作用于两级缓存。
+// l2实现TagKeysReader时，tag失效同样会删除回填到l1的key。l1的有效期不超过l1Expiration，其他实例的l1不会收到删除通知，最多在l1Expiration内读到旧值
+type TieredStore struct {
+	l1           store.StoreInterface
+	l2           store.StoreInterface
+	l1Expiration time.Duration
+}
+
+func NewTieredStore(l1 store.StoreInterface, l2 store.StoreInterface) *TieredStore {
+	return &TieredStore{
+		l1:           l1,
+		l2:           l2,
+		l1Expiration: defaultL1Expiration,
+	}
+}
+
+// NewTieredCacheManager 使用l1和l2组成的TieredStore创建CacheManager，l1的有效期通过WithL1Expiration设置
+func NewTieredCacheManager(l1 store.StoreInterface, l2 store.StoreInterface, opts ...ManagerOption) *CacheManager {
+	return NewCacheManager(NewTieredStore(l1, l2), opts...)
+}
+
+// WithL1Expiration 设置TieredStore一级缓存的最长有效期，默认为1分钟，store不是TieredStore时不生效
+func WithL1Expiration(expiration time.Duration) ManagerOption {
+	return func(m *CacheManager) {
+		if tiered, ok := m.cache.(*TieredStore); ok && expiration > 0 {
+			tiered.l1Expiration = expiration
+		}
+	}
+}
+
+func (s *TieredStore) Get(ctx context.Context, key any) (any, error) {
+	value, _, err := s.GetWithTTL(ctx, key)
+	return value, err
+}
+
+// GetWithTTL l1的错误按未命中处理，继续读取l2
+func (s *TieredStore) GetWithTTL(ctx context.Context, key any) (any, time.Duration, error) {
+	if value, ttl, err := s.l1.GetWithTTL(ctx, key); err == nil {
+		return value, ttl, nil
+	}
+
+	value, ttl, err := s.l2.GetWithTTL(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	//回填失败不影响本次读取
+	_ = s.l1.Set(ctx, key, value, store.WithExpiration(s.l1ExpirationFor(ttl)))
+	return value, ttl, nil
+}
+
+func (s *TieredStore) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	if err := s.l2.Set(ctx, key, value, options...); err != nil {
+		return err
+	}
+	opts := store.ApplyOptions(options...)
+	l1Options := []store.Option{store.WithExpiration(s.l1ExpirationFor(opts.Expiration))}
+	if len(opts.Tags) > 0 {
+		l1Options = append(l1Options, store.WithTags(opts.Tags))
+	}
+	return s.l1.Set(ctx, key, value, l1Options...)
+}
+
+func (s *TieredStore) Delete(ctx context.Context, key any) error {
+	return errors.Join(s.l2.Delete(ctx, key), s.l1.Delete(ctx, key))
+}
+
+// TagKeys 从l2读取tag关联的key，l2命中后回填的l1没有tag，l2中的tag是完整的。
+// l2没有实现TagKeysReader时返回ErrNotSupported
+func (s *TieredStore) TagKeys(ctx context.Context, tag string) ([]string, error) {
+	reader, ok := s.l2.(TagKeysReader)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return reader.TagKeys(ctx, tag)
+}
+
+// Invalidate l2命中后回填的l1没有tag，l2实现了TagKeysReader时先读取tag关联的key，失效后从l1删除这些key
+func (s *TieredStore) Invalidate(ctx context.Context, options ...store.InvalidateOption) error {
+	var keys []string
+	var errs []error
+	if reader, ok := s.l2.(TagKeysReader); ok {
+		for _, tag := range store.ApplyInvalidateOptions(options...).Tags {
+			keysOfTag, err := reader.TagKeys(ctx, tag)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			keys = append(keys, keysOfTag...)
+		}
+	}
+
+	errs = append(errs, s.l2.Invalidate(ctx, options...), s.l1.Invalidate(ctx, options...))
+	for _, key := range keys {
+		errs = append(errs, s.l1.Delete(ctx, key))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *TieredStore) Clear(ctx context.Context) error {
+	return errors.Join(s.l2.Clear(ctx), s.l1.Clear(ctx))
+}
+
+func (s *TieredStore) GetType() string {
+	return "tiered"
+}
+
+// l1ExpirationFor 返回写入l1的有效期，不超过l1Expiration，也不超过l2中的剩余有效期，0表示l2没有过期时间
+func (s *TieredStore) l1ExpirationFor(expiration time.Duration) time.Duration {
+	if expiration <= 0 || expiration > s.l1Expiration {
+		return s.l1Expiration
+	}
+	return expiration
+}
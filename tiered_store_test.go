@@ -0,0 +1,109 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredCacheManager(t *testing.T) {
+	ctx := context.Background()
+	l1 := newGoCacheStore()
+	l2, _ := newTestRedisStore(t)
+	cacheManager := NewTieredCacheManager(l1, l2, WithL1Expiration(10*time.Second))
+	calls := 0
+	fn := func() (string, error) {
+		calls++
+		return "value", nil
+	}
+
+	t.Run("都未命中时写入两级缓存", func(t *testing.T) {
+		value, err, cached := Get(ctx, cacheManager, namespace, "tiered_key", fn, WithExpiration(time.Hour))
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, 1, calls)
+
+		storeKey := cacheManager.buildKey(namespace, "tiered_key")
+		_, l1TTL, err := l1.GetWithTTL(ctx, storeKey)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, l1TTL, 10*time.Second)
+		_, l2TTL, err := l2.GetWithTTL(ctx, storeKey)
+		assert.NoError(t, err)
+		assert.Greater(t, l2TTL, 10*time.Second)
+	})
+
+	t.Run("l1命中", func(t *testing.T) {
+		_, err, cached := Get(ctx, cacheManager, namespace, "tiered_key", fn)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("l2命中时回填l1", func(t *testing.T) {
+		storeKey := cacheManager.buildKey(namespace, "tiered_key")
+		assert.NoError(t, l1.Delete(ctx, storeKey))
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "tiered_key", fn)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, 1, calls)
+
+		_, l1TTL, err := l1.GetWithTTL(ctx, storeKey)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, l1TTL, 10*time.Second)
+	})
+
+	t.Run("删除和tag失效作用于两级缓存", func(t *testing.T) {
+		storeKey := cacheManager.buildKey(namespace, "tiered_key")
+		assert.NoError(t, Delete(ctx, cacheManager, namespace, "tiered_key"))
+		_, err := l1.Get(ctx, storeKey)
+		assert.Error(t, err)
+		_, err = l2.Get(ctx, storeKey)
+		assert.Error(t, err)
+
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "tiered_tagged", "value", WithTags("tiered_tag")))
+		assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"tiered_tag"}))
+		_, err = l1.Get(ctx, cacheManager.buildKey(namespace, "tiered_tagged"))
+		assert.Error(t, err)
+		_, err = l2.Get(ctx, cacheManager.buildKey(namespace, "tiered_tagged"))
+		assert.Error(t, err)
+	})
+	t.Run("l2命中回填l1后按tag失效", func(t *testing.T) {
+		writer := NewTieredCacheManager(newGoCacheStore(), l2)
+		reader := NewTieredCacheManager(newGoCacheStore(), l2)
+		assert.NoError(t, Set(ctx, writer, namespace, "tiered_backfill", "old", WithTags("tiered_backfill_tag")))
+
+		value, err, cached := Get(ctx, reader, namespace, "tiered_backfill", func() (string, error) {
+			return "new", nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "old", value)
+
+		assert.NoError(t, DeleteByTags(ctx, reader, []string{"tiered_backfill_tag"}))
+		value, err, cached = Get(ctx, reader, namespace, "tiered_backfill", func() (string, error) {
+			return "new", nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "new", value)
+	})
+
+	t.Run("从l2读取tag关联的key", func(t *testing.T) {
+		//包装后的TieredStore同样可以预览
+		wrapped := NewCacheManager(struct{ *TieredStore }{NewTieredStore(newGoCacheStore(), l2)})
+		assert.NoError(t, Set(ctx, wrapped, namespace, "tiered_preview", "value", WithTags("tiered_preview_tag")))
+
+		count, keys, err := PreviewDeleteByTags(ctx, wrapped, []string{"tiered_preview_tag"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, []string{wrapped.buildKey(namespace, "tiered_preview")}, keys)
+
+		_, err = NewTieredStore(newGoCacheStore(), newGoCacheStore()).TagKeys(ctx, "tiered_preview_tag")
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
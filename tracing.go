@@ -0,0 +1,40 @@
+package cacheable
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/diemus/go-cacheable"
+
+// WithTracerProvider 使用tp创建OpenTelemetry span：Get为cache.get（包含cache.hit和cache.fn_invoked属性），
+// 调用fn为其子span cache.fn，写入、删除分别为cache.set、cache.delete和cache.delete_by_tags。没有设置时不创建span
+func WithTracerProvider(tp trace.TracerProvider) ManagerOption {
+	return func(m *CacheManager) {
+		m.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// startSpan 创建span，namespace不为空时记录为cache.namespace属性，没有设置WithTracerProvider时返回原ctx和不记录任何内容的span
+func (i *CacheManager) startSpan(ctx context.Context, name string, namespace string) (context.Context, trace.Span) {
+	if i.tracer == nil {
+		return ctx, noop.Span{}
+	}
+	if namespace == "" {
+		return i.tracer.Start(ctx, name)
+	}
+	return i.tracer.Start(ctx, name, trace.WithAttributes(attribute.String("cache.namespace", namespace)))
+}
+
+// endSpan 记录err并结束span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
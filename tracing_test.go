@@ -0,0 +1,87 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	cacheManager := newTestGoCacheManager(t, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+	// ended 返回上次调用之后结束的span
+	seen := 0
+	ended := func() []sdktrace.ReadOnlySpan {
+		spans := recorder.Ended()[seen:]
+		seen += len(spans)
+		return spans
+	}
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		result := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			result[kv.Key] = kv.Value
+		}
+		return result
+	}
+
+	t.Run("未命中时fn为子span", func(t *testing.T) {
+		_, _, _ = Get(ctx, cacheManager, namespace, "traced", func() (string, error) {
+			return "value", nil
+		})
+		spans := ended()
+		assert.Len(t, spans, 2)
+		fnSpan, getSpan := spans[0], spans[1]
+		assert.Equal(t, "cache.fn", fnSpan.Name())
+		assert.Equal(t, "cache.get", getSpan.Name())
+		assert.Equal(t, getSpan.SpanContext().SpanID(), fnSpan.Parent().SpanID())
+
+		attrs := attributes(getSpan)
+		assert.Equal(t, namespace, attrs["cache.namespace"].AsString())
+		assert.False(t, attrs["cache.hit"].AsBool())
+		assert.True(t, attrs["cache.fn_invoked"].AsBool())
+	})
+
+	t.Run("命中", func(t *testing.T) {
+		_, _, _ = Get(ctx, cacheManager, namespace, "traced", func() (string, error) {
+			return "value", nil
+		})
+		spans := ended()
+		assert.Len(t, spans, 1)
+		attrs := attributes(spans[0])
+		assert.True(t, attrs["cache.hit"].AsBool())
+		assert.False(t, attrs["cache.fn_invoked"].AsBool())
+	})
+
+	t.Run("fn返回错误", func(t *testing.T) {
+		fnErr := errors.New("db error")
+		_, _, _ = Get(ctx, cacheManager, namespace, "traced_error", func() (string, error) {
+			return "", fnErr
+		})
+		for _, span := range ended() {
+			assert.Equal(t, codes.Error, span.Status().Code)
+		}
+	})
+
+	t.Run("删除", func(t *testing.T) {
+		assert.NoError(t, Delete(ctx, cacheManager, namespace, "traced"))
+		assert.NoError(t, DeleteByTags(ctx, cacheManager, []string{"traced_tag"}))
+		spans := ended()
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "cache.delete", spans[0].Name())
+		assert.Equal(t, "cache.delete_by_tags", spans[1].Name())
+		assert.Equal(t, []string{"traced_tag"}, attributes(spans[1])["cache.tags"].AsStringSlice())
+	})
+
+	t.Run("未设置时不创建span", func(t *testing.T) {
+		ctx, span := newTestGoCacheManager(t).startSpan(ctx, "cache.get", namespace)
+		assert.False(t, span.IsRecording())
+		assert.Equal(t, context.Background(), ctx)
+	})
+}
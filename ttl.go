@@ -0,0 +1,24 @@
+package cacheable
+
+import (
+	"context"
+	"time"
+)
+
+// TTL 返回key剩余的有效期，key不存在时返回store.NotFound，没有过期时间时返回0。
+// 返回的是store中的实际有效期，开启WithStaleWhileRevalidate或WithServeStaleOnError时包含保留旧值的时间。
+// 需要store实现TTLReader，否则返回ErrTTLUnsupported
+func (i *CacheManager) TTL(ctx context.Context, namespace string, key string) (time.Duration, error) {
+	if err := i.checkNamespace(ctx, namespace); err != nil {
+		return 0, err
+	}
+	reader, ok := i.cache.(TTLReader)
+	if !ok {
+		return 0, ErrTTLUnsupported
+	}
+	return reader.TTL(ctx, i.tenantKey(ctx, namespace, key))
+}
+
+func TTL(ctx context.Context, cacheManager *CacheManager, namespace string, key string) (time.Duration, error) {
+	return cacheManager.TTL(ctx, namespace, key)
+}
@@ -0,0 +1,41 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _ := newTestRedisStore(t)
+	managers := map[string]*CacheManager{
+		"gocache": newTestGoCacheManager(t),
+		"redis":   NewCacheManager(redisStore),
+	}
+
+	for name, cacheManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			_, _, _ = Get(ctx, cacheManager, namespace, "ttl_key", func() (string, error) {
+				return "value", nil
+			}, WithExpiration(time.Minute))
+
+			ttl, err := TTL(ctx, cacheManager, namespace, "ttl_key")
+			assert.NoError(t, err)
+			assert.InDelta(t, float64(time.Minute), float64(ttl), float64(time.Second))
+
+			_, err = TTL(ctx, cacheManager, namespace, "ttl_missing")
+			assert.ErrorIs(t, err, store.NotFound{})
+		})
+	}
+
+	t.Run("store不支持读取有效期", func(t *testing.T) {
+		cacheManager := NewCacheManager(newGoCacheStore())
+		_, err := TTL(ctx, cacheManager, namespace, "ttl_key")
+		assert.ErrorIs(t, err, ErrTTLUnsupported)
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
@@ -0,0 +1,31 @@
+package cacheable
+
+import "context"
+
+// TypedCache 绑定了namespace和值类型的缓存，适合在repository中为每种实体创建一个。
+// 通过TypedCache写入的tag会自动加上namespace前缀，DeleteByTags只会影响该namespace下的缓存，
+// 不同TypedCache使用同名tag时互不影响
+type TypedCache[T any] struct {
+	cacheManager *CacheManager
+	namespace    string
+}
+
+func NewTypedCache[T any](cacheManager *CacheManager, namespace string) *TypedCache[T] {
+	return &TypedCache[T]{
+		cacheManager: cacheManager,
+		namespace:    namespace,
+	}
+}
+
+func (c *TypedCache[T]) Get(ctx context.Context, key string, fn func() (T, error), opts ...Option) (value T, err error, cached bool) {
+	return Get(ctx, c.cacheManager, c.namespace, key, fn, append(opts[:len(opts):len(opts)], withTagNamespace(c.namespace))...)
+}
+
+func (c *TypedCache[T]) Delete(ctx context.Context, key string) error {
+	return c.cacheManager.Delete(ctx, c.namespace, key)
+}
+
+// DeleteByTags 删除该namespace下带有指定tag的缓存
+func (c *TypedCache[T]) DeleteByTags(ctx context.Context, tags ...string) error {
+	return c.cacheManager.DeleteByTags(ctx, namespaceTags(c.namespace, tags))
+}
@@ -0,0 +1,70 @@
+package cacheable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedCache(t *testing.T) {
+	ctx := context.Background()
+
+	type profile struct {
+		Name string
+	}
+	type order struct {
+		ID int
+	}
+
+	t.Run("读取和删除", func(t *testing.T) {
+		profiles := NewTypedCache[profile](MockCacheManager, "typed_profiles")
+		_, err, _ := profiles.Get(ctx, "1", func() (profile, error) {
+			return profile{Name: "alice"}, nil
+		})
+		assert.NoError(t, err)
+
+		value, _, cached := profiles.Get(ctx, "1", func() (profile, error) {
+			return profile{Name: "bob"}, nil
+		})
+		assert.True(t, cached)
+		assert.Equal(t, profile{Name: "alice"}, value)
+
+		assert.NoError(t, profiles.Delete(ctx, "1"))
+		_, _, cached = profiles.Get(ctx, "1", func() (profile, error) {
+			return profile{Name: "bob"}, nil
+		})
+		assert.False(t, cached)
+	})
+
+	t.Run("标签删除只影响当前namespace", func(t *testing.T) {
+		profiles := NewTypedCache[profile](MockCacheManager, "typed_tag_profiles")
+		orders := NewTypedCache[order](MockCacheManager, "typed_tag_orders")
+
+		_, _, _ = profiles.Get(ctx, "1", func() (profile, error) {
+			return profile{Name: "alice"}, nil
+		}, WithTags("user:1"))
+		_, _, _ = orders.Get(ctx, "1", func() (order, error) {
+			return order{ID: 1}, nil
+		}, WithDynamicTags(func() []string {
+			return []string{"user:1"}
+		}))
+
+		assert.NoError(t, profiles.DeleteByTags(ctx, "user:1"))
+
+		_, _, cached := profiles.Get(ctx, "1", func() (profile, error) {
+			return profile{Name: "bob"}, nil
+		})
+		assert.False(t, cached)
+		_, _, cached = orders.Get(ctx, "1", func() (order, error) {
+			return order{ID: 2}, nil
+		})
+		assert.True(t, cached)
+
+		assert.NoError(t, orders.DeleteByTags(ctx, "user:1"))
+		_, _, cached = orders.Get(ctx, "1", func() (order, error) {
+			return order{ID: 2}, nil
+		})
+		assert.False(t, cached)
+	})
+}
@@ -0,0 +1,15 @@
+package cacheable
+
+// WithVersion 为缓存值记录版本，读取时版本与v不一致（包括没有记录版本的旧缓存）视为缓存不存在，重新调用fn并覆盖，
+// 用于缓存的结构发生变化时让该调用点之前写入的缓存全部失效，而不需要修改key。
+// 版本记录在缓存值的头部，key不变，Delete、DeleteByTags仍然可以删除旧版本的缓存。不使用WithVersion读取时不校验版本
+func WithVersion(v string) Option {
+	return func(o *Options) {
+		o.version = v
+	}
+}
+
+// versionMismatch 判断缓存的版本是否与读取时WithVersion设置的版本不一致
+func (e *entry) versionMismatch(version string) bool {
+	return version != "" && e.version != version
+}
@@ -0,0 +1,99 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithVersion(t *testing.T) {
+	ctx := context.Background()
+	type userV1 struct {
+		Name string
+	}
+	type userV2 struct {
+		FirstName string
+		LastName  string
+	}
+
+	t.Run("版本不一致时重新调用fn", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, namespace, "version_user", func() (userV1, error) {
+			return userV1{Name: "Alice Smith"}, nil
+		}, WithVersion("v1"))
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "version_user", func() (userV2, error) {
+			return userV2{FirstName: "Alice", LastName: "Smith"}, nil
+		}, WithVersion("v2"))
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, userV2{FirstName: "Alice", LastName: "Smith"}, value)
+
+		value, err, cached = Get(ctx, cacheManager, namespace, "version_user", func() (userV2, error) {
+			return userV2{}, errors.New("should not be called")
+		}, WithVersion("v2"))
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "Alice", value.FirstName)
+	})
+
+	t.Run("没有记录版本的旧缓存视为不一致", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "version_legacy", "legacy"))
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "version_legacy", func() (string, error) {
+			return "new", nil
+		}, WithVersion("v1"))
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "new", value)
+	})
+
+	t.Run("读取时不设置版本不校验", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "version_unchecked", "value", WithVersion("v1")))
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "version_unchecked", func() (string, error) {
+			return "", errors.New("should not be called")
+		})
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("旧版本缓存的错误同样失效", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, namespace, "version_negative", func() (string, error) {
+			return "", ErrNotFound
+		}, WithVersion("v1"), WithNegativeCache(time.Minute))
+
+		value, err, cached := Get(ctx, cacheManager, namespace, "version_negative", func() (string, error) {
+			return "created", nil
+		}, WithVersion("v2"))
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "created", value)
+	})
+
+	t.Run("MGet同样校验版本", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		assert.NoError(t, Set(ctx, cacheManager, namespace, "version_mget", "old", WithVersion("v1")))
+
+		values, err, hits := MGet(ctx, cacheManager, namespace, []string{"version_mget"}, func(missing []string) (map[string]string, error) {
+			return map[string]string{"version_mget": "new"}, nil
+		}, WithVersion("v2"))
+		assert.NoError(t, err)
+		assert.Empty(t, hits)
+		assert.Equal(t, "new", values["version_mget"])
+	})
+
+	t.Run("编码和解码", func(t *testing.T) {
+		e := &entry{version: "v2", payload: []byte("payload")}
+		decoded, err := decodeEntry(encodeEntry(e))
+		assert.NoError(t, err)
+		assert.Equal(t, e, decoded)
+	})
+}
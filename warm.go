@@ -0,0 +1,134 @@
+package cacheable
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// defaultWarmConcurrency 预热默认的并发数
+const defaultWarmConcurrency = 4
+
+// WarmTask 一个预热任务，通过NewWarmTask创建，直接构造的任务执行时计为失败
+type WarmTask struct {
+	Namespace string
+	Key       string
+	// Priority 越大越先执行，只对Warm有效
+	Priority int
+
+	get func(ctx context.Context, cacheManager *CacheManager) (cached bool, err error)
+}
+
+// NewWarmTask 创建预热任务，执行时与Get相同，缓存已存在时不会调用fn
+func NewWarmTask[T any](namespace string, key string, fn func() (T, error), opts ...Option) WarmTask {
+	return WarmTask{
+		Namespace: namespace,
+		Key:       key,
+		get: func(ctx context.Context, cacheManager *CacheManager) (bool, error) {
+			_, err, cached := Get(ctx, cacheManager, namespace, key, fn, opts...)
+			return cached, err
+		},
+	}
+}
+
+// run 执行任务，get为空说明任务不是通过NewWarmTask创建的
+func (t WarmTask) run(ctx context.Context, cacheManager *CacheManager) (bool, error) {
+	if t.get == nil {
+		return false, ErrInvalidWarmTask
+	}
+	return t.get(ctx, cacheManager)
+}
+
+// WithPriority 返回设置了优先级的任务
+func (t WarmTask) WithPriority(priority int) WarmTask {
+	t.Priority = priority
+	return t
+}
+
+// WarmOptions 预热的配置
+type WarmOptions struct {
+	// Concurrency 同时执行的任务数，默认为4，用于控制预热对后端的压力
+	Concurrency int
+	// OnProgress 每个任务完成后调用，传入当前的进度，调用是串行的
+	OnProgress func(report WarmReport)
+}
+
+// WarmReport 预热的进度
+type WarmReport struct {
+	// Warmed 调用fn并写入缓存的任务数
+	Warmed int
+	// Cached 缓存已存在，没有调用fn的任务数
+	Cached int
+	// Failed 执行失败的任务数
+	Failed int
+}
+
+// Warm 按优先级从高到低执行预热任务，优先级相同时保持原有顺序。ctx取消后不再开始新的任务，
+// 等待已经开始的任务结束后返回当前的进度和ctx.Err()，单个任务失败不会中断预热
+func Warm(ctx context.Context, cacheManager *CacheManager, tasks []WarmTask, options WarmOptions) (WarmReport, error) {
+	sorted := make([]WarmTask, len(tasks))
+	copy(sorted, tasks)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].Priority > sorted[b].Priority
+	})
+
+	n := 0
+	return WarmFromIterator(ctx, cacheManager, func() (WarmTask, bool) {
+		if n >= len(sorted) {
+			return WarmTask{}, false
+		}
+		n++
+		return sorted[n-1], true
+	}, options)
+}
+
+// WarmFromIterator 和Warm相同，任务由next逐个提供，按提供的顺序执行，next返回false时结束。
+// 适合任务数量很大、不适合一次性生成的场景，next只会在调度goroutine中调用
+func WarmFromIterator(ctx context.Context, cacheManager *CacheManager, next func() (WarmTask, bool), options WarmOptions) (WarmReport, error) {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultWarmConcurrency
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		report WarmReport
+	)
+	sem := make(chan struct{}, concurrency)
+dispatch:
+	for ctx.Err() == nil {
+		task, ok := next()
+		if !ok {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			cached, err := task.run(ctx, cacheManager)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				report.Failed++
+			case cached:
+				report.Cached++
+			default:
+				report.Warmed++
+			}
+			if options.OnProgress != nil {
+				options.OnProgress(report)
+			}
+		}()
+	}
+	wg.Wait()
+	return report, ctx.Err()
+}
@@ -0,0 +1,129 @@
+package cacheable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarm(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("按优先级执行并统计进度", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		_, _, _ = Get(ctx, cacheManager, "warm", "cached", func() (string, error) {
+			return "value", nil
+		})
+
+		var mu sync.Mutex
+		var order []string
+		task := func(key string, err error) WarmTask {
+			return NewWarmTask("warm", key, func() (string, error) {
+				mu.Lock()
+				order = append(order, key)
+				mu.Unlock()
+				return key, err
+			})
+		}
+		tasks := []WarmTask{
+			task("low", nil),
+			task("failed", errors.New("db error")).WithPriority(5),
+			task("critical", nil).WithPriority(10),
+			task("cached", nil).WithPriority(1),
+		}
+
+		var progress []WarmReport
+		report, err := Warm(ctx, cacheManager, tasks, WarmOptions{
+			Concurrency: 1,
+			OnProgress: func(report WarmReport) {
+				progress = append(progress, report)
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, WarmReport{Warmed: 2, Cached: 1, Failed: 1}, report)
+		assert.Equal(t, []string{"critical", "failed", "low"}, order)
+		assert.Len(t, progress, 4)
+		assert.Equal(t, report, progress[3])
+
+		_, _, cached := Get(ctx, cacheManager, "warm", "critical", func() (string, error) {
+			return "", nil
+		})
+		assert.True(t, cached)
+	})
+
+	t.Run("限制并发数", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		var running, maxRunning atomic.Int32
+		n := 0
+		report, err := WarmFromIterator(ctx, cacheManager, func() (WarmTask, bool) {
+			if n >= 8 {
+				return WarmTask{}, false
+			}
+			n++
+			value := n
+			return NewWarmTask("warm_concurrency", fmt.Sprint(value), func() (int, error) {
+				current := running.Add(1)
+				defer running.Add(-1)
+				for {
+					prev := maxRunning.Load()
+					if current <= prev || maxRunning.CompareAndSwap(prev, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return value, nil
+			}), true
+		}, WarmOptions{Concurrency: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, 8, report.Warmed)
+		assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	})
+
+	t.Run("直接构造的任务计为失败", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		tasks := []WarmTask{
+			{Namespace: "warm_invalid", Key: "literal"},
+			NewWarmTask("warm_invalid", "valid", func() (string, error) {
+				return "value", nil
+			}),
+		}
+
+		report, err := Warm(ctx, cacheManager, tasks, WarmOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, WarmReport{Warmed: 1, Failed: 1}, report)
+
+		sent := false
+		report, err = WarmFromIterator(ctx, cacheManager, func() (WarmTask, bool) {
+			if sent {
+				return WarmTask{}, false
+			}
+			sent = true
+			return WarmTask{}, true
+		}, WarmOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, WarmReport{Failed: 1}, report)
+	})
+
+	t.Run("ctx取消后停止", func(t *testing.T) {
+		cacheManager := newTestGoCacheManager(t)
+		cancelCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		tasks := make([]WarmTask, 0, 10)
+		for n := 0; n < 10; n++ {
+			tasks = append(tasks, NewWarmTask("warm_cancel", fmt.Sprint(n), func() (int, error) {
+				cancel()
+				return n, nil
+			}))
+		}
+
+		report, err := Warm(cancelCtx, cacheManager, tasks, WarmOptions{Concurrency: 1})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, report.Warmed+report.Failed, 10)
+	})
+}